@@ -1,9 +1,20 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"net/http"
 	"os"
 
+	"github.com/eysteinn/driftline/services/api/internal/credits"
+	"github.com/eysteinn/driftline/services/api/internal/database"
+	"github.com/eysteinn/driftline/services/api/internal/handlers"
+	"github.com/eysteinn/driftline/services/api/internal/middleware"
+	"github.com/eysteinn/driftline/services/api/internal/models"
+	"github.com/eysteinn/driftline/services/api/internal/observability"
+	"github.com/eysteinn/driftline/services/api/internal/payments"
+	"github.com/eysteinn/driftline/services/api/internal/queue"
 	"github.com/gin-gonic/gin"
 )
 
@@ -14,8 +25,23 @@ func main() {
 		port = "8000"
 	}
 
+	payments.Init()
+
+	shutdownTracing, err := observability.InitTracing(context.Background(), os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"))
+	if err != nil {
+		log.Fatalf("Failed to initialize tracing: %v", err)
+	}
+	defer shutdownTracing(context.Background())
+
 	// Initialize Gin router
 	router := gin.Default()
+	router.Use(middleware.RequestID(), observability.Propagate(), observability.Middleware())
+	router.GET("/metrics", middleware.RequireMetricsToken(), observability.Handler())
+
+	// Stripe requires the raw request body for signature verification, so
+	// this is registered outside the v1 group rather than behind any
+	// body-parsing middleware.
+	router.POST("/webhooks/stripe", handlers.StripeWebhook)
 
 	// Health check endpoint
 	router.GET("/health", func(c *gin.Context) {
@@ -25,29 +51,123 @@ func main() {
 		})
 	})
 
+	// /health/live reports whether the process is up, for Kubernetes'
+	// liveness probe - it never checks dependencies, so a slow Postgres or
+	// Redis doesn't get the pod killed and restarted.
+	router.GET("/health/live", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "live"})
+	})
+
+	// /health/ready additionally checks the database and Redis, for
+	// Kubernetes' readiness probe - a pod that can't reach its dependencies
+	// should stop receiving traffic without being restarted.
+	router.GET("/health/ready", func(c *gin.Context) {
+		if database.DB == nil || database.DB.PingContext(c.Request.Context()) != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "not ready", "reason": "database unreachable"})
+			return
+		}
+		if queue.RedisClient == nil || queue.RedisClient.Ping(c.Request.Context()).Err() != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "not ready", "reason": "redis unreachable"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "ready"})
+	})
+
 	// API version 1 routes
 	v1 := router.Group("/v1")
 	{
 		// Auth routes
-		v1.POST("/auth/register", func(c *gin.Context) {
-			c.JSON(501, gin.H{"message": "Not implemented yet"})
-		})
-		v1.POST("/auth/login", func(c *gin.Context) {
-			c.JSON(501, gin.H{"message": "Not implemented yet"})
-		})
+		v1.POST("/auth/register", handlers.Register)
+		v1.POST("/auth/login", handlers.Login)
+		v1.POST("/auth/refresh", handlers.RefreshToken)
+		v1.POST("/auth/logout", handlers.Logout)
 
-		// Mission routes
-		v1.POST("/missions", func(c *gin.Context) {
-			c.JSON(501, gin.H{"message": "Not implemented yet"})
-		})
-		v1.GET("/missions", func(c *gin.Context) {
-			c.JSON(501, gin.H{"message": "Not implemented yet"})
-		})
-		v1.GET("/missions/:id", func(c *gin.Context) {
-			c.JSON(501, gin.H{"message": "Not implemented yet"})
-		})
+		// Mission routes. Dashboard clients authenticate with their
+		// session's JWT; scripted/integration clients can use a scoped API
+		// key instead - see models.ScopeMissions*.
+		// CreateMission carries middleware.Idempotency() so a client retrying
+		// after a timed-out response (having possibly already reserved
+		// credits and enqueued a job) replays the original mission instead
+		// of creating a duplicate one.
+		v1.POST("/missions", middleware.RequireAuthOrScope(models.ScopeMissionsWrite), middleware.Idempotency(), handlers.CreateMission)
+		v1.GET("/missions", middleware.RequireAuthOrScope(models.ScopeMissionsRead), handlers.ListMissions)
+		v1.GET("/missions/:id", middleware.RequireAuthOrScope(models.ScopeMissionsRead), handlers.GetMission)
+		v1.DELETE("/missions/:id", middleware.RequireAuthOrScope(models.ScopeMissionsWrite), handlers.DeleteMission)
+		v1.GET("/missions/:id/status", middleware.RequireAuthOrScope(models.ScopeMissionsRead), handlers.GetMissionStatus)
+		v1.GET("/missions/:id/results", middleware.RequireAuthOrScope(models.ScopeMissionsRead), handlers.GetMissionResults)
+		v1.GET("/missions/:id/download", middleware.RequireAuthOrScope(models.ScopeMissionsDownload), handlers.DownloadMissionResults)
+		v1.GET("/missions/:id/attempts", middleware.RequireAuthOrScope(models.ScopeMissionsRead), handlers.GetMissionAttempts)
+		v1.GET("/missions/:id/events", middleware.RequireAuthOrScope(models.ScopeMissionsRead), handlers.StreamMissionEvents)
+		v1.POST("/missions/:id/rejudge", middleware.RequireAuthOrScope(models.ScopeMissionsWrite), handlers.RejudgeMission)
+		v1.GET("/missions/:id/result.geojson", middleware.RequireAuthOrScope(models.ScopeMissionsRead), handlers.GetMissionResultGeoJSON)
+		v1.GET("/missions/:id/results/url", middleware.RequireAuthOrScope(models.ScopeMissionsDownload), handlers.GetMissionResultURL)
+		v1.POST("/missions/:id/upload-url", middleware.RequireWorkerAuth(), handlers.GetMissionUploadURL)
+		v1.POST("/missions/:id/complete", middleware.RequireWorkerAuth(), handlers.CompleteMission)
+
+		// Credit routes. Dashboard clients authenticate with their session's
+		// JWT; scripted/integration clients can use a scoped API key instead.
+		v1.GET("/credits/balance", middleware.RequireAuthOrScope(models.ScopeCreditsRead), handlers.GetCreditBalance)
+		v1.GET("/credits/transactions", middleware.RequireAuthOrScope(models.ScopeCreditsRead), handlers.GetCreditTransactions)
+		v1.GET("/credits/ledger", middleware.RequireAuthOrScope(models.ScopeCreditsRead), handlers.GetCreditLedger)
+		v1.GET("/credits/packages", handlers.ListCreditPackages)
+
+		// Credit-mutating routes carry middleware.Idempotency() so a client
+		// retrying a timed-out request (e.g. after a Stripe charge actually
+		// went through) replays the original response instead of double
+		// charging or double granting credits.
+		v1.POST("/credits/purchase", middleware.RequireAuthOrScope(models.ScopeCreditsWrite), middleware.Idempotency(), handlers.PurchaseCredits)
+		// AddCredits grants free credits outright rather than charging for
+		// them, so it stays gated on the admin:* scope - credits:write only
+		// covers self-serve spends like PurchaseCredits.
+		v1.POST("/credits/add", middleware.RequireAuthOrScope(models.ScopeAdminAll), middleware.Idempotency(), handlers.AddCredits)
+
+		// API key management - always requires the owning user's own JWT,
+		// never another API key (a key can't be used to manage keys, scoped
+		// or otherwise - models.ScopeAdminAll is for admin-facing routes
+		// elsewhere, not a way to let a key rotate or delete itself).
+		apiKeys := v1.Group("/api-keys", middleware.RequireAuth())
+		{
+			apiKeys.GET("", handlers.ListApiKeys)
+			apiKeys.POST("", middleware.Idempotency(), handlers.CreateApiKey)
+			apiKeys.POST("/:id/rotate", handlers.RotateApiKey)
+			apiKeys.DELETE("/:id", handlers.DeleteApiKey)
+		}
+
+		// Job queue admin routes
+		v1.GET("/jobs/dlq", middleware.RequireAuth(), handlers.ListDeadLetterJobs)
+		v1.POST("/jobs/dlq/:id/requeue", middleware.RequireAuth(), handlers.RequeueDeadLetterJob)
+
+		// Mission asset uploads (custom current fields, bathymetry, search
+		// regions) - large enough that they move directly to/from S3
+		// rather than through this process's request body. Gated by the
+		// same scope as mission writes, since an uploaded asset only ever
+		// becomes useful once attached to a mission.
+		v1.POST("/uploads/initiate", middleware.RequireAuthOrScope(models.ScopeMissionsWrite), handlers.InitiateUpload)
+		v1.POST("/uploads/:id/complete", middleware.RequireAuthOrScope(models.ScopeMissionsWrite), handlers.CompleteUpload)
 	}
 
+	// When a drift job exhausts its retries, refund the mission's credit
+	// hold and mark it failed rather than leaving the reservation pending
+	// forever. This is the dead-letter side of the same lifecycle
+	// handlers.CompleteMission closes on success.
+	queue.OnDeadLetter = func(missionID, jobID, reason string) {
+		if err := credits.RefundMission(missionID, fmt.Sprintf("mission %s exhausted retries: %s", missionID, reason)); err != nil {
+			log.Printf("Failed to refund credits for dead-lettered mission %s: %v", missionID, err)
+		}
+		if _, err := database.DB.Exec(
+			`UPDATE missions SET status = 'failed', error_message = $1, updated_at = NOW() WHERE id = $2`,
+			reason, missionID,
+		); err != nil {
+			log.Printf("Failed to mark mission %s failed after dead-lettering: %v", missionID, err)
+		}
+	}
+
+	// Reap jobs left behind by workers that died mid-simulation, and
+	// requeue jobs whose backoff delay (see internal/queue/delay.go) has
+	// elapsed.
+	go queue.StartReaper(context.Background())
+	go queue.StartDelayedRequeuer(context.Background())
+
 	// Start server
 	log.Printf("Starting Driftline API server on port %s", port)
 	if err := router.Run(":" + port); err != nil {
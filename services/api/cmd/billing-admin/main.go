@@ -0,0 +1,69 @@
+// Command billing-admin runs the periodic Stripe invoicing pipeline: it
+// aggregates a billing period's metered usage into invoice_records,
+// attaches those as draft Stripe invoice items, then rolls each
+// customer's pending items into a Stripe invoice.
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/eysteinn/driftline/services/api/internal/database"
+	"github.com/eysteinn/driftline/services/api/internal/payments"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(1)
+	}
+
+	if err := database.Connect(); err != nil {
+		log.Fatalf("failed to connect to database: %v", err)
+	}
+	defer database.Close()
+	payments.Init()
+
+	switch os.Args[1] {
+	case "prepare-invoice-records":
+		if len(os.Args) < 3 {
+			log.Fatal("prepare-invoice-records requires a <period> argument, e.g. 2026-07")
+		}
+		n, err := payments.PrepareInvoiceRecords(os.Args[2])
+		if err != nil {
+			log.Fatalf("failed to prepare invoice records: %v", err)
+		}
+		fmt.Printf("prepared %d invoice record(s)\n", n)
+
+	case "create-invoice-items":
+		n, err := payments.CreateInvoiceItems()
+		if err != nil {
+			log.Fatalf("failed to create invoice items: %v", err)
+		}
+		fmt.Printf("created %d invoice item(s)\n", n)
+
+	case "create-invoices":
+		n, err := payments.CreateInvoices()
+		if err != nil {
+			log.Fatalf("failed to create invoices: %v", err)
+		}
+		fmt.Printf("created %d invoice(s)\n", n)
+
+	default:
+		usage()
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `usage: billing-admin <command> [args]
+
+commands:
+  prepare-invoice-records <period>   aggregate ledger spend for period (YYYY-MM) into invoice_records
+  create-invoice-items                attach pending invoice_records as draft Stripe invoice items
+  create-invoices                     roll each customer's pending invoice items into a Stripe invoice
+
+DATABASE_URL, STRIPE_SECRET_KEY configure the database and Stripe account,
+same as the api-gateway service.`)
+}
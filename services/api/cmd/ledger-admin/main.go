@@ -0,0 +1,67 @@
+// Command ledger-admin runs maintenance jobs against the credit ledger.
+// Its reconcile command is meant to run on a schedule (cron, k8s
+// CronJob) and alert whoever owns it if it ever exits non-zero, which it
+// does only when it finds drift.
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/eysteinn/driftline/services/api/internal/database"
+	"github.com/eysteinn/driftline/services/api/internal/ledger"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(1)
+	}
+
+	if err := database.Connect(); err != nil {
+		log.Fatalf("failed to connect to database: %v", err)
+	}
+	defer database.Close()
+
+	switch os.Args[1] {
+	case "reconcile":
+		drifted, err := ledger.Reconcile()
+		if err != nil {
+			log.Fatalf("failed to reconcile ledger: %v", err)
+		}
+		if len(drifted) == 0 {
+			fmt.Println("ledger balanced: every transaction's postings sum to zero")
+			return
+		}
+		fmt.Printf("DRIFT DETECTED: %d unbalanced ledger transaction(s):\n", len(drifted))
+		for _, id := range drifted {
+			fmt.Println(id)
+		}
+		os.Exit(1)
+
+	case "balance":
+		if len(os.Args) < 3 {
+			log.Fatal("balance requires an <account> argument, e.g. user:123:credits")
+		}
+		balance, err := ledger.Balance(os.Args[2])
+		if err != nil {
+			log.Fatalf("failed to compute balance: %v", err)
+		}
+		fmt.Println(balance)
+
+	default:
+		usage()
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `usage: ledger-admin <command> [args]
+
+commands:
+  reconcile              verify every ledger transaction's postings sum to zero, exit 1 on drift
+  balance <account>      print an account's current balance, e.g. user:123:credits
+
+DATABASE_URL configures the database, same as the api-gateway service.`)
+}
@@ -0,0 +1,217 @@
+// Package credits implements credit reservations for mission creation: a
+// hold is placed on a user's balance up front, then either committed
+// (spent for good) once the mission succeeds or refunded if it fails or is
+// cancelled, rather than deducting the balance irrevocably at creation
+// time the way handlers.DeductCredits does for the older, pre-reservation
+// flow. Holds move through the ledger into a mission escrow account
+// rather than vanishing into a single balance column, so a reservation's
+// credits are always sitting in some account, never in limbo.
+package credits
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/eysteinn/driftline/services/api/internal/database"
+	"github.com/eysteinn/driftline/services/api/internal/ledger"
+	"github.com/eysteinn/driftline/services/api/internal/observability"
+)
+
+// pricePerParticleHourMilliCredits is the credit cost per
+// (ensemble particle x forecast hour) unit, in thousandths of a credit.
+const pricePerParticleHourMilliCredits = 2
+
+// Cost computes the credit price of a mission from its forecast horizon
+// and ensemble size, rounding up to the nearest whole credit.
+func Cost(forecastHours, ensembleSize int) int {
+	units := forecastHours * ensembleSize
+	cost := (units*pricePerParticleHourMilliCredits + 999) / 1000
+	if cost < 1 {
+		cost = 1
+	}
+	return cost
+}
+
+// Reserve moves a hold of amount credits from userID's ledger balance
+// into missionID's escrow account, recording a pending reservation.
+// Commit or Refund resolves the hold once the mission's outcome is known.
+func Reserve(userID, missionID string, amount int) (reservationID string, err error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return "", fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	userAccount := ledger.UserAccount(userID)
+	balance, err := ledger.BalanceForUpdate(tx, userAccount)
+	if err != nil {
+		return "", err
+	}
+	if balance < amount {
+		return "", fmt.Errorf("insufficient credits: have %d, need %d", balance, amount)
+	}
+
+	_, err = ledger.Post(tx, fmt.Sprintf("Reserved %d credits for mission", amount),
+		ledger.Leg{Account: userAccount, Amount: -amount},
+		ledger.Leg{Account: ledger.MissionEscrowAccount(missionID), Amount: amount},
+	)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	err = tx.QueryRow(
+		`INSERT INTO credit_reservations (user_id, mission_id, amount, status, created_at, updated_at)
+		 VALUES ($1, $2, $3, 'pending', $4, $4)
+		 RETURNING id`,
+		userID, missionID, amount, now,
+	).Scan(&reservationID)
+	if err != nil {
+		return "", fmt.Errorf("failed to record reservation: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to commit reservation: %w", err)
+	}
+
+	return reservationID, nil
+}
+
+// Commit finalizes a pending reservation as spent: its escrowed credits
+// move from the mission's escrow account to system:revenue and are not
+// returned to the user.
+func Commit(reservationID string) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var missionID string
+	var amount int
+	var status string
+	err = tx.QueryRow(
+		`SELECT mission_id, amount, status FROM credit_reservations WHERE id = $1 FOR UPDATE`,
+		reservationID,
+	).Scan(&missionID, &amount, &status)
+
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("reservation not found")
+	} else if err != nil {
+		return fmt.Errorf("failed to load reservation: %w", err)
+	}
+	if status != "pending" {
+		return fmt.Errorf("reservation %s is not pending (status=%s)", reservationID, status)
+	}
+
+	_, err = ledger.Post(tx, fmt.Sprintf("Committed mission %s escrow", missionID),
+		ledger.Leg{Account: ledger.MissionEscrowAccount(missionID), Amount: -amount},
+		ledger.Leg{Account: ledger.SystemRevenue, Amount: amount},
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		`UPDATE credit_reservations SET status = 'committed', updated_at = $1 WHERE id = $2`,
+		time.Now(), reservationID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to commit reservation: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	observability.CreditsDeductedTotal.Add(float64(amount))
+	return nil
+}
+
+// CommitMission looks up missionID's pending credit reservation and
+// commits it, mirroring RefundMission. It is a no-op if the mission has
+// no pending reservation - e.g. it was already committed or refunded, or
+// no credits were ever reserved for it - so callers that can't tell those
+// cases apart (like the worker completion callback) can call it
+// unconditionally.
+func CommitMission(missionID string) error {
+	var reservationID string
+	err := database.DB.QueryRow(
+		`SELECT id FROM credit_reservations WHERE mission_id = $1 AND status = 'pending'`,
+		missionID,
+	).Scan(&reservationID)
+	if err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to look up reservation for mission %s: %w", missionID, err)
+	}
+
+	return Commit(reservationID)
+}
+
+// RefundMission looks up missionID's pending credit reservation and
+// refunds it, recording reason on the posting description. It is a no-op
+// if the mission has no pending reservation - e.g. its reservation was
+// already committed or refunded, or no credits were ever reserved for it -
+// so callers that can't tell those cases apart (like a dead-letter hook)
+// can call it unconditionally.
+func RefundMission(missionID, reason string) error {
+	var reservationID string
+	err := database.DB.QueryRow(
+		`SELECT id FROM credit_reservations WHERE mission_id = $1 AND status = 'pending'`,
+		missionID,
+	).Scan(&reservationID)
+	if err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to look up reservation for mission %s: %w", missionID, err)
+	}
+
+	return Refund(reservationID, reason)
+}
+
+// Refund releases a pending reservation's escrowed credits back to the
+// user's ledger balance, recording reason on the posting description.
+func Refund(reservationID, reason string) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var userID, missionID string
+	var amount int
+	var status string
+	err = tx.QueryRow(
+		`SELECT user_id, mission_id, amount, status FROM credit_reservations WHERE id = $1 FOR UPDATE`,
+		reservationID,
+	).Scan(&userID, &missionID, &amount, &status)
+
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("reservation not found")
+	} else if err != nil {
+		return fmt.Errorf("failed to load reservation: %w", err)
+	}
+	if status != "pending" {
+		return fmt.Errorf("reservation %s is not pending (status=%s)", reservationID, status)
+	}
+
+	_, err = ledger.Post(tx, reason,
+		ledger.Leg{Account: ledger.MissionEscrowAccount(missionID), Amount: -amount},
+		ledger.Leg{Account: ledger.UserAccount(userID), Amount: amount},
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		`UPDATE credit_reservations SET status = 'refunded', updated_at = $1 WHERE id = $2`,
+		time.Now(), reservationID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update reservation: %w", err)
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,25 @@
+package credits
+
+import "testing"
+
+func TestCost(t *testing.T) {
+	tests := []struct {
+		name          string
+		forecastHours int
+		ensembleSize  int
+		want          int
+	}{
+		{"typical mission", 48, 500, 48},
+		{"rounds up a partial credit", 1, 1, 1},
+		{"never charges zero", 0, 0, 1},
+		{"large mission", 120, 2000, 480},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Cost(tt.forecastHours, tt.ensembleSize); got != tt.want {
+				t.Errorf("Cost(%d, %d) = %d, want %d", tt.forecastHours, tt.ensembleSize, got, tt.want)
+			}
+		})
+	}
+}
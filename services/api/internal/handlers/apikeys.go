@@ -3,19 +3,38 @@ package handlers
 import (
 	"crypto/rand"
 	"crypto/sha256"
+	"database/sql"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/eysteinn/driftline/services/api/internal/database"
 	"github.com/eysteinn/driftline/services/api/internal/middleware"
 	"github.com/eysteinn/driftline/services/api/internal/models"
+	"github.com/eysteinn/driftline/services/api/internal/queue"
 	"github.com/eysteinn/driftline/services/api/internal/utils"
 	"github.com/gin-gonic/gin"
 )
 
+// defaultRotationGracePeriod is how long a rotated-out API key keeps working
+// after RotateApiKey issues its replacement, so in-flight clients have time
+// to pick up the new secret. Configurable via API_KEY_ROTATION_GRACE_PERIOD
+// (a Go duration string, e.g. "48h").
+const defaultRotationGracePeriod = 24 * time.Hour
+
+func rotationGracePeriod() time.Duration {
+	if v := os.Getenv("API_KEY_ROTATION_GRACE_PERIOD"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			return d
+		}
+	}
+	return defaultRotationGracePeriod
+}
+
 // generateApiKey generates a secure random API key
 func generateApiKey() (string, error) {
 	b := make([]byte, 32)
@@ -162,6 +181,101 @@ func CreateApiKey(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusCreated, response)
 }
 
+// RotateApiKey handles POST /api-keys/:id/rotate: issues a new secret for
+// an existing key, carrying over its name and scopes. The replaced key
+// keeps authenticating for rotationGracePeriod (its expires_at is pulled in
+// to that window, never pushed out) rather than being revoked immediately,
+// so clients mid-rollout of the new secret aren't cut off.
+func RotateApiKey(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	oldID := c.Param("id")
+
+	var name string
+	var scopesJSON []byte
+	var oldKeyHash string
+	err := database.DB.QueryRow(
+		`SELECT name, scopes, key_hash FROM api_keys WHERE id = $1 AND user_id = $2 AND is_active = TRUE`,
+		oldID, userID,
+	).Scan(&name, &scopesJSON, &oldKeyHash)
+
+	if err == sql.ErrNoRows {
+		utils.ErrorResponse(c, http.StatusNotFound, "API key not found")
+		return
+	} else if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to load API key")
+		return
+	}
+
+	newKey, err := generateApiKey()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate API key")
+		return
+	}
+	keyPreview := createKeyPreview(newKey)
+	keyHash := hashApiKey(newKey)
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to rotate API key")
+		return
+	}
+	defer tx.Rollback()
+
+	var newID string
+	var createdAt time.Time
+	err = tx.QueryRow(
+		`INSERT INTO api_keys (user_id, key_hash, key_preview, name, scopes, rotated_from, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+		 RETURNING id, created_at`,
+		userID, keyHash, keyPreview, name, scopesJSON, oldID, time.Now(),
+	).Scan(&newID, &createdAt)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to rotate API key")
+		return
+	}
+
+	// revoked_at is set for audit purposes only - what actually cuts the
+	// old key off once the grace period ends is its (shortened)
+	// expires_at, since it needs to keep authenticating during the
+	// window, not stop immediately.
+	graceExpiry := time.Now().Add(rotationGracePeriod())
+	if _, err := tx.Exec(
+		`UPDATE api_keys SET expires_at = LEAST(COALESCE(expires_at, $2), $2), revoked_at = $3 WHERE id = $1`,
+		oldID, graceExpiry, time.Now(),
+	); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to rotate API key")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to rotate API key")
+		return
+	}
+
+	if err := queue.InvalidateApiKeyCache(oldKeyHash); err != nil {
+		log.Printf("Failed to invalidate cache for rotated API key %s: %v", oldID, err)
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, models.CreateApiKeyResponse{
+		Key: newKey,
+		ApiKey: models.ApiKey{
+			ID:          newID,
+			UserID:      userID,
+			Name:        name,
+			Scopes:      scopesJSON,
+			KeyPreview:  keyPreview,
+			IsActive:    true,
+			CreatedAt:   createdAt,
+			RotatedFrom: &oldID,
+		},
+	})
+}
+
 // DeleteApiKey deletes an API key
 func DeleteApiKey(c *gin.Context) {
 	userID, ok := middleware.GetUserID(c)
@@ -172,6 +286,9 @@ func DeleteApiKey(c *gin.Context) {
 
 	keyID := c.Param("id")
 
+	var keyHash string
+	_ = database.DB.QueryRow(`SELECT key_hash FROM api_keys WHERE id = $1 AND user_id = $2`, keyID, userID).Scan(&keyHash)
+
 	// Verify the key belongs to the user and delete it
 	result, err := database.DB.Exec(
 		`DELETE FROM api_keys WHERE id = $1 AND user_id = $2`,
@@ -193,6 +310,12 @@ func DeleteApiKey(c *gin.Context) {
 		return
 	}
 
+	if keyHash != "" {
+		if err := queue.InvalidateApiKeyCache(keyHash); err != nil {
+			log.Printf("Failed to invalidate cache for deleted API key %s: %v", keyID, err)
+		}
+	}
+
 	utils.SuccessResponse(c, http.StatusOK, gin.H{
 		"message": "API key deleted successfully",
 	})
@@ -2,25 +2,38 @@ package handlers
 
 import (
 	"database/sql"
+	"errors"
+	"log"
 	"net/http"
 	"time"
 
 	"github.com/eysteinn/driftline/services/api/internal/database"
 	"github.com/eysteinn/driftline/services/api/internal/models"
+	"github.com/eysteinn/driftline/services/api/internal/queue"
+	"github.com/eysteinn/driftline/services/api/internal/security"
+	"github.com/eysteinn/driftline/services/api/internal/utils"
 	"github.com/gin-gonic/gin"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lib/pq"
 )
 
+// pqUniqueViolation is the PostgreSQL error code for unique_violation.
+const pqUniqueViolation = "23505"
+
 // Register handles user registration
 func Register(c *gin.Context) {
 	var req models.CreateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		utils.WriteError(c, utils.ValidationError(err))
+		return
+	}
+	if err := req.Validate(); err != nil {
+		utils.WriteError(c, utils.NewAppError(http.StatusUnprocessableEntity, "weak_password", err.Error()))
 		return
 	}
 
 	// Hash the password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	hashedPassword, err := security.HashPassword(req.Password, security.DefaultArgon2Params)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
 		return
@@ -32,13 +45,12 @@ func Register(c *gin.Context) {
 		`INSERT INTO users (email, hashed_password, full_name, created_at, updated_at)
 		 VALUES ($1, $2, $3, $4, $5)
 		 RETURNING id`,
-		req.Email, string(hashedPassword), req.FullName, time.Now(), time.Now(),
+		req.Email, hashedPassword, req.FullName, time.Now(), time.Now(),
 	).Scan(&userID)
 
 	if err != nil {
-		// TODO: Use proper error type checking with pq package
-		// This is a temporary solution checking error message string
-		if err.Error() == "pq: duplicate key value violates unique constraint \"users_email_key\"" {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == pqUniqueViolation {
 			c.JSON(http.StatusConflict, gin.H{"error": "Email already registered"})
 			return
 		}
@@ -56,17 +68,19 @@ func Register(c *gin.Context) {
 func Login(c *gin.Context) {
 	var req models.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		utils.WriteError(c, utils.ValidationError(err))
 		return
 	}
 
+	ip := c.ClientIP()
+
 	// Query user from database
 	var user models.User
 	err := database.DB.QueryRow(
-		`SELECT id, email, hashed_password, full_name, is_active, is_verified, role, created_at, updated_at
+		`SELECT id, email, hashed_password, full_name, is_active, is_verified, role, failed_login_attempts, locked_until, created_at, updated_at
 		 FROM users WHERE email = $1`,
 		req.Email,
-	).Scan(&user.ID, &user.Email, &user.HashedPassword, &user.FullName, &user.IsActive, &user.IsVerified, &user.Role, &user.CreatedAt, &user.UpdatedAt)
+	).Scan(&user.ID, &user.Email, &user.HashedPassword, &user.FullName, &user.IsActive, &user.IsVerified, &user.Role, &user.FailedLoginAttempts, &user.LockedUntil, &user.CreatedAt, &user.UpdatedAt)
 
 	if err == sql.ErrNoRows {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
@@ -76,6 +90,11 @@ func Login(c *gin.Context) {
 		return
 	}
 
+	if user.LockedUntil != nil && time.Now().Before(*user.LockedUntil) {
+		c.JSON(http.StatusLocked, gin.H{"error": "Account is temporarily locked due to too many failed login attempts"})
+		return
+	}
+
 	// Check if user is active
 	if !user.IsActive {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Account is inactive"})
@@ -83,19 +102,172 @@ func Login(c *gin.Context) {
 	}
 
 	// Verify password
-	err = bcrypt.CompareHashAndPassword([]byte(user.HashedPassword), []byte(req.Password))
+	passwordOK, err := security.VerifyPassword(user.HashedPassword, req.Password)
 	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify password"})
+		return
+	}
+	if !passwordOK {
+		if err := recordFailedLogin(user.ID, req.Email, ip); err != nil {
+			log.Printf("failed to record failed login for %s: %v", req.Email, err)
+		}
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
 		return
 	}
 
-	// TODO: Generate JWT token with proper signing and expiration
-	// This is a placeholder for initial testing - MUST be replaced with real JWT implementation
-	// For production: use github.com/golang-jwt/jwt or similar library
-	token := "placeholder-jwt-token"
+	if err := resetFailedLogins(user.ID, req.Email, ip); err != nil {
+		log.Printf("failed to reset failed logins for %s: %v", req.Email, err)
+	}
+
+	accessToken, refreshToken, err := utils.GenerateTokenPair(user.ID, user.Email, user.Role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate tokens"})
+		return
+	}
 
 	c.JSON(http.StatusOK, models.LoginResponse{
-		Token: token,
-		User:  user,
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		User:         user,
+	})
+}
+
+// RefreshToken handles refresh-token rotation: a valid, unrevoked refresh
+// token is exchanged for a new access/refresh pair, and the presented
+// refresh token's jti is revoked so it can't be replayed.
+func RefreshToken(c *gin.Context) {
+	var req models.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims, err := utils.ParseToken(req.RefreshToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired refresh token"})
+		return
+	}
+
+	if claims["type"] != "refresh" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token required"})
+		return
+	}
+
+	jti, _ := claims["jti"].(string)
+	if jti != "" {
+		revoked, err := queue.IsTokenRevoked(jti)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify refresh token"})
+			return
+		}
+		if revoked {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token revoked"})
+			return
+		}
+	}
+
+	userID, _ := claims["user_id"].(string)
+	email, _ := claims["email"].(string)
+	role, _ := claims["role"].(string)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token claims"})
+		return
+	}
+
+	accessToken, refreshToken, err := utils.GenerateTokenPair(userID, email, role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate tokens"})
+		return
+	}
+
+	if jti != "" {
+		if err := queue.RevokeToken(jti, ttlFromExpClaim(claims)); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate refresh token"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, models.TokenPairResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
 	})
 }
+
+// Logout revokes the presented refresh token so it can no longer be
+// exchanged for a new token pair.
+func Logout(c *gin.Context) {
+	var req models.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims, err := utils.ParseToken(req.RefreshToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired refresh token"})
+		return
+	}
+
+	jti, _ := claims["jti"].(string)
+	if jti == "" {
+		c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+		return
+	}
+
+	if err := queue.RevokeToken(jti, ttlFromExpClaim(claims)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+}
+
+// recordFailedLogin increments userID's failed-attempt counter in Postgres
+// and, via security.RecordFailedLogin, the Redis counter keyed on
+// email+ip. Once that reaches security.MaxFailedLoginAttempts within
+// security.LoginAttemptsWindow, it also sets LockedUntil so subsequent
+// logins are rejected outright until it passes.
+func recordFailedLogin(userID, email, ip string) error {
+	lock, err := security.RecordFailedLogin(email, ip)
+	if err != nil {
+		return err
+	}
+
+	if lock {
+		_, err := database.DB.Exec(
+			`UPDATE users SET failed_login_attempts = failed_login_attempts + 1, locked_until = $2, updated_at = NOW() WHERE id = $1`,
+			userID, time.Now().Add(security.LockoutDuration),
+		)
+		return err
+	}
+
+	_, err = database.DB.Exec(
+		`UPDATE users SET failed_login_attempts = failed_login_attempts + 1, updated_at = NOW() WHERE id = $1`,
+		userID,
+	)
+	return err
+}
+
+// resetFailedLogins clears userID's failed-attempt state in both Redis and
+// Postgres, after a successful login.
+func resetFailedLogins(userID, email, ip string) error {
+	if err := security.ResetFailedLogins(email, ip); err != nil {
+		return err
+	}
+
+	_, err := database.DB.Exec(
+		`UPDATE users SET failed_login_attempts = 0, locked_until = NULL, updated_at = NOW() WHERE id = $1`,
+		userID,
+	)
+	return err
+}
+
+// ttlFromExpClaim returns how long is left until claims' exp, so a revoked
+// token's entry doesn't outlive the token itself.
+func ttlFromExpClaim(claims jwt.MapClaims) time.Duration {
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return 0
+	}
+	return time.Until(time.Unix(int64(exp), 0))
+}
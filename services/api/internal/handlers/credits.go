@@ -2,17 +2,32 @@ package handlers
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 
 	"github.com/eysteinn/driftline/services/api/internal/database"
+	"github.com/eysteinn/driftline/services/api/internal/ledger"
 	"github.com/eysteinn/driftline/services/api/internal/middleware"
 	"github.com/eysteinn/driftline/services/api/internal/models"
+	"github.com/eysteinn/driftline/services/api/internal/observability"
+	"github.com/eysteinn/driftline/services/api/internal/payments"
 	"github.com/eysteinn/driftline/services/api/internal/utils"
 	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
 )
 
+// deductPqUniqueViolation is the PostgreSQL error code for
+// unique_violation, matching payments.pqUniqueViolation.
+const deductPqUniqueViolation = "23505"
+
+// signupGrantCredits is the free credit balance a user's ledger account
+// is seeded with the first time GetCreditBalance sees them.
+const signupGrantCredits = 100
+
 // GetCreditBalance returns the current credit balance for the authenticated user
 func GetCreditBalance(c *gin.Context) {
 	userID, ok := middleware.GetUserID(c)
@@ -21,26 +36,41 @@ func GetCreditBalance(c *gin.Context) {
 		return
 	}
 
-	var balance int
-	err := database.DB.QueryRow(
-		`SELECT balance FROM user_credits WHERE user_id = $1`,
-		userID,
-	).Scan(&balance)
+	account := ledger.UserAccount(userID)
+	seen, err := ledger.HasPostings(account)
+	if err != nil {
+		log.Printf("Failed to check ledger postings for user %s: %v", userID, err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Database error")
+		return
+	}
 
-	if err == sql.ErrNoRows {
-		// User doesn't have a credit record yet, create one with initial balance
-		_, err = database.DB.Exec(
-			`INSERT INTO user_credits (user_id, balance) VALUES ($1, $2)`,
-			userID, 100, // Give new users 100 free credits
+	if !seen {
+		tx, err := database.DB.Begin()
+		if err != nil {
+			log.Printf("Failed to start transaction: %v", err)
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to initialize credits")
+			return
+		}
+		_, err = ledger.Post(tx, "Signup credit grant",
+			ledger.Leg{Account: ledger.SystemFreeGrants, Amount: -signupGrantCredits},
+			ledger.Leg{Account: account, Amount: signupGrantCredits},
 		)
 		if err != nil {
-			log.Printf("Failed to create user_credits for user %s: %v", userID, err)
+			tx.Rollback()
+			log.Printf("Failed to post signup grant for user %s: %v", userID, err)
 			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to initialize credits")
 			return
 		}
-		balance = 100
-	} else if err != nil {
-		log.Printf("Database error fetching credit balance: %v", err)
+		if err := tx.Commit(); err != nil {
+			log.Printf("Failed to commit signup grant for user %s: %v", userID, err)
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to initialize credits")
+			return
+		}
+	}
+
+	balance, err := ledger.Balance(account)
+	if err != nil {
+		log.Printf("Failed to compute ledger balance for user %s: %v", userID, err)
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Database error")
 		return
 	}
@@ -50,6 +80,36 @@ func GetCreditBalance(c *gin.Context) {
 	})
 }
 
+// GetCreditLedger returns the authenticated user's ledger postings, most
+// recent first, each annotated with the running balance as of that
+// posting - the audit trail GetCreditTransactions' balance_after column
+// alone can't prove.
+func GetCreditLedger(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	page := 1
+	if p, err := strconv.Atoi(c.Query("page")); err == nil && p > 0 {
+		page = p
+	}
+	pageSize := 50
+
+	postings, err := ledger.History(ledger.UserAccount(userID), pageSize, (page-1)*pageSize)
+	if err != nil {
+		log.Printf("Failed to load ledger history for user %s: %v", userID, err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if postings == nil {
+		postings = []ledger.Posting{}
+	}
+
+	utils.PaginatedResponse(c, postings, len(postings), page, pageSize)
+}
+
 // GetCreditTransactions returns the transaction history for the authenticated user
 func GetCreditTransactions(c *gin.Context) {
 	userID, ok := middleware.GetUserID(c)
@@ -175,90 +235,37 @@ func PurchaseCredits(c *gin.Context) {
 		return
 	}
 
-	// TODO: Integrate with Stripe payment processing
-	// For now, we'll simulate a successful payment and add credits directly
-	// In production, this would:
-	// 1. Create a Stripe payment intent
-	// 2. Process the payment
-	// 3. Only add credits after successful payment
-
-	// Start a transaction
-	tx, err := database.DB.Begin()
-	if err != nil {
-		log.Printf("Failed to start transaction: %v", err)
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to process purchase")
-		return
-	}
-	defer tx.Rollback()
-
-	// Get current balance with row lock
-	var currentBalance int
-	err = tx.QueryRow(
-		`SELECT balance FROM user_credits WHERE user_id = $1 FOR UPDATE`,
-		userID,
-	).Scan(&currentBalance)
-
-	if err == sql.ErrNoRows {
-		// Create credits record if it doesn't exist
-		_, err = tx.Exec(
-			`INSERT INTO user_credits (user_id, balance) VALUES ($1, $2)`,
-			userID, 0,
-		)
-		if err != nil {
-			log.Printf("Failed to create user_credits: %v", err)
-			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to process purchase")
-			return
-		}
-		currentBalance = 0
-	} else if err != nil {
-		log.Printf("Failed to get current balance: %v", err)
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to process purchase")
-		return
-	}
-
-	// Update balance
-	newBalance := currentBalance + pkg.Credits
-	_, err = tx.Exec(
-		`UPDATE user_credits SET balance = $1 WHERE user_id = $2`,
-		newBalance, userID,
-	)
-	if err != nil {
-		log.Printf("Failed to update balance: %v", err)
+	var email string
+	if err := database.DB.QueryRow(`SELECT email FROM users WHERE id = $1`, userID).Scan(&email); err != nil {
+		log.Printf("Failed to load user email for %s: %v", userID, err)
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to process purchase")
 		return
 	}
 
-	// Record transaction
-	description := fmt.Sprintf("Purchased %s (%d credits)", pkg.Name, pkg.Credits)
-	_, err = tx.Exec(
-		`INSERT INTO credit_transactions 
-		 (user_id, transaction_type, amount, balance_after, description, package_id)
-		 VALUES ($1, $2, $3, $4, $5, $6)`,
-		userID, "purchase", pkg.Credits, newBalance, description, pkg.ID,
-	)
+	// Credits aren't granted here - PaymentIntent creation only starts the
+	// charge. The payment_intent.succeeded webhook credits the user once
+	// Stripe confirms payment actually went through.
+	pi, err := payments.CreatePaymentIntent(userID, email, pkg.ID, int64(pkg.PriceCents))
 	if err != nil {
-		log.Printf("Failed to record transaction: %v", err)
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to process purchase")
-		return
-	}
-
-	// Commit transaction
-	if err = tx.Commit(); err != nil {
-		log.Printf("Failed to commit transaction: %v", err)
+		log.Printf("Failed to create payment intent for user %s: %v", userID, err)
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to process purchase")
 		return
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, gin.H{
-		"success":      true,
-		"creditsAdded": pkg.Credits,
-		"newBalance":   newBalance,
-		"message":      description,
+	utils.SuccessResponse(c, http.StatusOK, models.PurchaseCreditsResponse{
+		ClientSecret: pi.ClientSecret,
+		PackageID:    pkg.ID,
+		AmountCents:  pkg.PriceCents,
 	})
 }
 
 // AddCredits handles adding credits (for subscriptions or admin operations)
 func AddCredits(c *gin.Context) {
+	if role, _ := middleware.GetRole(c); role != "admin" {
+		utils.ErrorResponse(c, http.StatusForbidden, "Admin role required")
+		return
+	}
+
 	userID, ok := middleware.GetUserID(c)
 	if !ok {
 		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated")
@@ -271,7 +278,6 @@ func AddCredits(c *gin.Context) {
 		return
 	}
 
-	// Start a transaction
 	tx, err := database.DB.Begin()
 	if err != nil {
 		log.Printf("Failed to start transaction: %v", err)
@@ -280,59 +286,25 @@ func AddCredits(c *gin.Context) {
 	}
 	defer tx.Rollback()
 
-	// Get current balance with row lock
-	var currentBalance int
-	err = tx.QueryRow(
-		`SELECT balance FROM user_credits WHERE user_id = $1 FOR UPDATE`,
-		userID,
-	).Scan(&currentBalance)
-
-	if err == sql.ErrNoRows {
-		// Create credits record if it doesn't exist
-		_, err = tx.Exec(
-			`INSERT INTO user_credits (user_id, balance) VALUES ($1, $2)`,
-			userID, 0,
-		)
-		if err != nil {
-			log.Printf("Failed to create user_credits: %v", err)
-			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to add credits")
-			return
-		}
-		currentBalance = 0
-	} else if err != nil {
-		log.Printf("Failed to get current balance: %v", err)
+	account := ledger.UserAccount(userID)
+	if _, err := ledger.Post(tx, req.Description,
+		ledger.Leg{Account: ledger.SystemFreeGrants, Amount: -req.Amount},
+		ledger.Leg{Account: account, Amount: req.Amount},
+	); err != nil {
+		log.Printf("Failed to post credit grant for user %s: %v", userID, err)
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to add credits")
 		return
 	}
 
-	// Update balance
-	newBalance := currentBalance + req.Amount
-	_, err = tx.Exec(
-		`UPDATE user_credits SET balance = $1 WHERE user_id = $2`,
-		newBalance, userID,
-	)
-	if err != nil {
-		log.Printf("Failed to update balance: %v", err)
+	if err := tx.Commit(); err != nil {
+		log.Printf("Failed to commit transaction: %v", err)
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to add credits")
 		return
 	}
 
-	// Record transaction
-	_, err = tx.Exec(
-		`INSERT INTO credit_transactions 
-		 (user_id, transaction_type, amount, balance_after, description)
-		 VALUES ($1, $2, $3, $4, $5)`,
-		userID, "subscription_grant", req.Amount, newBalance, req.Description,
-	)
+	newBalance, err := ledger.Balance(account)
 	if err != nil {
-		log.Printf("Failed to record transaction: %v", err)
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to add credits")
-		return
-	}
-
-	// Commit transaction
-	if err = tx.Commit(); err != nil {
-		log.Printf("Failed to commit transaction: %v", err)
+		log.Printf("Failed to compute balance for user %s: %v", userID, err)
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to add credits")
 		return
 	}
@@ -344,59 +316,125 @@ func AddCredits(c *gin.Context) {
 	})
 }
 
-// DeductCredits deducts credits from a user's balance
-// Returns the new balance or an error if insufficient credits
-func DeductCredits(userID string, amount int, description string, missionID *string) (int, error) {
-	// Start a transaction
+// deductResult is what DeductCredits records in idempotency_keys so a
+// retry carrying the same idempotencyKey can replay the outcome instead of
+// spending the user's credits twice.
+type deductResult struct {
+	NewBalance int `json:"newBalance"`
+}
+
+// deductIdempotencyEndpoint scopes DeductCredits's own idempotency_keys
+// rows apart from middleware.Idempotency's HTTP-level ones (see
+// internal/middleware/idempotency.go), which are scoped by the matched
+// route instead - so a caller's idempotencyKey here can never collide with
+// one presented as an Idempotency-Key header on some unrelated route.
+const deductIdempotencyEndpoint = "internal:deduct-credits"
+
+// DeductCredits posts an immediate spend of amount credits from userID's
+// ledger balance to system:revenue. Returns the new balance, or an error
+// if the user doesn't have enough credits.
+//
+// idempotencyKey is optional. When set, it's recorded in idempotency_keys
+// (the same table Idempotency uses for HTTP retries) keyed by userID, so a
+// caller that doesn't know whether its last attempt committed - mission
+// execution retrying a deduction after a timeout, say - can pass the same
+// key again and get the original result back instead of being charged
+// twice. An empty idempotencyKey skips all of this and always deducts.
+func DeductCredits(userID string, amount int, description string, missionID *string, idempotencyKey string) (int, error) {
 	tx, err := database.DB.Begin()
 	if err != nil {
 		return 0, fmt.Errorf("failed to start transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	// Get current balance with row lock
-	var currentBalance int
-	err = tx.QueryRow(
-		`SELECT balance FROM user_credits WHERE user_id = $1 FOR UPDATE`,
-		userID,
-	).Scan(&currentBalance)
+	if idempotencyKey != "" {
+		claimed, prior, err := claimDeductIdempotencyKey(tx, userID, idempotencyKey)
+		if err != nil {
+			return 0, err
+		}
+		if !claimed {
+			return prior.NewBalance, nil
+		}
+	}
 
-	if err == sql.ErrNoRows {
-		return 0, fmt.Errorf("user credit record not found")
-	} else if err != nil {
-		return 0, fmt.Errorf("failed to get current balance: %w", err)
+	account := ledger.UserAccount(userID)
+	balance, err := ledger.BalanceForUpdate(tx, account)
+	if err != nil {
+		return 0, err
+	}
+	if balance < amount {
+		return 0, fmt.Errorf("insufficient credits: have %d, need %d", balance, amount)
 	}
 
-	// Check if user has sufficient credits
-	if currentBalance < amount {
-		return 0, fmt.Errorf("insufficient credits: have %d, need %d", currentBalance, amount)
+	if _, err := ledger.Post(tx, description,
+		ledger.Leg{Account: account, Amount: -amount},
+		ledger.Leg{Account: ledger.SystemRevenue, Amount: amount},
+	); err != nil {
+		return 0, err
 	}
 
-	// Update balance
-	newBalance := currentBalance - amount
-	_, err = tx.Exec(
-		`UPDATE user_credits SET balance = $1 WHERE user_id = $2`,
-		newBalance, userID,
-	)
-	if err != nil {
-		return 0, fmt.Errorf("failed to update balance: %w", err)
+	newBalance := balance - amount
+
+	if idempotencyKey != "" {
+		body, err := json.Marshal(deductResult{NewBalance: newBalance})
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode idempotency result: %w", err)
+		}
+		if _, err := tx.Exec(
+			`UPDATE idempotency_keys SET status_code = $4, response_body = $5 WHERE user_id = $1 AND key = $2 AND endpoint = $3`,
+			userID, idempotencyKey, deductIdempotencyEndpoint, http.StatusOK, body,
+		); err != nil {
+			return 0, fmt.Errorf("failed to record idempotency key: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	// Record transaction (negative amount for deduction)
+	observability.CreditsDeductedTotal.Add(float64(amount))
+	return newBalance, nil
+}
+
+// claimDeductIdempotencyKey inserts a placeholder idempotency_keys row for
+// (userID, key, deductIdempotencyEndpoint), within tx so the claim only
+// sticks if the whole deduction commits. If the key already exists - a
+// retry - it reports claimed=false and returns the previously recorded
+// result, which is always present since DeductCredits fills it in before
+// committing (unlike the HTTP Idempotency middleware, there's no window
+// where a row is claimed but not yet filled).
+func claimDeductIdempotencyKey(tx *sql.Tx, userID, key string) (claimed bool, prior deductResult, err error) {
+	if _, err = tx.Exec(`SAVEPOINT claim_deduct_idempotency_key`); err != nil {
+		return false, deductResult{}, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
 	_, err = tx.Exec(
-		`INSERT INTO credit_transactions 
-		 (user_id, transaction_type, amount, balance_after, description, mission_id)
-		 VALUES ($1, $2, $3, $4, $5, $6)`,
-		userID, "deduction", -amount, newBalance, description, missionID,
+		`INSERT INTO idempotency_keys (user_id, key, endpoint, request_hash, created_at) VALUES ($1, $2, $3, '', NOW())`,
+		userID, key, deductIdempotencyEndpoint,
 	)
-	if err != nil {
-		return 0, fmt.Errorf("failed to record transaction: %w", err)
+	if err == nil {
+		return true, deductResult{}, nil
 	}
 
-	// Commit transaction
-	if err = tx.Commit(); err != nil {
-		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != deductPqUniqueViolation {
+		return false, deductResult{}, fmt.Errorf("failed to claim idempotency key: %w", err)
 	}
 
-	return newBalance, nil
+	// The failed INSERT aborted tx; roll back to before it so the lookup
+	// below can run.
+	if _, err := tx.Exec(`ROLLBACK TO SAVEPOINT claim_deduct_idempotency_key`); err != nil {
+		return false, deductResult{}, fmt.Errorf("failed to recover from idempotency key conflict: %w", err)
+	}
+
+	var responseBody []byte
+	if scanErr := tx.QueryRow(
+		`SELECT response_body FROM idempotency_keys WHERE user_id = $1 AND key = $2 AND endpoint = $3`,
+		userID, key, deductIdempotencyEndpoint,
+	).Scan(&responseBody); scanErr != nil {
+		return false, deductResult{}, fmt.Errorf("failed to load idempotency key: %w", scanErr)
+	}
+	if unmarshalErr := json.Unmarshal(responseBody, &prior); unmarshalErr != nil {
+		return false, deductResult{}, fmt.Errorf("failed to decode prior idempotency result: %w", unmarshalErr)
+	}
+	return false, prior, nil
 }
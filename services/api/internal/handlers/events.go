@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/eysteinn/driftline/services/api/internal/database"
+	"github.com/eysteinn/driftline/services/api/internal/middleware"
+	"github.com/eysteinn/driftline/services/api/internal/queue"
+	"github.com/eysteinn/driftline/services/api/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// missionEventHeartbeatInterval is how often StreamMissionEvents writes a
+// keep-alive comment when no progress message has arrived, so a proxy or
+// load balancer sitting between the client and this service doesn't time
+// out the connection for looking idle.
+const missionEventHeartbeatInterval = 15 * time.Second
+
+// missionEventRetryMillis is sent to the client in every frame's retry:
+// field, telling a disconnected EventSource how long to wait before
+// reconnecting.
+const missionEventRetryMillis = 3000
+
+// missionProgressEvent is the payload the drift-simulation worker is
+// expected to publish to queue's mission:<id>:progress Redis channel (see
+// queue.SubscribeMissionProgress/PublishMissionProgress) as it works
+// through a job. StreamMissionEvents translates each one into an SSE frame
+// whose event: name is Event.
+type missionProgressEvent struct {
+	Event      string   `json:"event"` // "status", "progress", "completed", or "failed"
+	Status     string   `json:"status,omitempty"`
+	Progress   *float64 `json:"progress,omitempty"`    // percent of particles integrated, 0-100
+	ETASeconds *int     `json:"eta_seconds,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+func isTerminalMissionEvent(event string) bool {
+	return event == "completed" || event == "failed"
+}
+
+// StreamMissionEvents handles GET /v1/missions/:id/events: an SSE stream of
+// a mission's status and progress, so a client can hold one long-lived
+// connection instead of tight-polling GetMissionStatus. Ownership is
+// checked up front exactly like the other mission routes; the Redis
+// subscription itself runs on the request's own context, so it's torn down
+// the instant the client disconnects.
+func StreamMissionEvents(c *gin.Context) {
+	missionID := c.Param("id")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	var status string
+	err := database.DB.QueryRow(
+		`SELECT status FROM missions WHERE id = $1 AND user_id = $2`,
+		missionID, userID,
+	).Scan(&status)
+	if err == sql.ErrNoRows {
+		utils.ErrorResponse(c, http.StatusNotFound, "Mission not found")
+		return
+	} else if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	ctx := c.Request.Context()
+	sub := queue.SubscribeMissionProgress(ctx, missionID)
+	defer sub.Close()
+	ch := sub.Channel()
+
+	c.Writer.Header().Set("Content-Type", "text/event-stream")
+	c.Writer.Header().Set("Cache-Control", "no-cache")
+	c.Writer.Header().Set("Connection", "keep-alive")
+	c.Writer.WriteHeader(http.StatusOK)
+
+	seq := 0
+	writeMissionEvent := func(ev missionProgressEvent) {
+		data, err := json.Marshal(ev)
+		if err != nil {
+			return
+		}
+		seq++
+		fmt.Fprintf(c.Writer, "id: %d\nretry: %d\nevent: %s\ndata: %s\n\n", seq, missionEventRetryMillis, ev.Event, data)
+	}
+
+	// Always announce the mission's current status first, so a client that
+	// connects after the worker's last publish (or that reconnects) isn't
+	// left waiting on a channel that may not see another message for a
+	// while.
+	writeMissionEvent(missionProgressEvent{Event: "status", Status: status})
+	if isTerminalMissionEvent(status) {
+		writeMissionEvent(missionProgressEvent{Event: status, Status: status})
+		return
+	}
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case msg, ok := <-ch:
+			if !ok {
+				return false
+			}
+			var ev missionProgressEvent
+			if err := json.Unmarshal([]byte(msg.Payload), &ev); err != nil {
+				// Malformed publish - ignore it rather than killing the
+				// stream over one bad message.
+				return true
+			}
+			writeMissionEvent(ev)
+			return !isTerminalMissionEvent(ev.Event)
+		case <-ctx.Done():
+			return false
+		case <-time.After(missionEventHeartbeatInterval):
+			fmt.Fprint(c.Writer, ": heartbeat\n\n")
+			return true
+		}
+	})
+}
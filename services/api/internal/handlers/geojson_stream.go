@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// bbox is a [minLon, minLat, maxLon, maxLat] filter for streamSimplifiedGeoJSON.
+type bbox struct {
+	minLon, minLat, maxLon, maxLat float64
+}
+
+// parseBBox parses a "?bbox=minLon,minLat,maxLon,maxLat" query value.
+func parseBBox(raw string) (*bbox, error) {
+	parts := strings.Split(raw, ",")
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("bbox must be minLon,minLat,maxLon,maxLat")
+	}
+	var vals [4]float64
+	for i, p := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bbox value %q: %w", p, err)
+		}
+		vals[i] = v
+	}
+	return &bbox{minLon: vals[0], minLat: vals[1], maxLon: vals[2], maxLat: vals[3]}, nil
+}
+
+// intersects reports whether a geometry's coordinates (in GeoJSON's usual
+// arbitrarily-nested [lon, lat, ...] form) overlap b. Geometries it can't
+// parse are kept rather than dropped, on the assumption a malformed filter
+// shouldn't silently hide data.
+func (b *bbox) intersects(coordinates json.RawMessage) bool {
+	var nested interface{}
+	if err := json.Unmarshal(coordinates, &nested); err != nil {
+		return true
+	}
+
+	minLon, minLat := math.Inf(1), math.Inf(1)
+	maxLon, maxLat := math.Inf(-1), math.Inf(-1)
+	var walk func(interface{})
+	walk = func(v interface{}) {
+		arr, ok := v.([]interface{})
+		if !ok {
+			return
+		}
+		if lon, lat, ok := asPoint(arr); ok {
+			minLon, maxLon = math.Min(minLon, lon), math.Max(maxLon, lon)
+			minLat, maxLat = math.Min(minLat, lat), math.Max(maxLat, lat)
+			return
+		}
+		for _, e := range arr {
+			walk(e)
+		}
+	}
+	walk(nested)
+
+	if math.IsInf(minLon, 1) {
+		return true
+	}
+	return minLon <= b.maxLon && maxLon >= b.minLon && minLat <= b.maxLat && maxLat >= b.minLat
+}
+
+// asPoint reports whether arr is a GeoJSON position ([lon, lat, ...]), as
+// opposed to a further level of nesting (a ring, a line, a polygon's list
+// of rings).
+func asPoint(arr []interface{}) (lon, lat float64, ok bool) {
+	if len(arr) < 2 {
+		return 0, 0, false
+	}
+	lon, ok1 := arr[0].(float64)
+	lat, ok2 := arr[1].(float64)
+	if !ok1 || !ok2 {
+		return 0, 0, false
+	}
+	for _, e := range arr {
+		if _, isNum := e.(float64); !isNum {
+			return 0, 0, false
+		}
+	}
+	return lon, lat, true
+}
+
+// simplifyCoordinates Douglas-Peucker-simplifies the innermost rings/lines
+// of a GeoJSON coordinates array, regardless of how deeply nested they are
+// (a LineString's [][]float64 vs. a Polygon's [][][]float64).
+func simplifyCoordinates(coordinates json.RawMessage, tolerance float64) (json.RawMessage, error) {
+	var nested interface{}
+	if err := json.Unmarshal(coordinates, &nested); err != nil {
+		return coordinates, err
+	}
+	out, err := json.Marshal(simplifyNested(nested, tolerance))
+	if err != nil {
+		return coordinates, err
+	}
+	return out, nil
+}
+
+func simplifyNested(v interface{}, tolerance float64) interface{} {
+	arr, ok := v.([]interface{})
+	if !ok || len(arr) == 0 {
+		return v
+	}
+	if isLineOfPoints(arr) {
+		return douglasPeucker(arr, tolerance)
+	}
+	result := make([]interface{}, len(arr))
+	for i, e := range arr {
+		result[i] = simplifyNested(e, tolerance)
+	}
+	return result
+}
+
+// isLineOfPoints reports whether arr is a line/ring of positions, i.e. the
+// innermost level of coordinate nesting, rather than another array of
+// rings/lines.
+func isLineOfPoints(arr []interface{}) bool {
+	for _, e := range arr {
+		pt, ok := e.([]interface{})
+		if !ok {
+			return false
+		}
+		if _, _, ok := asPoint(pt); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// douglasPeucker simplifies a line of GeoJSON positions, keeping only
+// points that deviate from the straight line between its endpoints by more
+// than tolerance (in the geometry's own coordinate units, i.e. degrees for
+// unprojected GeoJSON).
+func douglasPeucker(points []interface{}, tolerance float64) []interface{} {
+	if len(points) < 3 || tolerance <= 0 {
+		return points
+	}
+
+	x1, y1, _ := asPoint(points[0].([]interface{}))
+	x2, y2, _ := asPoint(points[len(points)-1].([]interface{}))
+
+	maxDist := -1.0
+	splitAt := 0
+	for i := 1; i < len(points)-1; i++ {
+		x, y, _ := asPoint(points[i].([]interface{}))
+		d := perpendicularDistance(x, y, x1, y1, x2, y2)
+		if d > maxDist {
+			maxDist = d
+			splitAt = i
+		}
+	}
+
+	if maxDist > tolerance {
+		left := douglasPeucker(points[:splitAt+1], tolerance)
+		right := douglasPeucker(points[splitAt:], tolerance)
+		return append(left[:len(left)-1], right...)
+	}
+	return []interface{}{points[0], points[len(points)-1]}
+}
+
+func perpendicularDistance(px, py, x1, y1, x2, y2 float64) float64 {
+	dx, dy := x2-x1, y2-y1
+	if dx == 0 && dy == 0 {
+		return math.Hypot(px-x1, py-y1)
+	}
+	t := ((px-x1)*dx + (py-y1)*dy) / (dx*dx + dy*dy)
+	projX, projY := x1+t*dx, y1+t*dy
+	return math.Hypot(px-projX, py-projY)
+}
+
+// streamSimplifiedGeoJSON re-encodes a GeoJSON FeatureCollection read from
+// body, applying a bbox filter and/or Douglas-Peucker simplification to
+// each feature's geometry. It decodes and re-encodes one feature at a time
+// via json.Decoder/Encoder tokens, so a multi-hundred-MB trajectory
+// document never sits in memory as a whole - only one feature does.
+func streamSimplifiedGeoJSON(w io.Writer, body io.Reader, tolerance float64, filter *bbox) error {
+	dec := json.NewDecoder(body)
+	enc := json.NewEncoder(w)
+
+	if _, err := dec.Token(); err != nil { // consume the FeatureCollection's '{'
+		return fmt.Errorf("failed to read GeoJSON: %w", err)
+	}
+
+	if _, err := io.WriteString(w, `{"type":"FeatureCollection","features":[`); err != nil {
+		return err
+	}
+
+	wroteFeature := false
+	for dec.More() {
+		keyTok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := keyTok.(string)
+
+		if key != "features" {
+			// Not the array we're transforming (e.g. a top-level "bbox" or
+			// "crs" member) - decode and discard its value without
+			// buffering it alongside the features.
+			var discard json.RawMessage
+			if err := dec.Decode(&discard); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if _, err := dec.Token(); err != nil { // consume the features array's '['
+			return err
+		}
+		for dec.More() {
+			var feature struct {
+				Type       string          `json:"type"`
+				Properties json.RawMessage `json:"properties"`
+				Geometry   struct {
+					Type        string          `json:"type"`
+					Coordinates json.RawMessage `json:"coordinates"`
+				} `json:"geometry"`
+			}
+			if err := dec.Decode(&feature); err != nil {
+				return err
+			}
+
+			if filter != nil && len(feature.Geometry.Coordinates) > 0 && !filter.intersects(feature.Geometry.Coordinates) {
+				continue
+			}
+			if tolerance > 0 && len(feature.Geometry.Coordinates) > 0 {
+				if simplified, err := simplifyCoordinates(feature.Geometry.Coordinates, tolerance); err == nil {
+					feature.Geometry.Coordinates = simplified
+				}
+			}
+
+			if wroteFeature {
+				if _, err := io.WriteString(w, ","); err != nil {
+					return err
+				}
+			}
+			if err := enc.Encode(feature); err != nil {
+				return err
+			}
+			wroteFeature = true
+		}
+		if _, err := dec.Token(); err != nil { // consume the features array's ']'
+			return err
+		}
+	}
+
+	_, err := io.WriteString(w, "]}")
+	return err
+}
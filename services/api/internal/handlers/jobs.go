@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/eysteinn/driftline/services/api/internal/middleware"
+	"github.com/eysteinn/driftline/services/api/internal/queue"
+	"github.com/eysteinn/driftline/services/api/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// ListDeadLetterJobs returns every drift job that exhausted its retries,
+// for operators investigating stuck simulations.
+func ListDeadLetterJobs(c *gin.Context) {
+	if role, _ := middleware.GetRole(c); role != "admin" {
+		utils.ErrorResponse(c, http.StatusForbidden, "Admin role required")
+		return
+	}
+
+	entries, err := queue.ListDLQ()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to list dead-letter queue")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"jobs": entries})
+}
+
+// RequeueDeadLetterJob moves a dead-lettered job back onto the main queue
+// for another attempt.
+func RequeueDeadLetterJob(c *gin.Context) {
+	if role, _ := middleware.GetRole(c); role != "admin" {
+		utils.ErrorResponse(c, http.StatusForbidden, "Admin role required")
+		return
+	}
+
+	jobID := c.Param("id")
+	if err := queue.RequeueFromDLQ(jobID); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Job requeued"})
+}
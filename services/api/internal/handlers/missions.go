@@ -6,19 +6,17 @@ import (
 	"io"
 	"log"
 	"net/http"
-	"os"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/eysteinn/driftline/services/api/internal/credits"
 	"github.com/eysteinn/driftline/services/api/internal/database"
 	"github.com/eysteinn/driftline/services/api/internal/middleware"
 	"github.com/eysteinn/driftline/services/api/internal/models"
+	"github.com/eysteinn/driftline/services/api/internal/observability"
 	"github.com/eysteinn/driftline/services/api/internal/queue"
+	"github.com/eysteinn/driftline/services/api/internal/storage"
 	"github.com/eysteinn/driftline/services/api/internal/utils"
 	"github.com/gin-gonic/gin"
 )
@@ -38,47 +36,18 @@ func CreateMission(c *gin.Context) {
 		return
 	}
 
+	requestLog := observability.Logger.With("request_id", middleware.GetRequestID(c), "user_id", userID)
+
 	// Set defaults
 	if req.EnsembleSize == 0 {
 		req.EnsembleSize = 1000
 	}
 
-	// Calculate credit cost based on mission parameters
-	// Base cost: 10 credits
-	// Additional cost: 1 credit per 24 hours of forecast
-	// Additional cost: 1 credit per 1000 particles beyond 1000
-	creditsCost := 10
-	creditsCost += (req.ForecastHours + 23) / 24 // Round up hours to days
-	if req.EnsembleSize > 1000 {
-		creditsCost += (req.EnsembleSize - 1000) / 1000
-	}
-
-	// Check if user has sufficient credits
-	var currentBalance int
-	err := database.DB.QueryRow(
-		`SELECT balance FROM user_credits WHERE user_id = $1`,
-		userID,
-	).Scan(&currentBalance)
-
-	if err == sql.ErrNoRows {
-		utils.ErrorResponse(c, http.StatusPaymentRequired, 
-			fmt.Sprintf("Insufficient credits. This mission requires %d credits. Please purchase credits to continue.", creditsCost))
-		return
-	} else if err != nil {
-		log.Printf("Failed to check credit balance: %v", err)
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to check credit balance")
-		return
-	}
-
-	if currentBalance < creditsCost {
-		utils.ErrorResponse(c, http.StatusPaymentRequired,
-			fmt.Sprintf("Insufficient credits. You have %d credits, but this mission requires %d credits. Please purchase more credits.", currentBalance, creditsCost))
-		return
-	}
+	creditsCost := credits.Cost(req.ForecastHours, req.EnsembleSize)
 
 	// Insert mission into database
 	var mission models.Mission
-	err = database.DB.QueryRow(
+	err := database.DB.QueryRow(
 		`INSERT INTO missions (
 			user_id, name, description, last_known_lat, last_known_lon, 
 			last_known_time, object_type, uncertainty_radius_m, 
@@ -104,19 +73,23 @@ func CreateMission(c *gin.Context) {
 		return
 	}
 
-	// Deduct credits for the mission
-	missionIDStr := mission.ID
-	description := fmt.Sprintf("Mission: %s (%d forecast hours, %d particles)", mission.Name, req.ForecastHours, req.EnsembleSize)
-	newBalance, err := DeductCredits(userID, creditsCost, description, &missionIDStr)
+	requestLog = requestLog.With("mission_id", mission.ID)
+
+	// Reserve credits for the mission. The hold is released by a Refund if
+	// enqueueing fails below; otherwise it's resolved once the mission's
+	// outcome is known - CompleteMission commits it on success, and
+	// queue.OnDeadLetter (see cmd/api-gateway/main.go) refunds it if the
+	// job exhausts its retries.
+	reservationID, err := credits.Reserve(userID, mission.ID, creditsCost)
 	if err != nil {
-		// Failed to deduct credits - delete the mission and return error
-		log.Printf("Failed to deduct credits for mission %s: %v", mission.ID, err)
+		requestLog.Error("failed to reserve credits for mission", "error", err)
 		database.DB.Exec(`DELETE FROM missions WHERE id = $1`, mission.ID)
-		utils.ErrorResponse(c, http.StatusPaymentRequired, fmt.Sprintf("Failed to deduct credits: %v", err))
+		observability.MissionsCreatedTotal.WithLabelValues("failed").Inc()
+		utils.ErrorResponse(c, http.StatusPaymentRequired, fmt.Sprintf("Failed to reserve credits: %v", err))
 		return
 	}
 
-	log.Printf("Deducted %d credits for mission %s. New balance: %d", creditsCost, mission.ID, newBalance)
+	requestLog.Info("reserved credits for mission", "credits_cost", creditsCost, "reservation_id", reservationID)
 
 	// Enqueue job to Redis for processing
 	objectTypeInt := 1 // Default to Person-in-water
@@ -127,6 +100,18 @@ func CreateMission(c *gin.Context) {
 		}
 	}
 
+	assetKeys, err := resolveMissionAssets(userID, mission.ID, req.AssetIDs)
+	if err != nil {
+		requestLog.Error("failed to resolve assets for mission", "error", err)
+		if refundErr := credits.Refund(reservationID, "failed to resolve mission assets"); refundErr != nil {
+			requestLog.Error("failed to refund reservation for mission", "reservation_id", reservationID, "error", refundErr)
+		}
+		database.DB.Exec(`DELETE FROM missions WHERE id = $1`, mission.ID)
+		observability.MissionsCreatedTotal.WithLabelValues("failed").Inc()
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	jobParams := queue.DriftJobParams{
 		Latitude:      req.LastKnownLat,
 		Longitude:     req.LastKnownLon,
@@ -134,11 +119,16 @@ func CreateMission(c *gin.Context) {
 		DurationHours: req.ForecastHours,
 		NumParticles:  req.EnsembleSize,
 		ObjectType:    objectTypeInt,
+		AssetKeys:     assetKeys,
 	}
 
 	if err := queue.EnqueueDriftJob(mission.ID, jobParams); err != nil {
-		// Failed to enqueue the job - return error response
-		log.Printf("Failed to enqueue drift job for mission %s: %v", mission.ID, err)
+		// Failed to enqueue the job - refund the reservation and return an error
+		requestLog.Error("failed to enqueue drift job for mission", "error", err)
+		if refundErr := credits.Refund(reservationID, "failed to enqueue drift simulation job"); refundErr != nil {
+			requestLog.Error("failed to refund reservation for mission", "reservation_id", reservationID, "error", refundErr)
+		}
+		observability.MissionsCreatedTotal.WithLabelValues("failed").Inc()
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to enqueue job for processing")
 		return
 	}
@@ -150,12 +140,13 @@ func CreateMission(c *gin.Context) {
 	)
 	if err != nil {
 		// Log the error but don't fail - the job is already queued and worker will update status
-		log.Printf("Failed to update mission %s status to queued: %v", mission.ID, err)
+		requestLog.Error("failed to update mission status to queued", "error", err)
 	} else {
 		mission.Status = "queued"
 		mission.UpdatedAt = time.Now()
 	}
 
+	observability.MissionsCreatedTotal.WithLabelValues("queued").Inc()
 	utils.SuccessResponse(c, http.StatusCreated, mission)
 }
 
@@ -387,7 +378,8 @@ func DownloadMissionResults(c *gin.Context) {
 	missionID := c.Param("id")
 	format := c.Query("format")
 
-	log.Printf("Download request: mission_id=%s, format=%s", missionID, format)
+	requestLog := observability.Logger.With("request_id", middleware.GetRequestID(c), "mission_id", missionID)
+	requestLog.Info("download request", "format", format)
 
 	// Validate format parameter
 	validFormats := map[string]bool{
@@ -396,7 +388,7 @@ func DownloadMissionResults(c *gin.Context) {
 		"pdf":     true,
 	}
 	if !validFormats[format] {
-		log.Printf("Invalid format: %s", format)
+		requestLog.Warn("invalid download format", "format", format)
 		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid format. Must be one of: netcdf, geojson, pdf")
 		return
 	}
@@ -404,12 +396,10 @@ func DownloadMissionResults(c *gin.Context) {
 	// Get user ID from JWT and verify ownership
 	userID, ok := middleware.GetUserID(c)
 	if !ok {
-		log.Printf("User not authenticated")
 		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated")
 		return
 	}
-
-	log.Printf("Checking mission ownership: mission_id=%s, user_id=%s", missionID, userID)
+	requestLog = requestLog.With("user_id", userID)
 
 	// Verify mission ownership
 	var missionStatus string
@@ -419,20 +409,18 @@ func DownloadMissionResults(c *gin.Context) {
 	).Scan(&missionStatus)
 
 	if err == sql.ErrNoRows {
-		log.Printf("Mission not found or not owned by user")
+		requestLog.Warn("mission not found or not owned by user")
 		utils.ErrorResponse(c, http.StatusNotFound, "Mission not found")
 		return
 	} else if err != nil {
-		log.Printf("Database error checking mission: %v", err)
+		requestLog.Error("database error checking mission", "error", err)
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Database error")
 		return
 	}
 
-	log.Printf("Mission status: %s", missionStatus)
-
 	// Check if mission is completed
 	if missionStatus != "completed" {
-		log.Printf("Mission not completed yet")
+		requestLog.Warn("mission not completed yet", "status", missionStatus)
 		utils.ErrorResponse(c, http.StatusBadRequest, "Mission is not completed yet")
 		return
 	}
@@ -450,106 +438,354 @@ func DownloadMissionResults(c *gin.Context) {
 		query = `SELECT pdf_report_path FROM mission_results WHERE mission_id = $1`
 	}
 
-	log.Printf("Executing query: %s with mission_id=%s", query, missionID)
 	err = database.DB.QueryRow(query, missionID).Scan(&filePath)
 
 	if err == sql.ErrNoRows {
-		log.Printf("No results found in mission_results table")
+		requestLog.Warn("no results found in mission_results table", "format", format)
 		utils.ErrorResponse(c, http.StatusNotFound, "Results not found")
 		return
 	} else if err != nil {
-		log.Printf("Database error querying results: %v", err)
+		requestLog.Error("database error querying results", "error", err)
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Database error")
 		return
 	}
 
-	log.Printf("File path retrieved: %v", filePath)
-
 	if filePath == nil || *filePath == "" {
-		log.Printf("%s file path is empty", format)
+		requestLog.Warn("result file path is empty", "format", format)
 		utils.ErrorResponse(c, http.StatusNotFound, fmt.Sprintf("%s file not available", format))
 		return
 	}
 
+	// format=geojson additionally accepts ?simplify=<tolerance> and/or
+	// ?bbox=minLon,minLat,maxLon,maxLat to transform the trajectory on the
+	// fly - that changes the response body, so it bypasses the
+	// range/conditional path below entirely.
+	if format == "geojson" && (c.Query("simplify") != "" || c.Query("bbox") != "") {
+		if err := streamGeoJSONTransform(c, *filePath, missionID); err != nil {
+			requestLog.Error("failed to stream simplified geojson", "error", err)
+			utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		return
+	}
+
 	// Download from S3 and stream to client
 	err = streamFromS3(c, *filePath, format, missionID)
 	if err != nil {
-		log.Printf("Failed to stream file from S3: %v", err)
+		requestLog.Error("failed to stream file from s3", "error", err)
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to download file")
 		return
 	}
 }
 
-// streamFromS3 downloads a file from S3 and streams it to the client
-func streamFromS3(c *gin.Context, s3Path string, format string, missionID string) error {
-	// Parse S3 path (s3://bucket/key)
+// parseS3Path splits an "s3://bucket/key" path as stored in mission_results
+// into its bucket and key.
+func parseS3Path(s3Path string) (bucket, key string, err error) {
 	if len(s3Path) < 5 || s3Path[:5] != "s3://" {
-		return fmt.Errorf("invalid S3 path: %s", s3Path)
+		return "", "", fmt.Errorf("invalid S3 path: %s", s3Path)
 	}
 
 	pathParts := strings.SplitN(s3Path[5:], "/", 2)
 	if len(pathParts) != 2 {
-		return fmt.Errorf("invalid S3 path format: %s", s3Path)
+		return "", "", fmt.Errorf("invalid S3 path format: %s", s3Path)
 	}
 
-	bucket := pathParts[0]
-	key := pathParts[1]
+	return pathParts[0], pathParts[1], nil
+}
 
-	// Initialize S3 client
-	s3Endpoint := os.Getenv("S3_ENDPOINT")
-	s3AccessKey := os.Getenv("S3_ACCESS_KEY")
-	s3SecretKey := os.Getenv("S3_SECRET_KEY")
+// resultFilename returns the Content-Disposition filename for a mission's
+// result download, by format.
+func resultFilename(format, missionID string) string {
+	switch format {
+	case "netcdf":
+		return fmt.Sprintf("mission-%s-results.nc", missionID)
+	case "geojson":
+		return fmt.Sprintf("mission-%s-trajectories.geojson", missionID)
+	case "pdf":
+		return fmt.Sprintf("mission-%s-report.pdf", missionID)
+	default:
+		return fmt.Sprintf("mission-%s-results", missionID)
+	}
+}
 
-	if s3Endpoint == "" || s3AccessKey == "" || s3SecretKey == "" {
-		return fmt.Errorf("S3 configuration not set")
+// streamFromS3 downloads a file from S3 and streams it to the client,
+// using the shared storage.Service session builder rather than
+// constructing its own AWS session. It honors Range, If-None-Match, and
+// If-Modified-Since, responding 206/Content-Range for a byte range and 304
+// for a conditional GET that matches, so a multi-GB NetCDF download can be
+// resumed instead of restarted from byte zero.
+func streamFromS3(c *gin.Context, s3Path string, format string, missionID string) error {
+	bucket, key, err := parseS3Path(s3Path)
+	if err != nil {
+		return err
 	}
 
-	// Configure AWS session
-	sess, err := session.NewSession(&aws.Config{
-		Endpoint:         aws.String(s3Endpoint),
-		Region:           aws.String("us-east-1"),
-		Credentials:      credentials.NewStaticCredentials(s3AccessKey, s3SecretKey, ""),
-		S3ForcePathStyle: aws.Bool(true),
-	})
+	s3, err := newResultsStorage()
 	if err != nil {
-		return fmt.Errorf("failed to create AWS session: %w", err)
+		return err
 	}
 
-	s3Client := s3.New(sess)
+	info, err := s3.Stat(bucket, key)
+	if err != nil {
+		return fmt.Errorf("failed to stat object in S3: %w", err)
+	}
+	etag := `"` + info.ETag + `"`
 
-	// Get object from S3
-	result, err := s3Client.GetObject(&s3.GetObjectInput{
-		Bucket: aws.String(bucket),
-		Key:    aws.String(key),
+	if match := c.GetHeader("If-None-Match"); match != "" && match == etag {
+		c.Status(http.StatusNotModified)
+		return nil
+	}
+	if since := c.GetHeader("If-Modified-Since"); since != "" {
+		if t, parseErr := http.ParseTime(since); parseErr == nil && !info.LastModified.After(t) {
+			c.Status(http.StatusNotModified)
+			return nil
+		}
+	}
+
+	rangeHeader := c.GetHeader("Range")
+	stream, err := s3.GetObjectRange(bucket, key, storage.RangeRequest{
+		Range:       rangeHeader,
+		IfNoneMatch: c.GetHeader("If-None-Match"),
 	})
 	if err != nil {
 		return fmt.Errorf("failed to get object from S3: %w", err)
 	}
-	defer result.Body.Close()
+	if stream.NotModified {
+		c.Status(http.StatusNotModified)
+		return nil
+	}
+	defer stream.Body.Close()
 
-	// Set appropriate headers
-	var contentType string
-	var filename string
+	c.Header("Content-Type", resultContentType(format))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", resultFilename(format, missionID)))
+	c.Header("Accept-Ranges", "bytes")
+	c.Header("ETag", etag)
+	c.Header("Last-Modified", info.LastModified.UTC().Format(http.TimeFormat))
 
-	switch format {
-	case "netcdf":
-		contentType = "application/x-netcdf"
-		filename = fmt.Sprintf("mission-%s-results.nc", missionID)
-	case "geojson":
-		contentType = "application/geo+json"
-		filename = fmt.Sprintf("mission-%s-trajectories.geojson", missionID)
-	case "pdf":
-		contentType = "application/pdf"
-		filename = fmt.Sprintf("mission-%s-report.pdf", missionID)
-	default:
-		contentType = "application/octet-stream"
-		filename = fmt.Sprintf("mission-%s-results", missionID)
+	if rangeHeader != "" && stream.ContentRange != "" {
+		c.Header("Content-Range", stream.ContentRange)
+		c.Header("Content-Length", strconv.FormatInt(stream.ContentLength, 10))
+		c.Status(http.StatusPartialContent)
+	} else {
+		c.Header("Content-Length", strconv.FormatInt(info.Size, 10))
+		c.Status(http.StatusOK)
+	}
+
+	n, err := io.Copy(c.Writer, stream.Body)
+	observability.S3DownloadBytes.Add(float64(n))
+	return err
+}
+
+// streamGeoJSONTransform serves format=geojson with ?simplify=<tolerance>
+// and/or ?bbox=minLon,minLat,maxLon,maxLat applied on the fly (see
+// streamSimplifiedGeoJSON), rather than proxying the stored document
+// byte-for-byte. Range/conditional requests aren't supported on this path:
+// the transform changes the document's content and length, so there's no
+// single ETag or byte range to offer ahead of time.
+func streamGeoJSONTransform(c *gin.Context, s3Path, missionID string) error {
+	bucket, key, err := parseS3Path(s3Path)
+	if err != nil {
+		return err
 	}
 
-	c.Header("Content-Type", contentType)
-	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	tolerance := 0.0
+	if raw := c.Query("simplify"); raw != "" {
+		tolerance, err = strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("invalid simplify value %q: %w", raw, err)
+		}
+	}
 
-	// Stream the file
-	_, err = io.Copy(c.Writer, result.Body)
+	var filter *bbox
+	if raw := c.Query("bbox"); raw != "" {
+		filter, err = parseBBox(raw)
+		if err != nil {
+			return err
+		}
+	}
+
+	s3, err := newResultsStorage()
+	if err != nil {
+		return err
+	}
+
+	body, err := s3.GetObject(bucket, key)
+	if err != nil {
+		return fmt.Errorf("failed to get object from S3: %w", err)
+	}
+	defer body.Close()
+
+	c.Header("Content-Type", "application/geo+json")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", resultFilename("geojson", missionID)))
+	c.Status(http.StatusOK)
+
+	counter := &byteCountingWriter{w: c.Writer}
+	err = streamSimplifiedGeoJSON(counter, body, tolerance, filter)
+	observability.S3DownloadBytes.Add(float64(counter.n))
 	return err
 }
+
+// byteCountingWriter wraps an io.Writer to track how many bytes have passed
+// through it, so streamGeoJSONTransform can report download volume even
+// though it writes through streamSimplifiedGeoJSON's encoder rather than a
+// single io.Copy.
+type byteCountingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (b *byteCountingWriter) Write(p []byte) (int, error) {
+	n, err := b.w.Write(p)
+	b.n += int64(n)
+	return n, err
+}
+
+// RejudgeMission re-runs a mission that ended in the dead-letter queue (or
+// simply wants another attempt) by reserving fresh credits and enqueuing a
+// new drift job, without touching its existing mission_attempts history.
+// The mission keeps its original ID, so results from the new attempt land
+// alongside whatever the previous run already wrote.
+func RejudgeMission(c *gin.Context) {
+	missionID := c.Param("id")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	var m models.Mission
+	err := database.DB.QueryRow(
+		`SELECT id, user_id, last_known_lat, last_known_lon, last_known_time,
+		        object_type, forecast_hours, ensemble_size, backtracking, status
+		 FROM missions WHERE id = $1 AND user_id = $2`,
+		missionID, userID,
+	).Scan(
+		&m.ID, &m.UserID, &m.LastKnownLat, &m.LastKnownLon, &m.LastKnownTime,
+		&m.ObjectType, &m.ForecastHours, &m.EnsembleSize, &m.Backtracking, &m.Status,
+	)
+	if err == sql.ErrNoRows {
+		utils.ErrorResponse(c, http.StatusNotFound, "Mission not found")
+		return
+	} else if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	if m.Status != "failed" && m.Status != "completed" {
+		utils.ErrorResponse(c, http.StatusConflict, fmt.Sprintf("Mission is %s, not eligible for rejudging", m.Status))
+		return
+	}
+
+	// Resolve the prior attempt's reservation before taking out a new one -
+	// a completed mission's was committed by CompleteMission and a failed
+	// one's was refunded by queue.OnDeadLetter, but both calls are no-ops
+	// if that already happened, so doing it again here just guards against
+	// a reservation left pending by an older, pre-CompleteMission mission.
+	if m.Status == "completed" {
+		if err := credits.CommitMission(m.ID); err != nil {
+			log.Printf("Failed to resolve prior reservation for mission %s before rejudge: %v", m.ID, err)
+		}
+	} else {
+		if err := credits.RefundMission(m.ID, "mission rejudged"); err != nil {
+			log.Printf("Failed to resolve prior reservation for mission %s before rejudge: %v", m.ID, err)
+		}
+	}
+
+	creditsCost := credits.Cost(m.ForecastHours, m.EnsembleSize)
+	reservationID, err := credits.Reserve(userID, m.ID, creditsCost)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusPaymentRequired, fmt.Sprintf("Failed to reserve credits: %v", err))
+		return
+	}
+
+	objectTypeInt := 1
+	if val, err := strconv.Atoi(m.ObjectType); err == nil {
+		objectTypeInt = val
+	}
+
+	jobParams := queue.DriftJobParams{
+		Latitude:      m.LastKnownLat,
+		Longitude:     m.LastKnownLon,
+		StartTime:     m.LastKnownTime.Format(time.RFC3339),
+		DurationHours: m.ForecastHours,
+		NumParticles:  m.EnsembleSize,
+		ObjectType:    objectTypeInt,
+		Backtracking:  m.Backtracking,
+	}
+
+	if err := queue.EnqueueDriftJob(m.ID, jobParams); err != nil {
+		log.Printf("Failed to enqueue rejudge job for mission %s: %v", m.ID, err)
+		if refundErr := credits.Refund(reservationID, "failed to enqueue rejudge job"); refundErr != nil {
+			log.Printf("Failed to refund reservation %s for mission %s: %v", reservationID, m.ID, refundErr)
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to enqueue job for processing")
+		return
+	}
+
+	_, err = database.DB.Exec(
+		`UPDATE missions SET status = $1, error_message = NULL, updated_at = $2 WHERE id = $3`,
+		"queued", time.Now(), m.ID,
+	)
+	if err != nil {
+		log.Printf("Failed to update mission %s status to queued on rejudge: %v", m.ID, err)
+	}
+
+	log.Printf("Reserved %d credits (reservation %s) for rejudge of mission %s", creditsCost, reservationID, m.ID)
+
+	utils.SuccessResponse(c, http.StatusAccepted, gin.H{"id": m.ID, "status": "queued"})
+}
+
+// GetMissionAttempts returns the attempt history recorded for a mission's
+// drift job (see queue.recordAttemptStarted/recordAttemptFinished), for
+// callers who want to see retries and timeouts beyond the mission's
+// current status.
+func GetMissionAttempts(c *gin.Context) {
+	missionID := c.Param("id")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	var owner string
+	err := database.DB.QueryRow(`SELECT user_id FROM missions WHERE id = $1`, missionID).Scan(&owner)
+	if err == sql.ErrNoRows {
+		utils.ErrorResponse(c, http.StatusNotFound, "Mission not found")
+		return
+	} else if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if owner != userID {
+		utils.ErrorResponse(c, http.StatusNotFound, "Mission not found")
+		return
+	}
+
+	rows, err := database.DB.Query(
+		`SELECT id, mission_id, job_id, attempt, status, error_message, started_at, finished_at
+		 FROM mission_attempts WHERE mission_id = $1 ORDER BY started_at ASC`,
+		missionID,
+	)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Database error")
+		return
+	}
+	defer rows.Close()
+
+	attempts := []models.MissionAttempt{}
+	for rows.Next() {
+		var a models.MissionAttempt
+		if err := rows.Scan(
+			&a.ID, &a.MissionID, &a.JobID, &a.Attempt, &a.Status,
+			&a.ErrorMessage, &a.StartedAt, &a.FinishedAt,
+		); err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to scan attempt")
+			return
+		}
+		attempts = append(attempts, a)
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{"attempts": attempts})
+}
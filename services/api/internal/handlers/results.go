@@ -0,0 +1,343 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/eysteinn/driftline/services/api/internal/credits"
+	"github.com/eysteinn/driftline/services/api/internal/database"
+	"github.com/eysteinn/driftline/services/api/internal/middleware"
+	"github.com/eysteinn/driftline/services/api/internal/storage"
+	"github.com/eysteinn/driftline/services/api/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	resultURLTTL = 15 * time.Minute
+	uploadURLTTL = 1 * time.Hour
+)
+
+// resultsPathCol maps a results format query param to its mission_results
+// column, shared by GetMissionResultURL and GetMissionUploadURL.
+var resultsPathCol = map[string]string{
+	"netcdf":  "netcdf_path",
+	"geojson": "geojson_path",
+	"pdf":     "pdf_report_path",
+}
+
+// GetMissionResultGeoJSON handles GET /v1/missions/:id/result.geojson: it
+// serves the persisted 50%/90% search-area polygons directly as GeoJSON,
+// for frontend map rendering.
+func GetMissionResultGeoJSON(c *gin.Context) {
+	missionID := c.Param("id")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	// Verify mission ownership before touching mission_results.
+	var missionStatus string
+	err := database.DB.QueryRow(
+		`SELECT status FROM missions WHERE id = $1 AND user_id = $2`,
+		missionID, userID,
+	).Scan(&missionStatus)
+
+	if err == sql.ErrNoRows {
+		utils.ErrorResponse(c, http.StatusNotFound, "Mission not found")
+		return
+	} else if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	var search50, search90 []byte
+	err = database.DB.QueryRow(
+		`SELECT search_area_50_geom, search_area_90_geom FROM mission_results WHERE mission_id = $1`,
+		missionID,
+	).Scan(&search50, &search90)
+
+	if err == sql.ErrNoRows {
+		utils.ErrorResponse(c, http.StatusNotFound, "Results not found")
+		return
+	} else if err != nil {
+		log.Printf("Database error fetching result geometry: %v", err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	features := make([]json.RawMessage, 0, 2)
+	if len(search50) > 0 {
+		features = append(features, search50)
+	}
+	if len(search90) > 0 {
+		features = append(features, search90)
+	}
+	if len(features) == 0 {
+		utils.ErrorResponse(c, http.StatusNotFound, "Search area polygons not available yet")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"type":     "FeatureCollection",
+		"features": features,
+	})
+}
+
+// resultContentType returns the MIME type stored results of format are
+// served/uploaded as.
+func resultContentType(format string) string {
+	switch format {
+	case "netcdf":
+		return "application/x-netcdf"
+	case "geojson":
+		return "application/geo+json"
+	case "pdf":
+		return "application/pdf"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// newResultsStorage builds a presigning Service from the API's S3
+// environment configuration, the same one streamFromS3 uses to stream
+// results today.
+func newResultsStorage() (*storage.Service, error) {
+	return storage.NewService(os.Getenv("S3_ENDPOINT"), os.Getenv("S3_ACCESS_KEY"), os.Getenv("S3_SECRET_KEY"))
+}
+
+// resultsBucket returns the bucket the drift-simulation worker uploads
+// mission results into.
+func resultsBucket() string {
+	if b := os.Getenv("S3_RESULTS_BUCKET"); b != "" {
+		return b
+	}
+	return "driftline-results"
+}
+
+// GetMissionResultURL handles GET /v1/missions/:id/results/url: it returns
+// a short-lived presigned GET URL for a completed mission's result file,
+// so the client downloads it directly from the bucket instead of the API
+// process proxying the bytes.
+func GetMissionResultURL(c *gin.Context) {
+	missionID := c.Param("id")
+	format := c.DefaultQuery("format", "geojson")
+
+	column, ok := resultsPathCol[format]
+	if !ok {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid format. Must be one of: netcdf, geojson, pdf")
+		return
+	}
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	var missionStatus string
+	err := database.DB.QueryRow(
+		`SELECT status FROM missions WHERE id = $1 AND user_id = $2`,
+		missionID, userID,
+	).Scan(&missionStatus)
+
+	if err == sql.ErrNoRows {
+		utils.ErrorResponse(c, http.StatusNotFound, "Mission not found")
+		return
+	} else if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if missionStatus != "completed" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Mission is not completed yet")
+		return
+	}
+
+	var filePath *string
+	err = database.DB.QueryRow(
+		fmt.Sprintf(`SELECT %s FROM mission_results WHERE mission_id = $1`, column),
+		missionID,
+	).Scan(&filePath)
+
+	if err == sql.ErrNoRows || filePath == nil || *filePath == "" {
+		utils.ErrorResponse(c, http.StatusNotFound, fmt.Sprintf("%s file not available", format))
+		return
+	} else if err != nil {
+		log.Printf("Database error querying results: %v", err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	bucket, key, err := parseS3Path(*filePath)
+	if err != nil {
+		log.Printf("Invalid stored S3 path for mission %s: %v", missionID, err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate download URL")
+		return
+	}
+
+	s3, err := newResultsStorage()
+	if err != nil {
+		log.Printf("Failed to initialize S3 client: %v", err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate download URL")
+		return
+	}
+
+	url, err := s3.PresignGet(bucket, key, resultURLTTL)
+	if err != nil {
+		log.Printf("Failed to presign GET for mission %s: %v", missionID, err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate download URL")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{
+		"url":        url,
+		"expires_at": time.Now().Add(resultURLTTL),
+	})
+}
+
+// GetMissionUploadURL handles POST /v1/missions/:id/upload-url: it returns
+// a short-lived presigned PUT URL the drift-simulation worker uploads a
+// mission's result file to directly, instead of streaming the (often
+// hundreds-of-MB) NetCDF output through the API process. Gated by
+// RequireWorkerAuth rather than a user's JWT, since the worker has no user
+// session.
+func GetMissionUploadURL(c *gin.Context) {
+	missionID := c.Param("id")
+	format := c.DefaultQuery("format", "netcdf")
+
+	if _, ok := resultsPathCol[format]; !ok {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid format. Must be one of: netcdf, geojson, pdf")
+		return
+	}
+
+	var exists bool
+	if err := database.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM missions WHERE id = $1)`, missionID).Scan(&exists); err != nil {
+		log.Printf("Database error checking mission %s: %v", missionID, err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if !exists {
+		utils.ErrorResponse(c, http.StatusNotFound, "Mission not found")
+		return
+	}
+
+	bucket := resultsBucket()
+	key := fmt.Sprintf("missions/%s/result.%s", missionID, format)
+	contentType := resultContentType(format)
+
+	s3, err := newResultsStorage()
+	if err != nil {
+		log.Printf("Failed to initialize S3 client: %v", err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate upload URL")
+		return
+	}
+
+	url, err := s3.PresignPut(bucket, key, uploadURLTTL, contentType)
+	if err != nil {
+		log.Printf("Failed to presign PUT for mission %s: %v", missionID, err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate upload URL")
+		return
+	}
+
+	// The worker uploads to s3://bucket/key using this URL, then reports it
+	// back via CompleteMission so it lands on mission_results.
+	utils.SuccessResponse(c, http.StatusOK, gin.H{
+		"url":          url,
+		"bucket":       bucket,
+		"key":          key,
+		"content_type": contentType,
+		"expires_at":   time.Now().Add(uploadURLTTL),
+	})
+}
+
+// completeMissionRequest is the body CompleteMission expects. A worker
+// fills in whichever result paths it uploaded via GetMissionUploadURL -
+// not every mission produces all three formats - plus the search-area
+// polygons GetMissionResultGeoJSON serves, when it ran a geojson pass.
+type completeMissionRequest struct {
+	NetCDFPath    string          `json:"netcdfPath"`
+	GeoJSONPath   string          `json:"geojsonPath"`
+	PDFReportPath string          `json:"pdfReportPath"`
+	SearchArea50  json.RawMessage `json:"searchArea50Geom"`
+	SearchArea90  json.RawMessage `json:"searchArea90Geom"`
+}
+
+// nullRawMessage returns raw as a driver value, or nil if it's empty, so
+// an omitted geometry clears to SQL NULL instead of storing "null".
+func nullRawMessage(raw json.RawMessage) interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+	return []byte(raw)
+}
+
+// CompleteMission handles POST /v1/missions/:id/complete: the
+// drift-simulation worker calls this once it's uploaded a mission's result
+// files via the URL GetMissionUploadURL presigned, reporting where they
+// landed. It records those paths onto mission_results, commits the
+// mission's credit reservation (see credits.Reserve in CreateMission), and
+// marks the mission completed. Gated by RequireWorkerAuth rather than a
+// user's JWT, since the worker has no user session.
+func CompleteMission(c *gin.Context) {
+	missionID := c.Param("id")
+
+	var req completeMissionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	var exists bool
+	if err := database.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM missions WHERE id = $1)`, missionID).Scan(&exists); err != nil {
+		log.Printf("Database error checking mission %s: %v", missionID, err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if !exists {
+		utils.ErrorResponse(c, http.StatusNotFound, "Mission not found")
+		return
+	}
+
+	_, err := database.DB.Exec(
+		`INSERT INTO mission_results
+		   (mission_id, netcdf_path, geojson_path, pdf_report_path, search_area_50_geom, search_area_90_geom, created_at, updated_at)
+		 VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), NULLIF($4, ''), $5, $6, NOW(), NOW())
+		 ON CONFLICT (mission_id) DO UPDATE SET
+		   netcdf_path         = COALESCE(EXCLUDED.netcdf_path, mission_results.netcdf_path),
+		   geojson_path        = COALESCE(EXCLUDED.geojson_path, mission_results.geojson_path),
+		   pdf_report_path     = COALESCE(EXCLUDED.pdf_report_path, mission_results.pdf_report_path),
+		   search_area_50_geom = COALESCE(EXCLUDED.search_area_50_geom, mission_results.search_area_50_geom),
+		   search_area_90_geom = COALESCE(EXCLUDED.search_area_90_geom, mission_results.search_area_90_geom),
+		   updated_at          = NOW()`,
+		missionID, req.NetCDFPath, req.GeoJSONPath, req.PDFReportPath,
+		nullRawMessage(req.SearchArea50), nullRawMessage(req.SearchArea90),
+	)
+	if err != nil {
+		log.Printf("Failed to record results for mission %s: %v", missionID, err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to record mission results")
+		return
+	}
+
+	if err := credits.CommitMission(missionID); err != nil {
+		log.Printf("Failed to commit credit reservation for mission %s: %v", missionID, err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to commit credit reservation")
+		return
+	}
+
+	if _, err := database.DB.Exec(
+		`UPDATE missions SET status = 'completed', completed_at = NOW(), updated_at = NOW() WHERE id = $1`,
+		missionID,
+	); err != nil {
+		log.Printf("Failed to mark mission %s completed: %v", missionID, err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to mark mission completed")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{"id": missionID, "status": "completed"})
+}
@@ -0,0 +1,320 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/eysteinn/driftline/services/api/internal/database"
+	"github.com/eysteinn/driftline/services/api/internal/middleware"
+	"github.com/eysteinn/driftline/services/api/internal/storage"
+	"github.com/eysteinn/driftline/services/api/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// multipartThreshold is the size above which InitiateUpload hands back a
+// multipart upload (an upload ID plus one presigned URL per part) instead
+// of a single presigned PUT, since most S3-compatible backends refuse (or
+// silently mishandle) single PUTs above a few hundred MB.
+const multipartThreshold = 100 * 1024 * 1024
+
+// uploadPartSize is the size of every part but the last in a multipart
+// upload. S3 requires every non-final part to be at least 5 MiB.
+const uploadPartSize = 25 * 1024 * 1024
+
+// assetUploadURLTTL bounds how long a presigned asset upload URL is valid
+// for, long enough for a large file over a slow connection.
+const assetUploadURLTTL = 2 * time.Hour
+
+// defaultUploadQuotaBytes is how many bytes of completed assets a user may
+// have stored at once, unless UPLOAD_QUOTA_BYTES overrides it.
+const defaultUploadQuotaBytes = 5 * 1024 * 1024 * 1024 // 5 GiB
+
+// assetsBucket returns the bucket mission input assets (custom current
+// fields, bathymetry, search regions) are uploaded into, distinct from
+// resultsBucket's worker-output bucket.
+func assetsBucket() string {
+	if b := os.Getenv("S3_ASSETS_BUCKET"); b != "" {
+		return b
+	}
+	return "driftline-assets"
+}
+
+// uploadQuotaBytes returns the per-user storage quota enforced by
+// InitiateUpload.
+func uploadQuotaBytes() int64 {
+	if v := os.Getenv("UPLOAD_QUOTA_BYTES"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n > 0 {
+			return n
+		}
+	}
+	return defaultUploadQuotaBytes
+}
+
+// newAssetsStorage builds a presigning Service from the same S3
+// environment configuration every other S3 access in this service uses.
+func newAssetsStorage() (*storage.Service, error) {
+	return storage.NewService(os.Getenv("S3_ENDPOINT"), os.Getenv("S3_ACCESS_KEY"), os.Getenv("S3_SECRET_KEY"))
+}
+
+type initiateUploadRequest struct {
+	Filename    string `json:"filename" binding:"required"`
+	ContentType string `json:"contentType" binding:"required"`
+	SizeBytes   int64  `json:"sizeBytes" binding:"required,min=1"`
+}
+
+type uploadPart struct {
+	PartNumber int64  `json:"partNumber"`
+	URL        string `json:"url"`
+}
+
+// InitiateUpload handles POST /v1/uploads/initiate: it records a pending
+// mission_assets row and returns either a single presigned PUT URL, or -
+// for files over multipartThreshold - a multipart upload ID with one
+// presigned URL per part. The caller uploads directly to S3, then calls
+// CompleteUpload.
+func InitiateUpload(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	var req initiateUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var used int64
+	if err := database.DB.QueryRow(
+		`SELECT COALESCE(SUM(size_bytes), 0) FROM mission_assets WHERE user_id = $1 AND status = 'completed'`,
+		userID,
+	).Scan(&used); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if quota := uploadQuotaBytes(); used+req.SizeBytes > quota {
+		utils.ErrorResponse(c, http.StatusRequestEntityTooLarge, fmt.Sprintf(
+			"upload of %d bytes would exceed your %d byte quota (%d bytes already in use)",
+			req.SizeBytes, quota, used))
+		return
+	}
+
+	var assetID string
+	err := database.DB.QueryRow(
+		`INSERT INTO mission_assets (user_id, filename, content_type, size_bytes, status, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, 'pending', $5, $5)
+		 RETURNING id`,
+		userID, req.Filename, req.ContentType, req.SizeBytes, time.Now(),
+	).Scan(&assetID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to record upload")
+		return
+	}
+
+	key := fmt.Sprintf("assets/%s/%s/%s", userID, assetID, req.Filename)
+
+	s3, err := newAssetsStorage()
+	if err != nil {
+		log.Printf("Failed to initialize S3 client: %v", err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate upload URL")
+		return
+	}
+
+	if req.SizeBytes <= multipartThreshold {
+		url, err := s3.PresignPut(assetsBucket(), key, assetUploadURLTTL, req.ContentType)
+		if err != nil {
+			log.Printf("Failed to presign PUT for asset %s: %v", assetID, err)
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate upload URL")
+			return
+		}
+		if _, err := database.DB.Exec(
+			`UPDATE mission_assets SET bucket = $1, object_key = $2, updated_at = $3 WHERE id = $4`,
+			assetsBucket(), key, time.Now(), assetID,
+		); err != nil {
+			log.Printf("Failed to record asset location for %s: %v", assetID, err)
+		}
+
+		utils.SuccessResponse(c, http.StatusOK, gin.H{
+			"assetId": assetID,
+			"bucket":  assetsBucket(),
+			"key":     key,
+			"url":     url,
+		})
+		return
+	}
+
+	uploadID, err := s3.CreateMultipartUpload(assetsBucket(), key, req.ContentType)
+	if err != nil {
+		log.Printf("Failed to create multipart upload for asset %s: %v", assetID, err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate upload URL")
+		return
+	}
+
+	numParts := (req.SizeBytes + uploadPartSize - 1) / uploadPartSize
+	parts := make([]uploadPart, 0, numParts)
+	for partNumber := int64(1); partNumber <= numParts; partNumber++ {
+		url, err := s3.PresignUploadPart(assetsBucket(), key, uploadID, partNumber, assetUploadURLTTL)
+		if err != nil {
+			log.Printf("Failed to presign part %d for asset %s: %v", partNumber, assetID, err)
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate upload URL")
+			return
+		}
+		parts = append(parts, uploadPart{PartNumber: partNumber, URL: url})
+	}
+
+	if _, err := database.DB.Exec(
+		`UPDATE mission_assets SET bucket = $1, object_key = $2, upload_id = $3, updated_at = $4 WHERE id = $5`,
+		assetsBucket(), key, uploadID, time.Now(), assetID,
+	); err != nil {
+		log.Printf("Failed to record asset location for %s: %v", assetID, err)
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{
+		"assetId":  assetID,
+		"bucket":   assetsBucket(),
+		"key":      key,
+		"uploadId": uploadID,
+		"parts":    parts,
+	})
+}
+
+// resolveMissionAssets validates that every asset ID in assetIDs belongs
+// to userID and finished uploading, attaches them to missionID, and
+// returns their "s3://bucket/key" locations for queue.DriftJobParams.
+func resolveMissionAssets(userID, missionID string, assetIDs []string) ([]string, error) {
+	if len(assetIDs) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, 0, len(assetIDs))
+	for _, assetID := range assetIDs {
+		var bucket, objectKey, status string
+		err := database.DB.QueryRow(
+			`SELECT bucket, object_key, status FROM mission_assets WHERE id = $1 AND user_id = $2`,
+			assetID, userID,
+		).Scan(&bucket, &objectKey, &status)
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("asset %s not found", assetID)
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to look up asset %s: %w", assetID, err)
+		}
+		if status != "completed" {
+			return nil, fmt.Errorf("asset %s has not finished uploading (status=%s)", assetID, status)
+		}
+
+		if _, err := database.DB.Exec(
+			`UPDATE mission_assets SET mission_id = $1, updated_at = $2 WHERE id = $3`,
+			missionID, time.Now(), assetID,
+		); err != nil {
+			return nil, fmt.Errorf("failed to attach asset %s to mission: %w", assetID, err)
+		}
+
+		keys = append(keys, fmt.Sprintf("s3://%s/%s", bucket, objectKey))
+	}
+
+	return keys, nil
+}
+
+type completedPartRequest struct {
+	PartNumber int64  `json:"partNumber" binding:"required"`
+	ETag       string `json:"etag" binding:"required"`
+}
+
+type completeUploadRequest struct {
+	Parts []completedPartRequest `json:"parts"`
+}
+
+// CompleteUpload handles POST /v1/uploads/:id/complete: for a multipart
+// upload it assembles the parts the client reports into the final
+// object; for a single-PUT upload there's nothing left to assemble, so it
+// just confirms the object landed. Either way it HEADs the finished
+// object to record its real size and an ETag-based checksum.
+func CompleteUpload(c *gin.Context) {
+	assetID := c.Param("id")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	var req completeUploadRequest
+	// A single-PUT completion has nothing to report, so an empty or
+	// absent body is fine - only reject genuinely malformed JSON.
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	var bucket, key, uploadID, status string
+	err := database.DB.QueryRow(
+		`SELECT bucket, object_key, COALESCE(upload_id, ''), status
+		 FROM mission_assets WHERE id = $1 AND user_id = $2`,
+		assetID, userID,
+	).Scan(&bucket, &key, &uploadID, &status)
+	if err == sql.ErrNoRows {
+		utils.ErrorResponse(c, http.StatusNotFound, "Upload not found")
+		return
+	} else if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if status == "completed" {
+		utils.SuccessResponse(c, http.StatusOK, gin.H{"assetId": assetID, "status": "completed"})
+		return
+	}
+	if bucket == "" || key == "" {
+		utils.ErrorResponse(c, http.StatusConflict, "Upload was never assigned a storage location")
+		return
+	}
+
+	s3, err := newAssetsStorage()
+	if err != nil {
+		log.Printf("Failed to initialize S3 client: %v", err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to complete upload")
+		return
+	}
+
+	if uploadID != "" {
+		parts := make([]storage.CompletedPart, len(req.Parts))
+		for i, p := range req.Parts {
+			parts[i] = storage.CompletedPart{PartNumber: p.PartNumber, ETag: p.ETag}
+		}
+		if err := s3.CompleteMultipartUpload(bucket, key, uploadID, parts); err != nil {
+			log.Printf("Failed to complete multipart upload for asset %s: %v", assetID, err)
+			utils.ErrorResponse(c, http.StatusBadGateway, "Failed to assemble uploaded parts")
+			return
+		}
+	}
+
+	checksum, size, err := s3.HeadObject(bucket, key)
+	if err != nil {
+		log.Printf("Failed to head completed object for asset %s: %v", assetID, err)
+		utils.ErrorResponse(c, http.StatusBadGateway, "Upload did not land in storage")
+		return
+	}
+
+	if _, err := database.DB.Exec(
+		`UPDATE mission_assets SET status = 'completed', checksum = $1, size_bytes = $2, updated_at = $3 WHERE id = $4`,
+		checksum, size, time.Now(), assetID,
+	); err != nil {
+		log.Printf("Failed to mark asset %s completed: %v", assetID, err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{
+		"assetId":  assetID,
+		"status":   "completed",
+		"checksum": checksum,
+		"size":     size,
+	})
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/eysteinn/driftline/services/api/internal/payments"
+	"github.com/eysteinn/driftline/services/api/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// StripeWebhook handles POST /webhooks/stripe. It verifies the request
+// actually came from Stripe before applying it, and is idempotent against
+// Stripe's at-least-once retry delivery (see payments.ProcessEvent).
+func StripeWebhook(c *gin.Context) {
+	payload, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	event, err := payments.VerifyAndParseEvent(payload, c.GetHeader("Stripe-Signature"))
+	if err != nil {
+		log.Printf("Stripe webhook signature verification failed: %v", err)
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid signature")
+		return
+	}
+
+	if err := payments.ProcessEvent(event); err != nil {
+		log.Printf("Failed to process stripe event %s (%s): %v", event.ID, event.Type, err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to process event")
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,51 @@
+package ledger
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/eysteinn/driftline/services/api/internal/database"
+)
+
+// Posting is one leg of a ledger transaction, as seen from a single
+// account's point of view, with its running balance immediately after.
+type Posting struct {
+	ID             string    `json:"id"`
+	TransactionID  string    `json:"transactionId"`
+	Account        string    `json:"account"`
+	Amount         int       `json:"amount"`
+	Description    string    `json:"description"`
+	RunningBalance int       `json:"runningBalance"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// History returns account's postings, most recent first, each annotated
+// with the account's running balance as of that posting.
+func History(account string, limit, offset int) ([]Posting, error) {
+	rows, err := database.DB.Query(
+		`SELECT p.id, p.transaction_id, p.amount, t.description, p.created_at,
+		        SUM(p.amount) OVER (ORDER BY p.created_at, p.id) AS running_balance
+		 FROM postings p
+		 JOIN accounts a ON a.id = p.account_id
+		 JOIN ledger_transactions t ON t.id = p.transaction_id
+		 WHERE a.name = $1
+		 ORDER BY p.created_at DESC, p.id DESC
+		 LIMIT $2 OFFSET $3`,
+		account, limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query postings for %s: %w", account, err)
+	}
+	defer rows.Close()
+
+	var postings []Posting
+	for rows.Next() {
+		p := Posting{Account: account}
+		if err := rows.Scan(&p.ID, &p.TransactionID, &p.Amount, &p.Description, &p.CreatedAt, &p.RunningBalance); err != nil {
+			return nil, fmt.Errorf("failed to scan posting: %w", err)
+		}
+		postings = append(postings, p)
+	}
+
+	return postings, nil
+}
@@ -0,0 +1,168 @@
+// Package ledger implements the double-entry accounting backing the
+// credit system: every balance-affecting event posts two or more legs to
+// named accounts that sum to zero, rather than mutating a single
+// user_credits.balance column. That gives every credit movement a
+// permanent, auditable trail and lets a reconciliation job prove no
+// credits were created or destroyed by a bug.
+package ledger
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/eysteinn/driftline/services/api/internal/database"
+)
+
+// Well-known system accounts. Per-entity accounts are built with
+// UserAccount and MissionEscrowAccount.
+const (
+	// SystemRevenue receives credits once they're actually spent - either
+	// immediately (the legacy DeductCredits path) or when a mission's
+	// escrow is committed on completion.
+	SystemRevenue = "system:revenue"
+
+	// SystemFreeGrants is the source leg for credits given away rather
+	// than sold: the new-signup bonus and admin/AddCredits grants.
+	SystemFreeGrants = "system:free_grants"
+
+	// SystemCreditsIssued is the source leg for credits granted in
+	// exchange for a real Stripe payment (one-off purchase or
+	// subscription invoice) - a liability until the user spends them.
+	SystemCreditsIssued = "system:credits_issued"
+)
+
+// UserAccount returns the name of userID's spendable credit balance.
+func UserAccount(userID string) string {
+	return fmt.Sprintf("user:%s:credits", userID)
+}
+
+// MissionEscrowAccount returns the name of the account holding credits
+// reserved for missionID between Reserve and Commit/Refund.
+func MissionEscrowAccount(missionID string) string {
+	return fmt.Sprintf("mission:%s:escrow", missionID)
+}
+
+// Leg is one side of a balanced posting: Amount credited to Account. A
+// debit is a negative Amount.
+type Leg struct {
+	Account string
+	Amount  int
+}
+
+// Post records legs as a single ledger transaction, atomically within tx.
+// legs must sum to zero - the double-entry invariant Reconcile later
+// verifies - or Post refuses to write anything.
+func Post(tx *sql.Tx, description string, legs ...Leg) (transactionID string, err error) {
+	if len(legs) < 2 {
+		return "", fmt.Errorf("a ledger transaction needs at least 2 legs, got %d", len(legs))
+	}
+	sum := 0
+	for _, l := range legs {
+		sum += l.Amount
+	}
+	if sum != 0 {
+		return "", fmt.Errorf("unbalanced ledger transaction %q: legs sum to %d, want 0", description, sum)
+	}
+
+	err = tx.QueryRow(
+		`INSERT INTO ledger_transactions (description) VALUES ($1) RETURNING id`,
+		description,
+	).Scan(&transactionID)
+	if err != nil {
+		return "", fmt.Errorf("failed to insert ledger transaction: %w", err)
+	}
+
+	for _, l := range legs {
+		accountID, err := ensureAccount(tx, l.Account)
+		if err != nil {
+			return "", err
+		}
+		if _, err := tx.Exec(
+			`INSERT INTO postings (transaction_id, account_id, amount) VALUES ($1, $2, $3)`,
+			transactionID, accountID, l.Amount,
+		); err != nil {
+			return "", fmt.Errorf("failed to insert posting for %s: %w", l.Account, err)
+		}
+	}
+
+	return transactionID, nil
+}
+
+// ensureAccount returns name's account id, creating the account on first
+// use.
+func ensureAccount(tx *sql.Tx, name string) (string, error) {
+	var id string
+	err := tx.QueryRow(`SELECT id FROM accounts WHERE name = $1`, name).Scan(&id)
+	if err == sql.ErrNoRows {
+		err = tx.QueryRow(
+			`INSERT INTO accounts (name) VALUES ($1)
+			 ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+			 RETURNING id`,
+			name,
+		).Scan(&id)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve account %s: %w", name, err)
+	}
+	return id, nil
+}
+
+// Balance returns account's current balance: the sum of every posting
+// ever made against it.
+func Balance(account string) (int, error) {
+	var balance int
+	err := database.DB.QueryRow(
+		`SELECT COALESCE(SUM(p.amount), 0)
+		 FROM postings p
+		 JOIN accounts a ON a.id = p.account_id
+		 WHERE a.name = $1`,
+		account,
+	).Scan(&balance)
+	if err != nil {
+		return 0, fmt.Errorf("failed to compute balance for %s: %w", account, err)
+	}
+	return balance, nil
+}
+
+// BalanceForUpdate returns account's current balance like Balance, but
+// first takes a row lock on account's accounts row within tx via SELECT
+// ... FOR UPDATE, so a concurrent BalanceForUpdate on the same account
+// blocks until tx commits or rolls back. Callers that check a balance
+// before deciding to post a debit - Reserve, the legacy DeductCredits
+// path - should use this instead of Balance, or two concurrent requests
+// can both observe a sufficient balance and both debit, overdrawing the
+// account.
+func BalanceForUpdate(tx *sql.Tx, account string) (int, error) {
+	accountID, err := ensureAccount(tx, account)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(`SELECT id FROM accounts WHERE id = $1 FOR UPDATE`, accountID); err != nil {
+		return 0, fmt.Errorf("failed to lock account %s: %w", account, err)
+	}
+
+	var balance int
+	err = tx.QueryRow(
+		`SELECT COALESCE(SUM(amount), 0) FROM postings WHERE account_id = $1`,
+		accountID,
+	).Scan(&balance)
+	if err != nil {
+		return 0, fmt.Errorf("failed to compute balance for %s: %w", account, err)
+	}
+	return balance, nil
+}
+
+// HasPostings reports whether account has ever received a posting, so
+// callers can tell "new account" apart from "account with a zero
+// balance".
+func HasPostings(account string) (bool, error) {
+	var exists bool
+	err := database.DB.QueryRow(
+		`SELECT EXISTS(SELECT 1 FROM postings p JOIN accounts a ON a.id = p.account_id WHERE a.name = $1)`,
+		account,
+	).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check postings for %s: %w", account, err)
+	}
+	return exists, nil
+}
@@ -0,0 +1,33 @@
+package ledger
+
+import "testing"
+
+func TestPost_RejectsUnbalancedLegs(t *testing.T) {
+	_, err := Post(nil, "test", Leg{Account: "a", Amount: 5}, Leg{Account: "b", Amount: 3})
+	if err == nil {
+		t.Fatal("expected error for legs that don't sum to zero, got nil")
+	}
+}
+
+func TestPost_RejectsFewerThanTwoLegs(t *testing.T) {
+	_, err := Post(nil, "test", Leg{Account: "a", Amount: 0})
+	if err == nil {
+		t.Fatal("expected error for a transaction with fewer than two legs, got nil")
+	}
+}
+
+func TestUserAccount(t *testing.T) {
+	got := UserAccount("u1")
+	want := "user:u1:credits"
+	if got != want {
+		t.Errorf("UserAccount(%q) = %q, want %q", "u1", got, want)
+	}
+}
+
+func TestMissionEscrowAccount(t *testing.T) {
+	got := MissionEscrowAccount("m1")
+	want := "mission:m1:escrow"
+	if got != want {
+		t.Errorf("MissionEscrowAccount(%q) = %q, want %q", "m1", got, want)
+	}
+}
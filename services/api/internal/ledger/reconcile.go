@@ -0,0 +1,32 @@
+package ledger
+
+import (
+	"fmt"
+
+	"github.com/eysteinn/driftline/services/api/internal/database"
+)
+
+// Reconcile returns the IDs of ledger transactions whose postings don't
+// sum to zero. This should never happen if every write went through
+// Post, so a non-empty result means something bypassed it - a manual DB
+// fix, a bug, direct SQL - and needs investigating.
+func Reconcile() ([]string, error) {
+	rows, err := database.DB.Query(
+		`SELECT transaction_id FROM postings GROUP BY transaction_id HAVING SUM(amount) != 0`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan postings for drift: %w", err)
+	}
+	defer rows.Close()
+
+	var drifted []string
+	for rows.Next() {
+		var transactionID string
+		if err := rows.Scan(&transactionID); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction id: %w", err)
+		}
+		drifted = append(drifted, transactionID)
+	}
+
+	return drifted, nil
+}
@@ -0,0 +1,57 @@
+package ledger
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/eysteinn/driftline/services/api/internal/database"
+)
+
+// SpendByUser returns each user's total credits spent (posted to
+// SystemRevenue, whether via the immediate DeductCredits path or a
+// committed mission escrow) during [start, end), keyed by user ID. It's
+// what usage-based billing periods are built from.
+func SpendByUser(start, end string) (map[string]int, error) {
+	rows, err := database.DB.Query(
+		`SELECT name, SUM(amount) FROM (
+		 SELECT a.name, -p.amount AS amount
+		 FROM postings p
+		 JOIN accounts a ON a.id = p.account_id
+		 JOIN accounts rev ON rev.name = $3
+		 WHERE a.name LIKE 'user:%:credits' AND p.amount < 0
+		   AND p.created_at >= $1 AND p.created_at < $2
+		   AND EXISTS (
+		     SELECT 1 FROM postings p2
+		     WHERE p2.transaction_id = p.transaction_id AND p2.account_id = rev.id
+		   )
+		 UNION ALL
+		 SELECT 'user:' || m.user_id || ':credits', -p.amount
+		 FROM postings p
+		 JOIN accounts a ON a.id = p.account_id
+		 JOIN missions m ON a.name = 'mission:' || m.id || ':escrow'
+		 JOIN postings p2 ON p2.transaction_id = p.transaction_id
+		 JOIN accounts rev ON rev.id = p2.account_id AND rev.name = $3
+		 WHERE p.amount < 0 AND p.created_at >= $1 AND p.created_at < $2
+		 ) spend
+		 GROUP BY name
+		 HAVING SUM(amount) > 0`,
+		start, end, SystemRevenue,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate spend: %w", err)
+	}
+	defer rows.Close()
+
+	spend := make(map[string]int)
+	for rows.Next() {
+		var accountName string
+		var amount int
+		if err := rows.Scan(&accountName, &amount); err != nil {
+			return nil, fmt.Errorf("failed to scan spend row: %w", err)
+		}
+		userID := strings.TrimSuffix(strings.TrimPrefix(accountName, "user:"), ":credits")
+		spend[userID] = amount
+	}
+
+	return spend, nil
+}
@@ -0,0 +1,153 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/eysteinn/driftline/services/api/internal/database"
+	"github.com/eysteinn/driftline/services/api/internal/models"
+	"github.com/eysteinn/driftline/services/api/internal/observability"
+	"github.com/eysteinn/driftline/services/api/internal/queue"
+	"github.com/gin-gonic/gin"
+)
+
+// apiKeyHeader is where callers present their API key, as opposed to
+// RequireAuth's JWT "Authorization: Bearer <token>".
+const apiKeyHeader = "X-API-Key"
+
+const apiKeyIDKey = "api_key_id"
+
+// RequireScope authenticates the request by its X-API-Key header and
+// rejects it with 403 unless the key's scopes include scope. The key record
+// is looked up by the SHA-256 hash of the presented key (never the raw key
+// itself) and cached in Redis, so repeated calls don't hit Postgres on
+// every request.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		presented := c.GetHeader(apiKeyHeader)
+		if presented == "" {
+			observability.ApiKeyAuthTotal.WithLabelValues("missing_header").Inc()
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": fmt.Sprintf("missing %s header", apiKeyHeader)})
+			return
+		}
+
+		keyHash := hashApiKey(presented)
+
+		record, err := loadApiKey(keyHash)
+		if err != nil {
+			observability.ApiKeyAuthTotal.WithLabelValues("error").Inc()
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to verify API key"})
+			return
+		}
+		if record == nil {
+			observability.ApiKeyAuthTotal.WithLabelValues("invalid_key").Inc()
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid API key"})
+			return
+		}
+		if !record.IsActive || (record.ExpiresAt != nil && record.ExpiresAt.Before(time.Now())) {
+			observability.ApiKeyAuthTotal.WithLabelValues("inactive_or_expired").Inc()
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "API key is no longer valid"})
+			return
+		}
+
+		scopes, err := models.ParseScopes(record.Scopes)
+		if err != nil {
+			observability.ApiKeyAuthTotal.WithLabelValues("error").Inc()
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to parse API key scopes"})
+			return
+		}
+		if !models.HasScope(scopes, scope) {
+			observability.ApiKeyAuthTotal.WithLabelValues("insufficient_scope").Inc()
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":         fmt.Sprintf("API key is missing required scope %q", scope),
+				"error_code":    "insufficient_scope",
+				"missing_scope": scope,
+			})
+			return
+		}
+
+		observability.ApiKeyAuthTotal.WithLabelValues("success").Inc()
+		c.Set(userIDKey, record.UserID)
+		c.Set(apiKeyIDKey, record.ID)
+		// A key carrying the admin:* wildcard scope stands in for an admin
+		// user the same way a JWT's role claim does, so handlers that gate
+		// on GetRole(c) == "admin" (e.g. AddCredits) work the same way
+		// whether the caller authenticated with a bearer token or an
+		// admin-scoped API key.
+		if models.HasScope(scopes, models.ScopeAdminAll) {
+			c.Set(roleKey, "admin")
+		}
+
+		// last_used_at/ip/user_agent are for operator visibility only, so
+		// they're updated off the request path rather than adding a write
+		// to every authenticated call. Read c.ClientIP()/User-Agent now,
+		// before the goroutine outlives the request.
+		go touchLastUsedAt(record.ID, c.ClientIP(), c.Request.UserAgent())
+
+		c.Next()
+	}
+}
+
+// GetApiKeyID returns the authenticated API key's ID, as set by
+// RequireScope. Only meaningful on routes behind RequireScope, not
+// RequireAuth.
+func GetApiKeyID(c *gin.Context) (string, bool) {
+	v, ok := c.Get(apiKeyIDKey)
+	if !ok {
+		return "", false
+	}
+	id, ok := v.(string)
+	return id, ok
+}
+
+// hashApiKey creates the SHA-256 hash api_keys.key_hash stores, matching
+// handlers.hashApiKey.
+func hashApiKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return fmt.Sprintf("%x", hash)
+}
+
+// loadApiKey resolves keyHash to its api_keys row, preferring the Redis
+// cache and falling back to (and repopulating from) Postgres on a miss.
+func loadApiKey(keyHash string) (*queue.CachedApiKey, error) {
+	if cached, err := queue.GetCachedApiKey(keyHash); err == nil && cached != nil {
+		return cached, nil
+	}
+
+	var record queue.CachedApiKey
+	err := database.DB.QueryRow(
+		`SELECT id, user_id, scopes, is_active, expires_at FROM api_keys WHERE key_hash = $1`,
+		keyHash,
+	).Scan(&record.ID, &record.UserID, &record.Scopes, &record.IsActive, &record.ExpiresAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load API key: %w", err)
+	}
+
+	if err := queue.CacheApiKey(keyHash, record); err != nil {
+		// Caching is an optimization, not a correctness requirement.
+		log.Printf("failed to cache API key %s: %v", record.ID, err)
+	}
+
+	return &record, nil
+}
+
+// touchLastUsedAt records that an API key was just used to authenticate a
+// request, along with the caller's IP and user agent for audit purposes.
+// Run in its own goroutine by RequireScope, so a slow or momentarily
+// unavailable database never adds latency to an authenticated call.
+func touchLastUsedAt(keyID, ip, userAgent string) {
+	if _, err := database.DB.Exec(
+		`UPDATE api_keys SET last_used_at = $1, last_used_ip = $2, last_used_user_agent = $3 WHERE id = $4`,
+		time.Now(), ip, userAgent, keyID,
+	); err != nil {
+		log.Printf("failed to update last_used_at for API key %s: %v", keyID, err)
+	}
+}
@@ -0,0 +1,197 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/eysteinn/driftline/services/api/internal/database"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// idempotencyPqUniqueViolation is the PostgreSQL error code for
+// unique_violation, matching payments.pqUniqueViolation.
+const idempotencyPqUniqueViolation = "23505"
+
+// idempotencyKeyHeader is where callers present a key identifying a single
+// logical attempt at an operation, so retrying the same request (e.g. after
+// a timed-out response) doesn't repeat its side effects.
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// idempotencyKeyTTL bounds how long a claimed key keeps replaying its
+// cached response. Past this, the key is reclaimed for a fresh attempt
+// rather than replaying (or conflicting against) a day-old response -
+// callers aren't expected to dedup the same logical operation forever.
+const idempotencyKeyTTL = 24 * time.Hour
+
+// bodyCapturingWriter buffers a handler's response so Idempotency can
+// record the final status code and body after the handler returns, while
+// still streaming them to the real connection as they're written.
+type bodyCapturingWriter struct {
+	gin.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bodyCapturingWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *bodyCapturingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// Idempotency makes a route safe to retry: a request carrying an
+// Idempotency-Key header replays the first response instead of re-running
+// the handler, the same dedup-by-insert approach payments.withDedup uses
+// for Stripe webhooks, adapted to claim the key before the handler runs
+// (since a Gin handler, unlike withDedup's apply, isn't a single function
+// this middleware can wrap in one transaction).
+//
+// Keys are scoped to (user, key, endpoint) - c.FullPath(), the matched
+// route template rather than the raw URL - so the same key value used
+// against two different routes (e.g. a client that seeds every request
+// with one UUID) doesn't collide; each route gets its own claim.
+//
+// The header is optional - a request without it runs the handler
+// unconditionally, so idempotency is opt-in per call, not enforced by the
+// route. Must come after whatever sets the user ID (RequireAuth /
+// RequireScope), since keys are scoped per user.
+func Idempotency() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		userID, ok := GetUserID(c)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		endpoint := c.FullPath()
+		if endpoint == "" {
+			endpoint = "unmatched"
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		requestHash := fmt.Sprintf("%x", sha256.Sum256(body))
+
+		existing, err := claimIdempotencyKey(userID, key, endpoint, requestHash)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to process idempotency key"})
+			return
+		}
+		if existing != nil {
+			if existing.requestHash != requestHash {
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "Idempotency-Key was already used with a different request body"})
+				return
+			}
+			if existing.responseBody != nil {
+				c.Data(existing.statusCode, "application/json", existing.responseBody)
+				c.Abort()
+				return
+			}
+			// Another request with the same key claimed it and hasn't
+			// finished yet. That's rare enough (two concurrent submits of
+			// the same operation) that this repo doesn't need a polling
+			// wait here - ask the caller to retry.
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "a request with this Idempotency-Key is already in progress"})
+			return
+		}
+
+		writer := &bodyCapturingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		status := writer.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if err := fillIdempotencyKey(userID, key, endpoint, status, writer.body.Bytes()); err != nil {
+			log.Printf("failed to record idempotency response for key %s: %v", key, err)
+		}
+	}
+}
+
+type idempotencyRecord struct {
+	requestHash  string
+	statusCode   int
+	responseBody []byte
+}
+
+// claimIdempotencyKey inserts a placeholder row for (userID, key, endpoint)
+// - the same way payments.withDedup inserts into stripe_events before doing
+// the real work. The unique constraint on (user_id, key, endpoint) is what
+// makes a second, concurrent submission fail the insert instead of running
+// the handler twice. If the key already exists, its current state
+// (possibly still pending, if the first request hasn't finished) is
+// returned instead of nil - unless it's older than idempotencyKeyTTL, in
+// which case it's reclaimed for this request rather than replayed.
+func claimIdempotencyKey(userID, key, endpoint, requestHash string) (*idempotencyRecord, error) {
+	_, err := database.DB.Exec(
+		`INSERT INTO idempotency_keys (user_id, key, endpoint, request_hash, created_at) VALUES ($1, $2, $3, $4, NOW())`,
+		userID, key, endpoint, requestHash,
+	)
+	if err == nil {
+		return nil, nil
+	}
+
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != idempotencyPqUniqueViolation {
+		return nil, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+
+	var record idempotencyRecord
+	var statusCode sql.NullInt64
+	var responseBody []byte
+	var createdAt time.Time
+	err = database.DB.QueryRow(
+		`SELECT request_hash, status_code, response_body, created_at FROM idempotency_keys WHERE user_id = $1 AND key = $2 AND endpoint = $3`,
+		userID, key, endpoint,
+	).Scan(&record.requestHash, &statusCode, &responseBody, &createdAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load idempotency key: %w", err)
+	}
+
+	if time.Since(createdAt) > idempotencyKeyTTL {
+		if _, err := database.DB.Exec(
+			`UPDATE idempotency_keys SET request_hash = $4, status_code = NULL, response_body = NULL, created_at = NOW()
+			 WHERE user_id = $1 AND key = $2 AND endpoint = $3`,
+			userID, key, endpoint, requestHash,
+		); err != nil {
+			return nil, fmt.Errorf("failed to reclaim expired idempotency key: %w", err)
+		}
+		return nil, nil
+	}
+
+	record.statusCode = int(statusCode.Int64)
+	record.responseBody = responseBody
+	return &record, nil
+}
+
+// fillIdempotencyKey records the outcome against an already-claimed key,
+// so a retry can replay it instead of re-running the handler.
+func fillIdempotencyKey(userID, key, endpoint string, statusCode int, responseBody []byte) error {
+	_, err := database.DB.Exec(
+		`UPDATE idempotency_keys SET status_code = $4, response_body = $5 WHERE user_id = $1 AND key = $2 AND endpoint = $3`,
+		userID, key, endpoint, statusCode, responseBody,
+	)
+	return err
+}
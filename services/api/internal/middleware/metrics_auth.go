@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireMetricsToken gates the /metrics endpoint behind a shared secret,
+// the same way RequireWorkerAuth gates worker-only endpoints - Prometheus
+// scrape config sends it in the X-Metrics-Token header, since a scraper has
+// no user session to present a JWT or API key for.
+func RequireMetricsToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := os.Getenv("METRICS_AUTH_TOKEN")
+		provided := c.GetHeader("X-Metrics-Token")
+
+		if token == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid metrics token"})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireWorkerAuth gates worker-only endpoints (e.g. presigned upload
+// URLs) behind a shared secret the drift-simulation worker sends in the
+// X-Worker-Token header, since workers run outside any user's JWT session.
+func RequireWorkerAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := os.Getenv("WORKER_AUTH_TOKEN")
+		provided := c.GetHeader("X-Worker-Token")
+
+		if token == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid worker token"})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"encoding/json"
+	"strings"
 	"time"
 )
 
@@ -13,9 +15,67 @@ type ApiKey struct {
 	Scopes      []byte     `json:"scopes" db:"scopes"`
 	IsActive    bool       `json:"isActive" db:"is_active"`
 	LastUsedAt  *time.Time `json:"lastUsedAt,omitempty" db:"last_used_at"`
-	CreatedAt   time.Time  `json:"createdAt" db:"created_at"`
-	ExpiresAt   *time.Time `json:"expiresAt,omitempty" db:"expires_at"`
-	KeyPreview  string     `json:"keyPreview" db:"-"`
+	// LastUsedIP and LastUsedUserAgent record where the key's most recent
+	// authenticated request came from, for an operator investigating a
+	// leaked or misused key - updated alongside LastUsedAt, not on their
+	// own schedule.
+	LastUsedIP        *string    `json:"lastUsedIp,omitempty" db:"last_used_ip"`
+	LastUsedUserAgent *string    `json:"lastUsedUserAgent,omitempty" db:"last_used_user_agent"`
+	CreatedAt         time.Time  `json:"createdAt" db:"created_at"`
+	ExpiresAt         *time.Time `json:"expiresAt,omitempty" db:"expires_at"`
+	KeyPreview        string     `json:"keyPreview" db:"-"`
+	// RotatedFrom holds the ID of the key this one replaced, if any. The
+	// replaced key keeps working until its (shortened) expiry so clients
+	// have a grace period to pick up the new secret.
+	RotatedFrom *string `json:"rotatedFrom,omitempty" db:"rotated_from"`
+	// RevokedAt records when a key was superseded by a rotation, purely
+	// for audit purposes - what actually cuts the old key off is its
+	// (shortened) ExpiresAt, since rotation intentionally leaves it
+	// working through a grace period rather than revoking it outright.
+	RevokedAt *time.Time `json:"revokedAt,omitempty" db:"revoked_at"`
+}
+
+// Scope catalog. These are the scope strings CreateApiKeyRequest.Scopes and
+// RequireScope deal in. A scope ending in ":*" is a wildcard that grants
+// every scope sharing its prefix - e.g. ScopeAdminAll ("admin:*") grants
+// "admin:anything", and a key could similarly be issued "missions:*" to
+// grant every missions:* scope below without enumerating them.
+const (
+	ScopeMissionsRead     = "missions:read"
+	ScopeMissionsWrite    = "missions:write"
+	ScopeMissionsDownload = "missions:download"
+	ScopeCreditsRead      = "credits:read"
+	ScopeCreditsWrite     = "credits:write"
+	ScopeAdminAll         = "admin:*"
+)
+
+// ParseScopes unmarshals an api_keys.scopes JSON column (a JSON array of
+// scope strings, e.g. ["credits:read","data:oceancurrents:read"]) into a
+// slice. A nil or empty column means no scopes.
+func ParseScopes(raw []byte) ([]string, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var scopes []string
+	if err := json.Unmarshal(raw, &scopes); err != nil {
+		return nil, err
+	}
+	return scopes, nil
+}
+
+// HasScope reports whether scopes grants scope, either by an exact match or
+// by a wildcard entry: a scope ending in ":*" (e.g. "admin:*", "missions:*")
+// grants every scope sharing that prefix.
+func HasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+		if strings.HasSuffix(s, "*") && strings.HasPrefix(scope, strings.TrimSuffix(s, "*")) {
+			return true
+		}
+	}
+	return false
 }
 
 // CreateApiKeyRequest represents a request to create an API key
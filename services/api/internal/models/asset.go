@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// MissionAsset represents a user-uploaded auxiliary input to a mission -
+// a custom NetCDF current field, GeoTIFF bathymetry, or KML search region
+// - uploaded via handlers.InitiateUpload/CompleteUpload and optionally
+// attached to a mission at creation time.
+type MissionAsset struct {
+	ID          string    `json:"id" db:"id"`
+	UserID      string    `json:"userId" db:"user_id"`
+	MissionID   *string   `json:"missionId" db:"mission_id"`
+	Filename    string    `json:"filename" db:"filename"`
+	ContentType string    `json:"contentType" db:"content_type"`
+	SizeBytes   int64     `json:"sizeBytes" db:"size_bytes"`
+	Bucket      string    `json:"bucket" db:"bucket"`
+	ObjectKey   string    `json:"objectKey" db:"object_key"`
+	Checksum    *string   `json:"checksum" db:"checksum"`
+	Status      string    `json:"status" db:"status"`
+	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt   time.Time `json:"updatedAt" db:"updated_at"`
+}
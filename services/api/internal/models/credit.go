@@ -48,6 +48,15 @@ type PurchaseCreditsRequest struct {
 	PaymentMethodID *string `json:"paymentMethodId"`
 }
 
+// PurchaseCreditsResponse carries the Stripe PaymentIntent client secret
+// the front-end needs to collect payment; credits aren't granted until
+// the payment_intent.succeeded webhook fires.
+type PurchaseCreditsResponse struct {
+	ClientSecret string `json:"clientSecret"`
+	PackageID    string `json:"packageId"`
+	AmountCents  int    `json:"amountCents"`
+}
+
 // AddCreditsRequest represents a request to add credits (admin/subscription)
 type AddCreditsRequest struct {
 	Amount      int    `json:"amount" binding:"required,min=1"`
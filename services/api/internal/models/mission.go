@@ -39,6 +39,28 @@ type CreateMissionRequest struct {
 	ForecastHours      int       `json:"forecastHours" binding:"required,min=1,max=168"`
 	EnsembleSize       int       `json:"ensembleSize" binding:"min=100,max=10000"`
 	Backtracking       bool      `json:"backtracking"`
+
+	// AssetIDs are mission_assets rows (see handlers.InitiateUpload) to
+	// attach to this mission - e.g. a custom current field or bathymetry
+	// grid the worker should use instead of its defaults. Each must
+	// already be uploaded (status "completed") and owned by the
+	// requesting user.
+	AssetIDs []string `json:"assetIds"`
+}
+
+// MissionAttempt records a single pass of a mission's drift job through
+// the worker queue, including attempts the queue itself already
+// retried - so an operator can see, e.g., "timed out twice, then failed
+// with a worker panic" rather than only the job's last queue state.
+type MissionAttempt struct {
+	ID           string     `json:"id" db:"id"`
+	MissionID    string     `json:"missionId" db:"mission_id"`
+	JobID        string     `json:"jobId" db:"job_id"`
+	Attempt      int        `json:"attempt" db:"attempt"`
+	Status       string     `json:"status" db:"status"`
+	ErrorMessage *string    `json:"errorMessage" db:"error_message"`
+	StartedAt    time.Time  `json:"startedAt" db:"started_at"`
+	FinishedAt   *time.Time `json:"finishedAt" db:"finished_at"`
 }
 
 // MissionListResponse represents a paginated list of missions
@@ -2,19 +2,30 @@ package models
 
 import (
 	"time"
+
+	"github.com/eysteinn/driftline/services/api/internal/security"
 )
 
 // User represents a user in the system
 type User struct {
-	ID             string    `json:"id" db:"id"`
-	Email          string    `json:"email" db:"email"`
-	HashedPassword string    `json:"-" db:"hashed_password"`
-	FullName       string    `json:"full_name" db:"full_name"`
-	IsActive       bool      `json:"is_active" db:"is_active"`
-	IsVerified     bool      `json:"is_verified" db:"is_verified"`
-	Role           string    `json:"role" db:"role"`
-	CreatedAt      time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+	ID                   string     `json:"id" db:"id"`
+	Email                string     `json:"email" db:"email"`
+	HashedPassword       string     `json:"-" db:"hashed_password"`
+	FullName             string     `json:"full_name" db:"full_name"`
+	IsActive             bool       `json:"is_active" db:"is_active"`
+	IsVerified           bool       `json:"is_verified" db:"is_verified"`
+	Role                 string     `json:"role" db:"role"`
+	StripeCustomerID     *string    `json:"-" db:"stripe_customer_id"`
+	// FailedLoginAttempts and LockedUntil track account lockout: Login
+	// increments FailedLoginAttempts on every bad password and, once
+	// security.MaxFailedLoginAttempts is reached within
+	// security.LoginAttemptsWindow, sets LockedUntil so further attempts
+	// are rejected outright until it passes. Both reset to their zero
+	// value on a successful login.
+	FailedLoginAttempts int        `json:"-" db:"failed_login_attempts"`
+	LockedUntil         *time.Time `json:"-" db:"locked_until"`
+	CreatedAt           time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at" db:"updated_at"`
 }
 
 // CreateUserRequest represents a user registration request
@@ -24,14 +35,34 @@ type CreateUserRequest struct {
 	FullName string `json:"full_name"`
 }
 
+// Validate enforces security.DefaultPasswordPolicy on Password, beyond the
+// binding tag's bare min=8 length check - character-class requirements and
+// a check against a list of known-compromised passwords.
+func (r CreateUserRequest) Validate() error {
+	return security.DefaultPasswordPolicy.Validate(r.Password)
+}
+
 // LoginRequest represents a login request
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
 }
 
-// LoginResponse represents a login response with JWT token
+// LoginResponse represents a login response with a freshly issued
+// access/refresh token pair
 type LoginResponse struct {
-	Token string `json:"token"`
-	User  User   `json:"user"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	User         User   `json:"user"`
+}
+
+// RefreshTokenRequest represents a token-refresh or logout request
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// TokenPairResponse represents a freshly issued access/refresh token pair
+type TokenPairResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
 }
@@ -0,0 +1,51 @@
+package observability
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// sugaredLogger adapts zap.SugaredLogger to the message-then-keys-and-values
+// call shape (Info/Warn/Error(msg, "key", value, ...)) the rest of this
+// service's log call sites already use, so they don't each need to switch
+// to zap's own Infow/Warnw/Errorw names.
+type sugaredLogger struct {
+	*zap.SugaredLogger
+}
+
+// With attaches the given key/value pairs to every subsequent log line
+// written through the returned logger.
+func (l sugaredLogger) With(args ...interface{}) sugaredLogger {
+	return sugaredLogger{l.SugaredLogger.With(args...)}
+}
+
+// Info logs msg at info level with the given key/value pairs attached.
+func (l sugaredLogger) Info(msg string, keysAndValues ...interface{}) {
+	l.SugaredLogger.Infow(msg, keysAndValues...)
+}
+
+// Warn logs msg at warn level with the given key/value pairs attached.
+func (l sugaredLogger) Warn(msg string, keysAndValues ...interface{}) {
+	l.SugaredLogger.Warnw(msg, keysAndValues...)
+}
+
+// Error logs msg at error level with the given key/value pairs attached.
+func (l sugaredLogger) Error(msg string, keysAndValues ...interface{}) {
+	l.SugaredLogger.Errorw(msg, keysAndValues...)
+}
+
+// Logger is the service's structured logger. It writes JSON to stdout so
+// log aggregation can index fields (request_id, user_id, mission_id, ...)
+// instead of grepping free-form log.Printf text. Call sites that want those
+// fields attached should use Logger.With(...) rather than passing them
+// inline in the message.
+var Logger = mustNewLogger()
+
+func mustNewLogger() sugaredLogger {
+	zl, err := zap.NewProduction()
+	if err != nil {
+		panic(fmt.Sprintf("failed to initialize zap logger: %v", err))
+	}
+	return sugaredLogger{zl.Sugar()}
+}
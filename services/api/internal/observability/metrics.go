@@ -0,0 +1,109 @@
+// Package observability wires up Prometheus metrics and OpenTelemetry
+// tracing for the api service: a Gin middleware records request metrics
+// and propagates trace context, and InitTracing configures the OTLP exporter
+// used by handlers and the queue package to emit spans.
+package observability
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	httpRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Total HTTP requests handled, by route, method, and status code.",
+	}, []string{"route", "method", "status"})
+
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency in seconds, by route and method.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	QueueDepth = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "queue_depth",
+		Help: "Number of jobs currently waiting in a queue.",
+	}, []string{"queue"})
+
+	QueueEnqueueTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "queue_enqueue_total",
+		Help: "Total jobs enqueued, by queue.",
+	}, []string{"queue"})
+
+	QueueJobDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "queue_job_duration_seconds",
+		Help:    "Time from enqueue to terminal outcome (ack or dead-letter), by queue and outcome.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"queue", "outcome"})
+
+	QueueStateTransitions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "queue_state_transitions_total",
+		Help: "Job state transitions, by queue and the state entered (queued, processing, delayed, dlq).",
+	}, []string{"queue", "state"})
+
+	// MissionsCreatedTotal counts CreateMission outcomes, by the mission
+	// status it leaves behind ("queued" on success, "failed" if credit
+	// reservation, asset resolution, or enqueueing failed after the mission
+	// row was inserted).
+	MissionsCreatedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "driftline_missions_created_total",
+		Help: "Missions created, by resulting status.",
+	}, []string{"status"})
+
+	// CreditsDeductedTotal is the running total of credits actually spent
+	// (not merely reserved) - incremented wherever a reservation is
+	// Committed or a legacy DeductCredits call succeeds.
+	CreditsDeductedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "driftline_credits_deducted_total",
+		Help: "Total credits deducted (committed reservations plus legacy immediate deductions).",
+	})
+
+	// ApiKeyAuthTotal counts RequireScope outcomes, by result
+	// (success, missing_header, invalid_key, inactive_or_expired,
+	// insufficient_scope, error), so a sudden spike in failures is visible without
+	// grepping logs.
+	ApiKeyAuthTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "driftline_api_key_auth_total",
+		Help: "API key authentication attempts, by result.",
+	}, []string{"result"})
+
+	// S3DownloadBytes is the running total of result-file bytes streamed to
+	// clients through streamFromS3/streamGeoJSONTransform.
+	S3DownloadBytes = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "driftline_s3_download_bytes",
+		Help: "Total bytes of mission result files streamed to clients.",
+	})
+)
+
+// Middleware records http_requests_total and http_request_duration_seconds
+// for every request. It uses c.FullPath() (the matched route pattern, not
+// the raw URL) as the route label so per-path cardinality stays bounded.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		status := strconv.Itoa(c.Writer.Status())
+		httpRequestsTotal.WithLabelValues(route, c.Request.Method, status).Inc()
+		httpRequestDuration.WithLabelValues(route, c.Request.Method).Observe(time.Since(start).Seconds())
+	}
+}
+
+// Handler serves the /metrics endpoint for Prometheus scraping.
+func Handler() gin.HandlerFunc {
+	h := promhttp.Handler()
+	return func(c *gin.Context) {
+		h.ServeHTTP(c.Writer, c.Request)
+	}
+}
@@ -0,0 +1,73 @@
+package observability
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracer is the tracer handlers and the queue package use to start spans.
+var Tracer trace.Tracer = otel.Tracer("driftline-api")
+
+// InitTracing configures the global OTel tracer provider to export spans
+// via OTLP/gRPC to otlpEndpoint (e.g. "otel-collector:4317"). It returns a
+// shutdown function the caller should defer-call to flush pending spans on
+// exit. If otlpEndpoint is empty, tracing is left disabled (a no-op tracer
+// provider) so running without a collector configured is not an error.
+func InitTracing(ctx context.Context, otlpEndpoint string) (shutdown func(context.Context) error, err error) {
+	if otlpEndpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracegrpc.New(ctx, otlptracegrpc.WithEndpoint(otlpEndpoint), otlptracegrpc.WithInsecure())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	res, err := resource.New(ctx, resource.WithAttributes(
+		semconv.ServiceName("driftline-api"),
+		semconv.ServiceVersion(serviceVersion()),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build resource: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	Tracer = tp.Tracer("driftline-api")
+
+	return tp.Shutdown, nil
+}
+
+func serviceVersion() string {
+	if v := os.Getenv("SERVICE_VERSION"); v != "" {
+		return v
+	}
+	return "dev"
+}
+
+// Propagate extracts a traceparent header (if present) from the incoming
+// request into the Gin context, so a span started downstream (e.g. around
+// a Redis or Postgres call) is linked to the caller's trace instead of
+// starting a new one.
+func Propagate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
@@ -0,0 +1,172 @@
+package payments
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/eysteinn/driftline/services/api/internal/database"
+	"github.com/eysteinn/driftline/services/api/internal/ledger"
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/invoice"
+	"github.com/stripe/stripe-go/v76/invoiceitem"
+)
+
+// PrepareInvoiceRecords aggregates period's (a "YYYY-MM" billing month)
+// ledger spend per user into invoice_records rows, one per user, ready
+// for CreateInvoiceItems to turn into Stripe invoice items. Returns the
+// number of records created.
+func PrepareInvoiceRecords(period string) (int, error) {
+	start, end, err := periodBounds(period)
+	if err != nil {
+		return 0, err
+	}
+
+	spend, err := ledger.SpendByUser(start, end)
+	if err != nil {
+		return 0, fmt.Errorf("failed to aggregate ledger spend: %w", err)
+	}
+
+	count := 0
+	for userID, creditsUsed := range spend {
+		description := fmt.Sprintf("Driftline usage for %s (%d credits)", period, creditsUsed)
+		_, err := database.DB.Exec(
+			`INSERT INTO invoice_records (user_id, period, amount_cents, description, status)
+			 VALUES ($1, $2, $3, $4, 'pending')
+			 ON CONFLICT (user_id, period) DO NOTHING`,
+			userID, period, creditsUsed*centsPerCredit, description,
+		)
+		if err != nil {
+			return count, fmt.Errorf("failed to insert invoice_records for user %s: %w", userID, err)
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// centsPerCredit is the price charged per metered credit on usage
+// invoices, independent of whatever a credit_packages row charges for a
+// prepaid bundle.
+const centsPerCredit = 5
+
+// CreateInvoiceItems turns every pending invoice_records row into a draft
+// Stripe invoice item against the user's customer, so the next
+// create-invoices run picks it up. Returns the number of items created.
+func CreateInvoiceItems() (int, error) {
+	rows, err := database.DB.Query(
+		`SELECT ir.id, u.stripe_customer_id, ir.amount_cents, ir.description
+		 FROM invoice_records ir
+		 JOIN users u ON u.id = ir.user_id
+		 WHERE ir.status = 'pending'`,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load pending invoice_records: %w", err)
+	}
+	defer rows.Close()
+
+	type record struct {
+		id          string
+		customerID  sql.NullString
+		amountCents int64
+		description string
+	}
+	var records []record
+	for rows.Next() {
+		var r record
+		if err := rows.Scan(&r.id, &r.customerID, &r.amountCents, &r.description); err != nil {
+			return 0, fmt.Errorf("failed to scan invoice_records row: %w", err)
+		}
+		records = append(records, r)
+	}
+
+	count := 0
+	for _, r := range records {
+		if !r.customerID.Valid || r.customerID.String == "" {
+			return count, fmt.Errorf("invoice_records %s: user has no stripe_customer_id yet", r.id)
+		}
+
+		item, err := invoiceitem.New(&stripe.InvoiceItemParams{
+			Customer:    stripe.String(r.customerID.String),
+			Amount:      stripe.Int64(r.amountCents),
+			Currency:    stripe.String(string(stripe.CurrencyUSD)),
+			Description: stripe.String(r.description),
+		})
+		if err != nil {
+			return count, fmt.Errorf("failed to create invoice item for invoice_records %s: %w", r.id, err)
+		}
+
+		if _, err := database.DB.Exec(
+			`UPDATE invoice_records SET status = 'item_created', stripe_invoice_item_id = $1 WHERE id = $2`,
+			item.ID, r.id,
+		); err != nil {
+			return count, fmt.Errorf("failed to update invoice_records %s: %w", r.id, err)
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// CreateInvoices drafts a Stripe invoice per customer with pending
+// invoice items (item_created invoice_records), pulling in every item
+// Stripe already has queued for that customer. Returns the number of
+// invoices created.
+func CreateInvoices() (int, error) {
+	rows, err := database.DB.Query(
+		`SELECT DISTINCT u.stripe_customer_id
+		 FROM invoice_records ir
+		 JOIN users u ON u.id = ir.user_id
+		 WHERE ir.status = 'item_created'`,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list customers pending invoicing: %w", err)
+	}
+	defer rows.Close()
+
+	var customerIDs []string
+	for rows.Next() {
+		var customerID string
+		if err := rows.Scan(&customerID); err != nil {
+			return 0, fmt.Errorf("failed to scan customer id: %w", err)
+		}
+		customerIDs = append(customerIDs, customerID)
+	}
+
+	count := 0
+	for _, customerID := range customerIDs {
+		inv, err := invoice.New(&stripe.InvoiceParams{
+			Customer:    stripe.String(customerID),
+			AutoAdvance: stripe.Bool(false),
+		})
+		if err != nil {
+			return count, fmt.Errorf("failed to create invoice for customer %s: %w", customerID, err)
+		}
+
+		if _, err := database.DB.Exec(
+			`UPDATE invoice_records SET status = 'invoiced', stripe_invoice_id = $1
+			 WHERE status = 'item_created' AND user_id = (SELECT id FROM users WHERE stripe_customer_id = $2)`,
+			inv.ID, customerID,
+		); err != nil {
+			return count, fmt.Errorf("failed to mark invoice_records invoiced for customer %s: %w", customerID, err)
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// periodBounds parses a "YYYY-MM" billing period into its [start, end)
+// month boundaries.
+func periodBounds(period string) (start, end string, err error) {
+	var year, month int
+	if _, err := fmt.Sscanf(period, "%d-%d", &year, &month); err != nil || month < 1 || month > 12 {
+		return "", "", fmt.Errorf("invalid period %q, expected YYYY-MM", period)
+	}
+
+	nextYear, nextMonth := year, month+1
+	if nextMonth > 12 {
+		nextYear, nextMonth = year+1, 1
+	}
+
+	return fmt.Sprintf("%04d-%02d-01", year, month), fmt.Sprintf("%04d-%02d-01", nextYear, nextMonth), nil
+}
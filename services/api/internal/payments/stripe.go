@@ -0,0 +1,87 @@
+// Package payments implements the Stripe integration backing credit
+// purchases and subscription billing: creating PaymentIntents for
+// one-off package purchases, verifying and applying webhook events, and
+// aggregating per-user ledger spend into draft invoices for recurring
+// billing. It owns its own database access the same way internal/credits
+// does, rather than routing through handlers, so the handlers package can
+// depend on payments without a cycle.
+package payments
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+
+	"github.com/eysteinn/driftline/services/api/internal/database"
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/customer"
+	"github.com/stripe/stripe-go/v76/paymentintent"
+)
+
+// Init points the Stripe SDK at the account's secret key. Call it once
+// during startup, before any handler or CLI command in this package runs.
+func Init() {
+	stripe.Key = os.Getenv("STRIPE_SECRET_KEY")
+}
+
+// CreatePaymentIntent starts a Stripe PaymentIntent for a credit package
+// purchase. It returns the intent so the caller can hand the client_secret
+// to the front-end to complete payment; no credits are granted until the
+// resulting payment_intent.succeeded webhook fires.
+func CreatePaymentIntent(userID, email, packageID string, amountCents int64) (*stripe.PaymentIntent, error) {
+	customerID, err := getOrCreateCustomer(userID, email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve stripe customer: %w", err)
+	}
+
+	pi, err := paymentintent.New(&stripe.PaymentIntentParams{
+		Amount:   stripe.Int64(amountCents),
+		Currency: stripe.String(string(stripe.CurrencyUSD)),
+		Customer: stripe.String(customerID),
+		AutomaticPaymentMethods: &stripe.PaymentIntentAutomaticPaymentMethodsParams{
+			Enabled: stripe.Bool(true),
+		},
+		Metadata: map[string]string{
+			"user_id":    userID,
+			"package_id": packageID,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create payment intent: %w", err)
+	}
+
+	return pi, nil
+}
+
+// getOrCreateCustomer returns userID's Stripe customer ID, creating one
+// (and persisting it to users.stripe_customer_id) on first purchase.
+func getOrCreateCustomer(userID, email string) (string, error) {
+	var customerID sql.NullString
+	err := database.DB.QueryRow(
+		`SELECT stripe_customer_id FROM users WHERE id = $1`,
+		userID,
+	).Scan(&customerID)
+	if err != nil {
+		return "", fmt.Errorf("failed to load user: %w", err)
+	}
+	if customerID.Valid && customerID.String != "" {
+		return customerID.String, nil
+	}
+
+	cust, err := customer.New(&stripe.CustomerParams{
+		Email:    stripe.String(email),
+		Metadata: map[string]string{"user_id": userID},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create stripe customer: %w", err)
+	}
+
+	if _, err := database.DB.Exec(
+		`UPDATE users SET stripe_customer_id = $1 WHERE id = $2`,
+		cust.ID, userID,
+	); err != nil {
+		return "", fmt.Errorf("failed to persist stripe customer id: %w", err)
+	}
+
+	return cust.ID, nil
+}
@@ -0,0 +1,162 @@
+package payments
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/eysteinn/driftline/services/api/internal/database"
+	"github.com/eysteinn/driftline/services/api/internal/ledger"
+	"github.com/lib/pq"
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/webhook"
+)
+
+// pqUniqueViolation is the PostgreSQL error code for unique_violation.
+const pqUniqueViolation = "23505"
+
+// VerifyAndParseEvent checks payload's signature against
+// STRIPE_WEBHOOK_SECRET and returns the parsed event, rejecting anything
+// that wasn't actually sent by Stripe.
+func VerifyAndParseEvent(payload []byte, sigHeader string) (stripe.Event, error) {
+	secret := os.Getenv("STRIPE_WEBHOOK_SECRET")
+	return webhook.ConstructEvent(payload, sigHeader, secret)
+}
+
+// ProcessEvent applies a verified Stripe event, deduplicating by event ID
+// in stripe_events so a Stripe retry of an already-handled event is a
+// no-op rather than crediting the user twice.
+func ProcessEvent(event stripe.Event) error {
+	switch event.Type {
+	case "payment_intent.succeeded":
+		return withDedup(event, handlePaymentIntentSucceeded)
+	case "invoice.paid":
+		return withDedup(event, handleInvoicePaid)
+	default:
+		return nil
+	}
+}
+
+// withDedup records event.ID in stripe_events and runs apply in the same
+// transaction as that insert, so a duplicate delivery fails the insert
+// (unique_violation) and apply never runs twice.
+func withDedup(event stripe.Event, apply func(tx *sql.Tx, event stripe.Event) error) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		`INSERT INTO stripe_events (id, event_type) VALUES ($1, $2)`,
+		event.ID, event.Type,
+	)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == pqUniqueViolation {
+			return nil // already processed
+		}
+		return fmt.Errorf("failed to record stripe event: %w", err)
+	}
+
+	if err := apply(tx, event); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// handlePaymentIntentSucceeded credits the user the package's credits and
+// records a "purchase" transaction, atomically with the stripe_events
+// dedup insert in withDedup's transaction.
+func handlePaymentIntentSucceeded(tx *sql.Tx, event stripe.Event) error {
+	var pi stripe.PaymentIntent
+	if err := json.Unmarshal(event.Data.Raw, &pi); err != nil {
+		return fmt.Errorf("failed to parse payment intent: %w", err)
+	}
+
+	userID := pi.Metadata["user_id"]
+	packageID := pi.Metadata["package_id"]
+	if userID == "" || packageID == "" {
+		return fmt.Errorf("payment intent %s missing user_id/package_id metadata", pi.ID)
+	}
+
+	var credits int
+	var name string
+	err := tx.QueryRow(
+		`SELECT credits, name FROM credit_packages WHERE id = $1`,
+		packageID,
+	).Scan(&credits, &name)
+	if err != nil {
+		return fmt.Errorf("failed to load credit package %s: %w", packageID, err)
+	}
+
+	description := fmt.Sprintf("Purchased %s (%d credits)", name, credits)
+	_, err = ledger.Post(tx, description,
+		ledger.Leg{Account: ledger.SystemCreditsIssued, Amount: -credits},
+		ledger.Leg{Account: ledger.UserAccount(userID), Amount: credits},
+	)
+	return err
+}
+
+// handleInvoicePaid grants the subscription's recurring credit allotment
+// when a subscription invoice is paid. Non-subscription invoices (e.g. the
+// one-off invoices create-invoices produces for usage billing) are
+// ignored here - they don't grant credits, they bill for credits already
+// spent.
+func handleInvoicePaid(tx *sql.Tx, event stripe.Event) error {
+	var inv stripe.Invoice
+	if err := json.Unmarshal(event.Data.Raw, &inv); err != nil {
+		return fmt.Errorf("failed to parse invoice: %w", err)
+	}
+
+	if inv.Subscription == nil {
+		return nil
+	}
+
+	credits, err := subscriptionCredits(inv)
+	if err != nil {
+		return err
+	}
+
+	userID, err := userIDForCustomer(tx, inv.Customer.ID)
+	if err != nil {
+		return err
+	}
+
+	description := fmt.Sprintf("Subscription credit grant (invoice %s)", inv.ID)
+	_, err = ledger.Post(tx, description,
+		ledger.Leg{Account: ledger.SystemCreditsIssued, Amount: -credits},
+		ledger.Leg{Account: ledger.UserAccount(userID), Amount: credits},
+	)
+	return err
+}
+
+// subscriptionCredits reads the number of credits a subscription
+// invoice grants from its first line item's price metadata, where the
+// subscription's credit allotment is configured in the Stripe dashboard.
+func subscriptionCredits(inv stripe.Invoice) (int, error) {
+	if len(inv.Lines.Data) == 0 || inv.Lines.Data[0].Price == nil {
+		return 0, fmt.Errorf("invoice %s has no priced line item", inv.ID)
+	}
+	creditsStr := inv.Lines.Data[0].Price.Metadata["credits"]
+	var credits int
+	if _, err := fmt.Sscanf(creditsStr, "%d", &credits); err != nil || credits <= 0 {
+		return 0, fmt.Errorf("invoice %s price missing valid credits metadata", inv.ID)
+	}
+	return credits, nil
+}
+
+func userIDForCustomer(tx *sql.Tx, stripeCustomerID string) (string, error) {
+	var userID string
+	err := tx.QueryRow(
+		`SELECT id FROM users WHERE stripe_customer_id = $1`,
+		stripeCustomerID,
+	).Scan(&userID)
+	if err != nil {
+		return "", fmt.Errorf("no user found for stripe customer %s: %w", stripeCustomerID, err)
+	}
+	return userID, nil
+}
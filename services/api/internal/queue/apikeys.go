@@ -0,0 +1,74 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// apiKeyCachePrefix namespaces cached API key lookups in Redis, keyed by the
+// SHA-256 hash of the presented key (never the key itself).
+const apiKeyCachePrefix = "api_key:"
+
+// apiKeyCacheTTL bounds how stale a cached key record can get - short enough
+// that a revoked or rotated key stops working quickly, long enough that a
+// hot client isn't round-tripping to Postgres on every request.
+const apiKeyCacheTTL = 5 * time.Minute
+
+// CachedApiKey is the subset of an api_keys row RequireScope needs to
+// authenticate a request, cached so it doesn't hit Postgres every time.
+type CachedApiKey struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"user_id"`
+	Scopes    []byte     `json:"scopes"`
+	IsActive  bool       `json:"is_active"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// CacheApiKey stores key under keyHash for apiKeyCacheTTL.
+func CacheApiKey(keyHash string, key CachedApiKey) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	data, err := json.Marshal(key)
+	if err != nil {
+		return fmt.Errorf("failed to marshal api key: %w", err)
+	}
+	if err := RedisClient.Set(ctx, apiKeyCachePrefix+keyHash, data, apiKeyCacheTTL).Err(); err != nil {
+		return fmt.Errorf("failed to cache api key: %w", err)
+	}
+	return nil
+}
+
+// GetCachedApiKey returns the cached record for keyHash, or nil if nothing
+// is cached (not necessarily meaning the key doesn't exist - just that
+// RequireScope needs to fall back to Postgres).
+func GetCachedApiKey(keyHash string) (*CachedApiKey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	data, err := RedisClient.Get(ctx, apiKeyCachePrefix+keyHash).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cached api key: %w", err)
+	}
+
+	var key CachedApiKey
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal cached api key: %w", err)
+	}
+	return &key, nil
+}
+
+// InvalidateApiKeyCache removes keyHash's cached record, so a newly rotated
+// or deleted key doesn't keep authenticating against a stale cache entry.
+func InvalidateApiKeyCache(keyHash string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return RedisClient.Del(ctx, apiKeyCachePrefix+keyHash).Err()
+}
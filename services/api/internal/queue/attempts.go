@@ -0,0 +1,57 @@
+package queue
+
+import (
+	"log"
+	"time"
+
+	"github.com/eysteinn/driftline/services/api/internal/database"
+)
+
+// recordAttemptStarted inserts a mission_attempts row for a job that just
+// came off the queue, giving operators a persisted history of every
+// attempt a mission's simulation made (as opposed to the Redis job
+// metadata, which only ever reflects the current attempt). It's
+// best-effort: a failure to persist history doesn't affect the job
+// itself, so errors are logged rather than returned, and a database.DB
+// that was never configured (e.g. a worker running without Postgres
+// access) is silently skipped.
+func recordAttemptStarted(missionID, jobID string, attempt int) {
+	if database.DB == nil {
+		return
+	}
+	_, err := database.DB.Exec(
+		`INSERT INTO mission_attempts (mission_id, job_id, attempt, status, started_at)
+		 VALUES ($1, $2, $3, 'processing', $4)`,
+		missionID, jobID, attempt, time.Now(),
+	)
+	if err != nil {
+		log.Printf("queue: failed to record attempt start for mission %s job %s: %v", missionID, jobID, err)
+	}
+}
+
+// recordAttemptFinished updates the still-open mission_attempts row
+// recorded by recordAttemptStarted with the attempt's outcome. Rows already
+// finished by an earlier run of the same job ID (e.g. before a rejudge)
+// are left as they are.
+func recordAttemptFinished(missionID, jobID string, attempt int, status, errMsg string) {
+	if database.DB == nil {
+		return
+	}
+	_, err := database.DB.Exec(
+		`UPDATE mission_attempts SET status = $1, error_message = $2, finished_at = $3
+		 WHERE mission_id = $4 AND job_id = $5 AND attempt = $6 AND finished_at IS NULL`,
+		status, nullableString(errMsg), time.Now(), missionID, jobID, attempt,
+	)
+	if err != nil {
+		log.Printf("queue: failed to record attempt finish for mission %s job %s: %v", missionID, jobID, err)
+	}
+}
+
+// nullableString turns an empty string into a SQL NULL rather than
+// storing an empty error_message for attempts that succeeded.
+func nullableString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
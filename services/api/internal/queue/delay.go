@@ -0,0 +1,112 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/eysteinn/driftline/services/api/internal/observability"
+	"github.com/redis/go-redis/v9"
+)
+
+// delayBase and delayMax bound the exponential backoff applied between a
+// nacked job's attempts: attempt 1 waits ~delayBase, each subsequent
+// attempt roughly doubles, capped at delayMax so a job with MaxAttempts-1
+// retries can't end up parked for hours.
+const (
+	delayBase = 10 * time.Second
+	delayMax  = 5 * time.Minute
+)
+
+// delayedRequeueScanInterval is how often StartDelayedRequeuer checks for
+// jobs whose backoff has elapsed.
+const delayedRequeueScanInterval = 2 * time.Second
+
+func delayedKey() string {
+	return queueName() + ":delayed"
+}
+
+// backoffDelay returns how long a job should wait before its next attempt,
+// given the attempt number it just failed (1-indexed, as stored by
+// Dequeue). It doubles per attempt and caps at delayMax.
+func backoffDelay(attempt int) time.Duration {
+	d := delayBase
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if d >= delayMax {
+			return delayMax
+		}
+	}
+	return d
+}
+
+// scheduleRetry parks jobID in the delayed-retry ZSET, scored by the Unix
+// time it becomes eligible for requeue, instead of putting it straight
+// back on the main queue. This is what gives failed jobs exponential
+// backoff rather than an immediate retry that can hammer a still-struggling
+// dependency.
+func scheduleRetry(ctx context.Context, jobID string, attempt int) error {
+	readyAt := time.Now().Add(backoffDelay(attempt))
+	return RedisClient.ZAdd(ctx, delayedKey(), redis.Z{
+		Score:  float64(readyAt.Unix()),
+		Member: jobID,
+	}).Err()
+}
+
+// StartDelayedRequeuer runs until ctx is cancelled, periodically moving
+// jobs whose backoff has elapsed from the delayed-retry ZSET back onto the
+// main queue. It's meant to be started once per process with
+// `go queue.StartDelayedRequeuer(ctx)`, alongside StartReaper.
+func StartDelayedRequeuer(ctx context.Context) {
+	ticker := time.NewTicker(delayedRequeueScanInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := requeueDueJobs(ctx); err != nil {
+				log.Printf("queue delayed requeuer: %v", err)
+			}
+		}
+	}
+}
+
+// requeueDueJobs moves every job in the delayed-retry ZSET whose score
+// (ready time) has passed back onto the main queue.
+func requeueDueJobs(ctx context.Context) error {
+	ids, err := RedisClient.ZRangeByScore(ctx, delayedKey(), &redis.ZRangeBy{
+		Min: "-inf",
+		Max: fmt.Sprintf("%d", time.Now().Unix()),
+	}).Result()
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		// ZRem first so two processes racing this scan don't both
+		// requeue the same job.
+		removed, err := RedisClient.ZRem(ctx, delayedKey(), id).Result()
+		if err != nil || removed == 0 {
+			continue
+		}
+
+		if err := RedisClient.HSet(ctx, metaKey(id), map[string]interface{}{
+			"status":         "queued",
+			"last_heartbeat": time.Now().Unix(),
+		}).Err(); err != nil {
+			log.Printf("queue delayed requeuer: failed to update metadata for %s: %v", id, err)
+			continue
+		}
+		if err := RedisClient.RPush(ctx, queueName(), id).Err(); err != nil {
+			log.Printf("queue delayed requeuer: failed to requeue %s: %v", id, err)
+			continue
+		}
+		observability.QueueStateTransitions.WithLabelValues(queueName(), "queued").Inc()
+		reportQueueDepth(ctx)
+	}
+
+	return nil
+}
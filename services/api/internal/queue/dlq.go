@@ -0,0 +1,117 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/eysteinn/driftline/services/api/internal/observability"
+)
+
+// DLQEntry describes a job that exhausted MaxAttempts and was moved to the
+// dead-letter list, as surfaced by ListDLQ.
+type DLQEntry struct {
+	JobID         string         `json:"job_id"`
+	MissionID     string         `json:"mission_id"`
+	Params        DriftJobParams `json:"params"`
+	Attempt       int            `json:"attempt"`
+	FailureReason string         `json:"failure_reason"`
+}
+
+// OnDeadLetter, if set, is invoked after a job is moved to the
+// dead-letter list with the mission, job, and failure reason. main wires
+// this to refund the mission's credit reservation and mark it failed,
+// since this package has no notion of missions or credits of its own -
+// it only knows job IDs and Redis. Left nil, dead-lettering a job has no
+// side effect beyond what moveToDLQ itself does.
+var OnDeadLetter func(missionID, jobID, reason string)
+
+// moveToDLQ marks jobID as failed and appends it to the dead-letter list.
+// ctx's deadline is shared with the caller that is already mid-transaction
+// against Redis (failJob, reapOnce), rather than each opening its own.
+func moveToDLQ(ctx context.Context, jobID, reason string) error {
+	missionID, _ := RedisClient.HGet(ctx, metaKey(jobID), "mission_id").Result()
+
+	if err := RedisClient.HSet(ctx, metaKey(jobID), map[string]interface{}{
+		"status":         "dlq",
+		"failure_reason": reason,
+	}).Err(); err != nil {
+		return fmt.Errorf("failed to update job metadata: %w", err)
+	}
+	if err := RedisClient.RPush(ctx, dlqKey(), jobID).Err(); err != nil {
+		return fmt.Errorf("failed to move job to dead-letter list: %w", err)
+	}
+	observability.QueueStateTransitions.WithLabelValues(queueName(), "dlq").Inc()
+
+	if OnDeadLetter != nil && missionID != "" {
+		OnDeadLetter(missionID, jobID, reason)
+	}
+
+	return nil
+}
+
+// ListDLQ returns every job currently in the dead-letter list, for
+// operator inspection.
+func ListDLQ() ([]DLQEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ids, err := RedisClient.LRange(ctx, dlqKey(), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead-letter queue: %w", err)
+	}
+
+	entries := make([]DLQEntry, 0, len(ids))
+	for _, id := range ids {
+		fields, err := RedisClient.HGetAll(ctx, metaKey(id)).Result()
+		if err != nil || len(fields) == 0 {
+			continue
+		}
+
+		var params DriftJobParams
+		_ = json.Unmarshal([]byte(fields["params"]), &params)
+		attempt, _ := strconv.Atoi(fields["attempt"])
+
+		entries = append(entries, DLQEntry{
+			JobID:         id,
+			MissionID:     fields["mission_id"],
+			Params:        params,
+			Attempt:       attempt,
+			FailureReason: fields["failure_reason"],
+		})
+	}
+
+	return entries, nil
+}
+
+// RequeueFromDLQ moves jobID out of the dead-letter list and back onto the
+// main queue with its attempt count reset, giving it a fresh MaxAttempts
+// budget.
+func RequeueFromDLQ(jobID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	removed, err := RedisClient.LRem(ctx, dlqKey(), 1, jobID).Result()
+	if err != nil {
+		return fmt.Errorf("failed to remove job from dead-letter list: %w", err)
+	}
+	if removed == 0 {
+		return fmt.Errorf("job %s not found in dead-letter list", jobID)
+	}
+
+	if err := RedisClient.HSet(ctx, metaKey(jobID), map[string]interface{}{
+		"attempt":        0,
+		"status":         "queued",
+		"last_heartbeat": time.Now().Unix(),
+	}).Err(); err != nil {
+		return fmt.Errorf("failed to reset job metadata: %w", err)
+	}
+
+	if err := RedisClient.RPush(ctx, queueName(), jobID).Err(); err != nil {
+		return fmt.Errorf("failed to requeue job: %w", err)
+	}
+
+	return nil
+}
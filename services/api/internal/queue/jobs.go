@@ -0,0 +1,294 @@
+// Package queue's job-queue files implement a reliable, at-least-once
+// drift simulation queue on top of Redis: jobs are deduplicated by a
+// deterministic job ID, moved onto a per-worker processing list while
+// in flight so a crashed worker's jobs are visible to the reaper
+// (see reaper.go), and retried up to MaxAttempts before landing in the
+// dead-letter list (see dlq.go).
+package queue
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/eysteinn/driftline/services/api/internal/observability"
+	"github.com/redis/go-redis/v9"
+)
+
+// VisibilityTimeout is how long a job may sit in a processing list without
+// a heartbeat before the reaper considers its worker dead and requeues it.
+const VisibilityTimeout = 5 * time.Minute
+
+// MaxAttempts is how many attempts a job gets (the first run plus
+// MaxAttempts-1 retries) before it is moved to the dead-letter list.
+const MaxAttempts = 5
+
+// dequeueTimeout bounds how long Dequeue blocks waiting for a job.
+const dequeueTimeout = 5 * time.Second
+
+// DriftJobParams represents the parameters for a drift simulation job
+type DriftJobParams struct {
+	Latitude      float64  `json:"latitude"`
+	Longitude     float64  `json:"longitude"`
+	StartTime     string   `json:"start_time"`
+	DurationHours int      `json:"duration_hours"`
+	NumParticles  int      `json:"num_particles"`
+	ObjectType    int      `json:"object_type"`
+	Backtracking  bool     `json:"backtracking"`
+	// AssetKeys are "s3://bucket/key" locations of uploaded mission
+	// assets (custom current fields, bathymetry, search regions) the
+	// worker should fetch and use in place of its defaults.
+	AssetKeys []string `json:"asset_keys,omitempty"`
+}
+
+// DriftJob represents a drift simulation job
+type DriftJob struct {
+	JobID     string         `json:"job_id"`
+	MissionID string         `json:"mission_id"`
+	Params    DriftJobParams `json:"params"`
+	Attempt   int            `json:"attempt"`
+}
+
+func queueName() string {
+	if name := os.Getenv("QUEUE_NAME"); name != "" {
+		return name
+	}
+	return "drift_jobs"
+}
+
+func processingKey(workerID string) string {
+	return queueName() + ":processing:" + workerID
+}
+
+func dlqKey() string {
+	return queueName() + ":dlq"
+}
+
+func metaKey(jobID string) string {
+	return queueName() + ":meta:" + jobID
+}
+
+// jobID deterministically derives a job ID from a mission and its
+// parameters, so enqueuing the same mission+params twice (e.g. a client
+// retrying a timed-out request) is deduplicated rather than run twice.
+func jobID(missionID string, params DriftJobParams) (string, error) {
+	paramsJSON, err := json.Marshal(params)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal job params: %w", err)
+	}
+	sum := sha256.Sum256(append([]byte(missionID+":"), paramsJSON...))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// EnqueueDriftJob adds a drift simulation job to the Redis queue. It is
+// idempotent: if a job with the same mission ID and params is already
+// queued, processing, or delayed, this is a no-op. A job with that ID
+// sitting in the DLQ is moved back onto the queue via RequeueFromDLQ.
+func EnqueueDriftJob(missionID string, params DriftJobParams) error {
+	id, err := jobID(missionID, params)
+	if err != nil {
+		return err
+	}
+
+	paramsJSON, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job params: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	created, err := RedisClient.HSetNX(ctx, metaKey(id), "mission_id", missionID).Result()
+	if err != nil {
+		return fmt.Errorf("failed to record job metadata: %w", err)
+	}
+	if !created {
+		status, err := RedisClient.HGet(ctx, metaKey(id), "status").Result()
+		if err == nil && status == "dlq" {
+			return RequeueFromDLQ(id)
+		}
+		// Already enqueued under this job ID - duplicate enqueue, dedup.
+		return nil
+	}
+
+	now := time.Now().Unix()
+	if err := RedisClient.HSet(ctx, metaKey(id), map[string]interface{}{
+		"params":         string(paramsJSON),
+		"attempt":        0,
+		"status":         "queued",
+		"enqueued_at":    now,
+		"last_heartbeat": now,
+	}).Err(); err != nil {
+		return fmt.Errorf("failed to record job metadata: %w", err)
+	}
+
+	if err := RedisClient.RPush(ctx, queueName(), id).Err(); err != nil {
+		return fmt.Errorf("failed to enqueue job: %w", err)
+	}
+
+	observability.QueueEnqueueTotal.WithLabelValues(queueName()).Inc()
+	observability.QueueStateTransitions.WithLabelValues(queueName(), "queued").Inc()
+	reportQueueDepth(ctx)
+
+	return nil
+}
+
+// reportQueueDepth publishes the main queue's current length as the
+// queue_depth gauge. Errors are swallowed - a failed depth read shouldn't
+// fail the enqueue/dequeue it was piggybacked on.
+func reportQueueDepth(ctx context.Context) {
+	depth, err := RedisClient.LLen(ctx, queueName()).Result()
+	if err != nil {
+		return
+	}
+	observability.QueueDepth.WithLabelValues(queueName()).Set(float64(depth))
+}
+
+// Dequeue blocks briefly waiting for a job, atomically moving it from the
+// main queue onto workerID's processing list via BRPOPLPUSH so the job
+// stays visible (for the reaper in reaper.go) until ack or nack is called.
+// It returns a nil job (and nil funcs) if no job became available within
+// the wait window - callers are expected to loop.
+func Dequeue(ctx context.Context, workerID string) (job *DriftJob, ack func() error, nack func(error) error, err error) {
+	id, err := RedisClient.BRPopLPush(ctx, queueName(), processingKey(workerID), dequeueTimeout).Result()
+	if err == redis.Nil {
+		return nil, nil, nil, nil
+	} else if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to dequeue job: %w", err)
+	}
+	reportQueueDepth(ctx)
+
+	fields, err := RedisClient.HGetAll(ctx, metaKey(id)).Result()
+	if err != nil || len(fields) == 0 {
+		// Metadata is missing (e.g. already acked/requeued elsewhere) -
+		// drop this duplicate delivery rather than processing garbage.
+		RedisClient.LRem(ctx, processingKey(workerID), 1, id)
+		return nil, nil, nil, nil
+	}
+
+	var params DriftJobParams
+	if err := json.Unmarshal([]byte(fields["params"]), &params); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to unmarshal job params: %w", err)
+	}
+	attempt, _ := strconv.Atoi(fields["attempt"])
+	attempt++
+
+	now := time.Now().Unix()
+	if err := RedisClient.HSet(ctx, metaKey(id), map[string]interface{}{
+		"attempt":        attempt,
+		"status":         "processing",
+		"last_heartbeat": now,
+	}).Err(); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to update job metadata: %w", err)
+	}
+	observability.QueueStateTransitions.WithLabelValues(queueName(), "processing").Inc()
+
+	job = &DriftJob{
+		JobID:     id,
+		MissionID: fields["mission_id"],
+		Params:    params,
+		Attempt:   attempt,
+	}
+
+	enqueuedAt, _ := strconv.ParseInt(fields["enqueued_at"], 10, 64)
+
+	recordAttemptStarted(job.MissionID, id, attempt)
+
+	ack = func() error {
+		recordAttemptFinished(job.MissionID, id, attempt, "completed", "")
+		return completeJob(id, workerID, enqueuedAt)
+	}
+	nack = func(cause error) error {
+		reason := ""
+		if cause != nil {
+			reason = cause.Error()
+		}
+		recordAttemptFinished(job.MissionID, id, attempt, "failed", reason)
+		return failJob(id, workerID, attempt, enqueuedAt, cause)
+	}
+
+	return job, ack, nack, nil
+}
+
+// Heartbeat refreshes jobID's last-seen time so the reaper doesn't mistake
+// a still-running job for one whose worker died.
+func Heartbeat(jobID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := RedisClient.HSet(ctx, metaKey(jobID), "last_heartbeat", time.Now().Unix()).Err(); err != nil {
+		return fmt.Errorf("failed to record heartbeat: %w", err)
+	}
+	return nil
+}
+
+// completeJob removes a successfully processed job from its processing
+// list and deletes its metadata.
+func completeJob(jobID, workerID string, enqueuedAt int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := RedisClient.LRem(ctx, processingKey(workerID), 1, jobID).Err(); err != nil {
+		return fmt.Errorf("failed to remove job from processing list: %w", err)
+	}
+	if err := RedisClient.Del(ctx, metaKey(jobID)).Err(); err != nil {
+		return fmt.Errorf("failed to clear job metadata: %w", err)
+	}
+	observeJobDuration("acked", enqueuedAt)
+	return nil
+}
+
+// failJob removes jobID from workerID's processing list and either
+// requeues it for another attempt or, once MaxAttempts is exhausted,
+// moves it to the dead-letter list with cause as the failure reason.
+func failJob(jobID, workerID string, attempt int, enqueuedAt int64, cause error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := RedisClient.LRem(ctx, processingKey(workerID), 1, jobID).Err(); err != nil {
+		return fmt.Errorf("failed to remove job from processing list: %w", err)
+	}
+
+	reason := ""
+	if cause != nil {
+		reason = cause.Error()
+	}
+
+	if attempt >= MaxAttempts {
+		if err := moveToDLQ(ctx, jobID, reason); err != nil {
+			return err
+		}
+		observeJobDuration("dead_letter", enqueuedAt)
+		return nil
+	}
+
+	if err := RedisClient.HSet(ctx, metaKey(jobID), map[string]interface{}{
+		"status":         "delayed",
+		"last_heartbeat": time.Now().Unix(),
+		"failure_reason": reason,
+	}).Err(); err != nil {
+		return fmt.Errorf("failed to update job metadata: %w", err)
+	}
+	// Park it on the delayed-retry ZSET rather than requeueing
+	// immediately, so a retry doesn't hammer a dependency that just
+	// failed - see delay.go.
+	if err := scheduleRetry(ctx, jobID, attempt); err != nil {
+		return fmt.Errorf("failed to schedule retry: %w", err)
+	}
+	observability.QueueStateTransitions.WithLabelValues(queueName(), "delayed").Inc()
+	return nil
+}
+
+// observeJobDuration records how long jobID spent in the queue between
+// enqueue and its terminal outcome.
+func observeJobDuration(outcome string, enqueuedAt int64) {
+	if enqueuedAt == 0 {
+		return
+	}
+	observability.QueueJobDuration.WithLabelValues(queueName(), outcome).Observe(time.Since(time.Unix(enqueuedAt, 0)).Seconds())
+}
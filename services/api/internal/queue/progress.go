@@ -0,0 +1,44 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// progressChannel is the Redis pub/sub channel the drift-simulation worker
+// publishes a mission's progress updates to, and handlers.StreamMissionEvents
+// subscribes to in order to serve them as SSE without polling.
+func progressChannel(missionID string) string {
+	return "mission:" + missionID + ":progress"
+}
+
+// SubscribeMissionProgress subscribes to missionID's progress channel. The
+// subscription is torn down the moment ctx is canceled - callers driving an
+// SSE response pass the request's own context, so a client disconnect
+// cleans up the Redis subscription immediately rather than leaking it.
+func SubscribeMissionProgress(ctx context.Context, missionID string) *redis.PubSub {
+	return RedisClient.Subscribe(ctx, progressChannel(missionID))
+}
+
+// PublishMissionProgress publishes a progress update for missionID. This is
+// the contract the (out-of-repo) drift-simulation worker is expected to
+// publish to as it integrates particles - payload should match the JSON
+// shape handlers.missionProgressEvent expects: an "event" field of
+// "status", "progress", "completed", or "failed", plus whichever of
+// status/progress/eta_seconds/error applies. Exported mainly so that
+// contract has one canonical Go-side definition, even though nothing in
+// this service calls it yet.
+func PublishMissionProgress(missionID string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal mission progress event: %w", err)
+	}
+	ctx := context.Background()
+	if err := RedisClient.Publish(ctx, progressChannel(missionID), data).Err(); err != nil {
+		return fmt.Errorf("failed to publish mission progress event: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package queue
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+)
+
+// reaperScanInterval is how often the reaper checks processing lists for
+// stale entries.
+const reaperScanInterval = 30 * time.Second
+
+// StartReaper runs the dead-worker reaper until ctx is cancelled: on every
+// tick it scans all workers' processing lists for jobs whose heartbeat is
+// older than VisibilityTimeout (a sign their worker died mid-job) and
+// requeues or dead-letters them by the same MaxAttempts and backoff rules
+// failJob applies. It's meant to
+// be started once per process with `go queue.StartReaper(ctx)`.
+func StartReaper(ctx context.Context) {
+	ticker := time.NewTicker(reaperScanInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := reapOnce(ctx); err != nil {
+				log.Printf("queue reaper: %v", err)
+			}
+		}
+	}
+}
+
+// reapOnce scans every "<queue>:processing:*" list and reclaims jobs whose
+// last heartbeat is older than VisibilityTimeout.
+func reapOnce(ctx context.Context) error {
+	pattern := queueName() + ":processing:*"
+
+	var cursor uint64
+	for {
+		keys, next, err := RedisClient.Scan(ctx, cursor, pattern, 100).Result()
+		if err != nil {
+			return err
+		}
+
+		for _, processingList := range keys {
+			if err := reapProcessingList(ctx, processingList); err != nil {
+				log.Printf("queue reaper: failed to reap %s: %v", processingList, err)
+			}
+		}
+
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+	}
+
+	return nil
+}
+
+func reapProcessingList(ctx context.Context, processingList string) error {
+	ids, err := RedisClient.LRange(ctx, processingList, 0, -1).Result()
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		fields, err := RedisClient.HGetAll(ctx, metaKey(id)).Result()
+		if err != nil || len(fields) == 0 {
+			// No metadata left - drop the stale reference.
+			RedisClient.LRem(ctx, processingList, 1, id)
+			continue
+		}
+
+		heartbeat, _ := strconv.ParseInt(fields["last_heartbeat"], 10, 64)
+		if time.Since(time.Unix(heartbeat, 0)) < VisibilityTimeout {
+			continue
+		}
+
+		attempt, _ := strconv.Atoi(fields["attempt"])
+
+		if err := RedisClient.LRem(ctx, processingList, 1, id).Err(); err != nil {
+			return err
+		}
+
+		recordAttemptFinished(fields["mission_id"], id, attempt, "timed_out", "visibility timeout exceeded: worker did not heartbeat or ack")
+
+		if attempt >= MaxAttempts {
+			if err := moveToDLQ(ctx, id, "visibility timeout exceeded: worker did not heartbeat or ack"); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := RedisClient.HSet(ctx, metaKey(id), map[string]interface{}{
+			"status":         "delayed",
+			"failure_reason": "visibility timeout exceeded: worker did not heartbeat or ack",
+		}).Err(); err != nil {
+			return err
+		}
+		// Same backoff as an explicit nack (see scheduleRetry) - a worker
+		// that just died is not a reason to hammer it again immediately.
+		if err := scheduleRetry(ctx, id, attempt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// revokedKeyPrefix namespaces revoked-token keys in Redis so they don't
+// collide with job queue keys.
+const revokedKeyPrefix = "revoked_jti:"
+
+// RevokeToken adds a JWT's jti to the revocation set for ttl, after which
+// Redis reaps the key automatically - matching the token's own expiry means
+// the set never grows unbounded.
+func RevokeToken(jti string, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = time.Minute
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := RedisClient.Set(ctx, revokedKeyPrefix+jti, "1", ttl).Err(); err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	return nil
+}
+
+// IsTokenRevoked reports whether jti has been revoked.
+func IsTokenRevoked(jti string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	n, err := RedisClient.Exists(ctx, revokedKeyPrefix+jti).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+	return n > 0, nil
+}
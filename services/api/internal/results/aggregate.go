@@ -0,0 +1,78 @@
+package results
+
+import (
+	"fmt"
+
+	"github.com/eysteinn/driftline/services/api/internal/models"
+)
+
+// Aggregate computes the centroid, 50%/90% search-area polygons, and
+// particle/stranded counts from a completed drift simulation's trajectory
+// output, ready to persist as a MissionResult.
+func Aggregate(traj *Trajectory) (*models.MissionResult, error) {
+	if len(traj.Particles) == 0 || len(traj.Timesteps) == 0 {
+		return nil, fmt.Errorf("trajectory has no timesteps")
+	}
+
+	centroids, ok := Centroids(traj)
+	lastIdx := len(centroids) - 1
+	if !ok[lastIdx] {
+		return nil, fmt.Errorf("no active particles remain at the final timestep")
+	}
+	finalCentroid := centroids[lastIdx]
+	finalTime := traj.Timesteps[lastIdx]
+
+	finalParticles := traj.Particles[lastIdx]
+	particleCount := len(finalParticles)
+	strandedCount := 0
+
+	proj := newLAEAProjection(finalCentroid.Lat, finalCentroid.Lon)
+	xs := make([]float64, 0, particleCount)
+	ys := make([]float64, 0, particleCount)
+	for _, p := range finalParticles {
+		if p.Status == StatusStranded {
+			strandedCount++
+			continue
+		}
+		x, y := proj.project(p.Lat, p.Lon)
+		xs = append(xs, x)
+		ys = append(ys, y)
+	}
+
+	if len(xs) < 3 {
+		return nil, fmt.Errorf("not enough active particles to estimate a search area")
+	}
+
+	grid := buildDensityGrid(xs, ys)
+
+	search50, err := contourGeoJSON(grid, proj, 0.5)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute 50%% search area: %w", err)
+	}
+	search90, err := contourGeoJSON(grid, proj, 0.9)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute 90%% search area: %w", err)
+	}
+
+	return &models.MissionResult{
+		CentroidLat:      &finalCentroid.Lat,
+		CentroidLon:      &finalCentroid.Lon,
+		CentroidTime:     &finalTime,
+		SearchArea50Geom: search50,
+		SearchArea90Geom: search90,
+		ParticleCount:    &particleCount,
+		StrandedCount:    &strandedCount,
+	}, nil
+}
+
+// contourGeoJSON finds the density level enclosing `fraction` of the
+// grid's probability mass, extracts its contour via marching squares, and
+// encodes the resulting ring(s) as GeoJSON.
+func contourGeoJSON(grid densityGrid, proj laeaProjection, fraction float64) ([]byte, error) {
+	level := massThresholdLevel(grid, fraction)
+	rings := stitchRings(marchingSquares(grid, level))
+	if len(rings) == 0 {
+		return nil, fmt.Errorf("no contour found at %.0f%% probability level", fraction*100)
+	}
+	return ringsToGeoJSON(rings, proj, fraction)
+}
@@ -0,0 +1,45 @@
+package results
+
+import "math"
+
+// Centroid is the mean position of a set of particles.
+type Centroid struct {
+	Lat float64
+	Lon float64
+}
+
+// Centroids computes the per-timestep centroid over non-stranded
+// particles. centroids[t] is only valid (ok[t] == true) if at least one
+// particle was still active at timestep t.
+func Centroids(traj *Trajectory) (centroids []Centroid, ok []bool) {
+	centroids = make([]Centroid, len(traj.Particles))
+	ok = make([]bool, len(traj.Particles))
+
+	for t, particles := range traj.Particles {
+		var sumLat, sumSin, sumCos float64
+		var n int
+		for _, p := range particles {
+			if p.Status == StatusStranded {
+				continue
+			}
+			sumLat += p.Lat
+			// Longitude wraps at +-180, so average it circularly rather
+			// than arithmetically - a naive mean breaks for particles
+			// straddling the antimeridian.
+			rad := p.Lon * math.Pi / 180
+			sumSin += math.Sin(rad)
+			sumCos += math.Cos(rad)
+			n++
+		}
+		if n == 0 {
+			continue
+		}
+		centroids[t] = Centroid{
+			Lat: sumLat / float64(n),
+			Lon: math.Atan2(sumSin/float64(n), sumCos/float64(n)) * 180 / math.Pi,
+		}
+		ok[t] = true
+	}
+
+	return centroids, ok
+}
@@ -0,0 +1,147 @@
+package results
+
+import "math"
+
+type point struct {
+	x, y float64
+}
+
+type segment struct {
+	a, b point
+}
+
+// marchingSquares extracts the line segments along which grid's density
+// crosses level, using the standard 16-case marching squares table.
+// Saddle cases (a diagonal pair of corners above level, the other
+// diagonal below) are resolved using the average of the four corner
+// values - the usual tie-breaking heuristic, since the grid resolution
+// makes the "correct" topology ambiguous either way.
+func marchingSquares(grid densityGrid, level float64) []segment {
+	var segments []segment
+
+	at := func(row, col int) float64 { return grid.density[row*grid.cols+col] }
+	pos := func(row, col int) point {
+		return point{
+			x: grid.minX + float64(col)*grid.cellSize,
+			y: grid.minY + float64(row)*grid.cellSize,
+		}
+	}
+	interp := func(p1, p2 point, v1, v2 float64) point {
+		if v1 == v2 {
+			return point{(p1.x + p2.x) / 2, (p1.y + p2.y) / 2}
+		}
+		t := (level - v1) / (v2 - v1)
+		return point{p1.x + t*(p2.x-p1.x), p1.y + t*(p2.y-p1.y)}
+	}
+	bit := func(v float64) int {
+		if v >= level {
+			return 1
+		}
+		return 0
+	}
+
+	for row := 0; row < grid.rows-1; row++ {
+		for col := 0; col < grid.cols-1; col++ {
+			tl, tr, br, bl := at(row, col), at(row, col+1), at(row+1, col+1), at(row+1, col)
+			pTL, pTR, pBR, pBL := pos(row, col), pos(row, col+1), pos(row+1, col+1), pos(row+1, col)
+
+			top := func() point { return interp(pTL, pTR, tl, tr) }
+			right := func() point { return interp(pTR, pBR, tr, br) }
+			bottom := func() point { return interp(pBL, pBR, bl, br) }
+			left := func() point { return interp(pTL, pBL, tl, bl) }
+
+			c := bit(tl)<<3 | bit(tr)<<2 | bit(br)<<1 | bit(bl)
+
+			switch c {
+			case 1, 14:
+				segments = append(segments, segment{left(), bottom()})
+			case 2, 13:
+				segments = append(segments, segment{bottom(), right()})
+			case 3, 12:
+				segments = append(segments, segment{left(), right()})
+			case 4, 11:
+				segments = append(segments, segment{top(), right()})
+			case 6, 9:
+				segments = append(segments, segment{top(), bottom()})
+			case 7, 8:
+				segments = append(segments, segment{top(), left()})
+			case 5:
+				if avg := (tl + tr + br + bl) / 4; avg >= level {
+					segments = append(segments, segment{top(), left()}, segment{bottom(), right()})
+				} else {
+					segments = append(segments, segment{top(), right()}, segment{bottom(), left()})
+				}
+			case 10:
+				if avg := (tl + tr + br + bl) / 4; avg >= level {
+					segments = append(segments, segment{top(), right()}, segment{bottom(), left()})
+				} else {
+					segments = append(segments, segment{top(), left()}, segment{bottom(), right()})
+				}
+			}
+		}
+	}
+
+	return segments
+}
+
+// stitchRings joins unordered contour segments into closed rings by
+// matching shared endpoints. Adjacent cells compute the same interpolated
+// point for a shared edge, so rounding to a fixed precision is enough to
+// merge them without an explicit topology.
+func stitchRings(segments []segment) [][]point {
+	const precision = 1e6
+	keyOf := func(p point) [2]int64 {
+		return [2]int64{int64(math.Round(p.x * precision)), int64(math.Round(p.y * precision))}
+	}
+
+	adjacency := make(map[[2]int64][][2]int64)
+	pointAt := make(map[[2]int64]point)
+	addEdge := func(ka, kb [2]int64, pa, pb point) {
+		pointAt[ka] = pa
+		pointAt[kb] = pb
+		adjacency[ka] = append(adjacency[ka], kb)
+	}
+
+	for _, s := range segments {
+		ka, kb := keyOf(s.a), keyOf(s.b)
+		if ka == kb {
+			continue
+		}
+		addEdge(ka, kb, s.a, s.b)
+		addEdge(kb, ka, s.b, s.a)
+	}
+
+	visited := make(map[[2]int64]bool)
+	var rings [][]point
+
+	for start := range adjacency {
+		if visited[start] {
+			continue
+		}
+		ring := []point{pointAt[start]}
+		visited[start] = true
+		current := start
+		for {
+			var next [2]int64
+			found := false
+			for _, n := range adjacency[current] {
+				if !visited[n] {
+					next = n
+					found = true
+					break
+				}
+			}
+			if !found {
+				break
+			}
+			ring = append(ring, pointAt[next])
+			visited[next] = true
+			current = next
+		}
+		if len(ring) >= 3 {
+			rings = append(rings, ring)
+		}
+	}
+
+	return rings
+}
@@ -0,0 +1,34 @@
+package results
+
+import "encoding/json"
+
+// ringsToGeoJSON converts one or more closed rings (in projected x/y) into
+// a GeoJSON FeatureCollection, one Polygon feature per ring, each carrying
+// the probability-mass fraction it was contoured at.
+func ringsToGeoJSON(rings [][]point, proj laeaProjection, fraction float64) (json.RawMessage, error) {
+	features := make([]map[string]interface{}, 0, len(rings))
+	for _, ring := range rings {
+		coords := make([][2]float64, len(ring)+1)
+		for i, p := range ring {
+			lat, lon := proj.unproject(p.x, p.y)
+			coords[i] = [2]float64{lon, lat}
+		}
+		coords[len(ring)] = coords[0] // GeoJSON polygon rings must be closed
+
+		features = append(features, map[string]interface{}{
+			"type": "Feature",
+			"geometry": map[string]interface{}{
+				"type":        "Polygon",
+				"coordinates": [][][2]float64{coords},
+			},
+			"properties": map[string]interface{}{
+				"probability": fraction,
+			},
+		})
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"type":     "FeatureCollection",
+		"features": features,
+	})
+}
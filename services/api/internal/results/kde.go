@@ -0,0 +1,145 @@
+package results
+
+import (
+	"math"
+	"sort"
+)
+
+// gridResolutionCells is the number of grid cells along the longer axis of
+// the KDE evaluation grid. Higher values give smoother contours at more
+// CPU cost; 64 is enough resolution for a search area a few hundred km
+// across while keeping the O(cells * particles) KDE evaluation cheap.
+const gridResolutionCells = 64
+
+// densityGrid is a regular planar grid of Gaussian-KDE-evaluated
+// probability density, used to derive probability-mass contours.
+type densityGrid struct {
+	minX, minY float64
+	cellSize   float64
+	cols, rows int
+	density    []float64 // row-major, cols*rows
+}
+
+// buildDensityGrid bins xs/ys into a grid padded beyond their bounding box
+// and evaluates a Gaussian KDE with Silverman's-rule bandwidth at each
+// cell center.
+func buildDensityGrid(xs, ys []float64) densityGrid {
+	bandwidth := silvermanBandwidth(xs, ys)
+
+	minX, maxX := minMax(xs)
+	minY, maxY := minMax(ys)
+
+	// Pad beyond the bounding box so the KDE tails aren't clipped.
+	pad := bandwidth * 3
+	minX -= pad
+	maxX += pad
+	minY -= pad
+	maxY += pad
+
+	width := maxX - minX
+	height := maxY - minY
+	cellSize := math.Max(width, height) / gridResolutionCells
+	if cellSize == 0 {
+		cellSize = 1
+	}
+
+	cols := int(math.Ceil(width/cellSize)) + 1
+	rows := int(math.Ceil(height/cellSize)) + 1
+
+	grid := densityGrid{
+		minX:     minX,
+		minY:     minY,
+		cellSize: cellSize,
+		cols:     cols,
+		rows:     rows,
+		density:  make([]float64, cols*rows),
+	}
+
+	norm := 1 / (2 * math.Pi * bandwidth * bandwidth * float64(len(xs)))
+	for row := 0; row < rows; row++ {
+		cy := minY + (float64(row)+0.5)*cellSize
+		for col := 0; col < cols; col++ {
+			cx := minX + (float64(col)+0.5)*cellSize
+			var sum float64
+			for i := range xs {
+				dx := cx - xs[i]
+				dy := cy - ys[i]
+				sum += math.Exp(-(dx*dx + dy*dy) / (2 * bandwidth * bandwidth))
+			}
+			grid.density[row*cols+col] = sum * norm
+		}
+	}
+
+	return grid
+}
+
+// silvermanBandwidth returns Silverman's rule-of-thumb bandwidth for a 2D
+// Gaussian KDE over the given points.
+func silvermanBandwidth(xs, ys []float64) float64 {
+	n := float64(len(xs))
+	sigma := (stdDev(xs) + stdDev(ys)) / 2
+	if sigma == 0 {
+		sigma = 1
+	}
+	return sigma * math.Pow(n, -1.0/6.0)
+}
+
+func stdDev(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var mean float64
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+
+	var variance float64
+	for _, v := range values {
+		d := v - mean
+		variance += d * d
+	}
+	variance /= float64(len(values))
+	return math.Sqrt(variance)
+}
+
+func minMax(values []float64) (min, max float64) {
+	min, max = values[0], values[0]
+	for _, v := range values[1:] {
+		if v < min {
+			min = v
+		}
+		if v > max {
+			max = v
+		}
+	}
+	return min, max
+}
+
+// massThresholdLevel returns the density value such that cells at or above
+// it hold at least `fraction` of the grid's total probability mass - the
+// contour level enclosing that fraction of particles. Cells are sorted
+// descending by density and accumulated until the running total crosses
+// fraction * totalMass.
+func massThresholdLevel(grid densityGrid, fraction float64) float64 {
+	sorted := append([]float64(nil), grid.density...)
+	sort.Sort(sort.Reverse(sort.Float64Slice(sorted)))
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	var total float64
+	for _, d := range grid.density {
+		total += d
+	}
+	target := total * fraction
+
+	var cumulative float64
+	for _, d := range sorted {
+		cumulative += d
+		if cumulative >= target {
+			return d
+		}
+	}
+	return sorted[len(sorted)-1]
+}
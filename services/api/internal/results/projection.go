@@ -0,0 +1,46 @@
+package results
+
+import "math"
+
+const earthRadiusMeters = 6371000.0
+
+// laeaProjection is a spherical Lambert azimuthal equal-area projection
+// centered on an origin point. It turns a cluster of particles near a
+// drift centroid into a local planar grid for kernel density estimation
+// without the area distortion a naive lat/lon grid would introduce.
+type laeaProjection struct {
+	lat0, lon0 float64 // radians
+}
+
+func newLAEAProjection(originLat, originLon float64) laeaProjection {
+	return laeaProjection{
+		lat0: originLat * math.Pi / 180,
+		lon0: originLon * math.Pi / 180,
+	}
+}
+
+// project converts a lat/lon (degrees) to planar (x, y) meters.
+func (p laeaProjection) project(lat, lon float64) (x, y float64) {
+	phi := lat * math.Pi / 180
+	lambda := lon * math.Pi / 180
+	dLambda := lambda - p.lon0
+
+	kPrime := math.Sqrt(2 / (1 + math.Sin(p.lat0)*math.Sin(phi) + math.Cos(p.lat0)*math.Cos(phi)*math.Cos(dLambda)))
+	x = earthRadiusMeters * kPrime * math.Cos(phi) * math.Sin(dLambda)
+	y = earthRadiusMeters * kPrime * (math.Cos(p.lat0)*math.Sin(phi) - math.Sin(p.lat0)*math.Cos(phi)*math.Cos(dLambda))
+	return x, y
+}
+
+// unproject converts planar (x, y) meters back to lat/lon degrees.
+func (p laeaProjection) unproject(x, y float64) (lat, lon float64) {
+	rho := math.Hypot(x, y)
+	if rho < 1e-9 {
+		return p.lat0 * 180 / math.Pi, p.lon0 * 180 / math.Pi
+	}
+
+	c := 2 * math.Asin(rho/(2*earthRadiusMeters))
+	phi := math.Asin(math.Cos(c)*math.Sin(p.lat0) + (y*math.Sin(c)*math.Cos(p.lat0))/rho)
+	lambda := p.lon0 + math.Atan2(x*math.Sin(c), rho*math.Cos(p.lat0)*math.Cos(c)-y*math.Sin(p.lat0)*math.Sin(c))
+
+	return phi * 180 / math.Pi, lambda * 180 / math.Pi
+}
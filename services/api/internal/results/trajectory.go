@@ -0,0 +1,46 @@
+// Package results turns an OpenDrift ensemble run into the fields
+// MissionResult needs for the frontend: a centroid track and 50%/90%
+// probability search-area polygons.
+package results
+
+import (
+	"fmt"
+	"time"
+)
+
+// Status mirrors the OpenDrift particle status codes relevant here:
+// stranded particles no longer represent open-water drift probability and
+// are excluded from the search-area density estimate.
+type Status int
+
+const (
+	StatusActive   Status = 0
+	StatusStranded Status = 1
+)
+
+// Particle is a single drift particle's state at one timestep.
+type Particle struct {
+	Lat    float64
+	Lon    float64
+	Status Status
+}
+
+// Trajectory is the OpenDrift trajectory output for an ensemble run: N
+// particles over T timesteps. Particles[t][n] is particle n's state at
+// timestep t.
+type Trajectory struct {
+	Timesteps []time.Time
+	Particles [][]Particle
+}
+
+// LoadTrajectory reads N×T×(lat, lon, status) particle data out of an
+// OpenDrift NetCDF output file.
+//
+// The repo doesn't vendor a NetCDF decoder yet (see the data-service's
+// NetCDFWriter, which only ever treats .nc files as opaque bytes), so this
+// is a placeholder the worker can swap a real decoder into once one is
+// chosen; Aggregate (aggregate.go) takes an already-decoded *Trajectory so the rest
+// of this package doesn't depend on the file format.
+func LoadTrajectory(path string) (*Trajectory, error) {
+	return nil, fmt.Errorf("results: NetCDF decoding not implemented, got path %q", path)
+}
@@ -0,0 +1,75 @@
+package security
+
+import (
+	"hash/fnv"
+	"math"
+)
+
+// BloomFilter is a fixed-size Bloom filter: MightContain can false-positive
+// (report an item as present when it isn't) but never false-negative,
+// which is the right tradeoff for a "is this password too risky" gate - an
+// occasional extra rejection is cheaper than ever missing a truly
+// compromised password.
+type BloomFilter struct {
+	bits   []uint64
+	nBits  uint64
+	hashes int
+}
+
+// NewBloomFilter creates a filter sized for roughly expectedItems entries
+// at the given target false-positive rate.
+func NewBloomFilter(expectedItems int, falsePositiveRate float64) *BloomFilter {
+	if expectedItems < 1 {
+		expectedItems = 1
+	}
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		falsePositiveRate = 0.01
+	}
+
+	n := float64(expectedItems)
+	m := math.Ceil(-n * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2))
+	k := int(math.Round((m / n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+
+	nBits := uint64(m)
+	return &BloomFilter{
+		bits:   make([]uint64, nBits/64+1),
+		nBits:  nBits,
+		hashes: k,
+	}
+}
+
+// Add records item as present.
+func (b *BloomFilter) Add(item string) {
+	h1, h2 := b.hash(item)
+	for i := 0; i < b.hashes; i++ {
+		idx := (h1 + uint64(i)*h2) % b.nBits
+		b.bits[idx/64] |= 1 << (idx % 64)
+	}
+}
+
+// MightContain reports whether item was possibly added via Add - see the
+// BloomFilter doc comment for the false-positive/false-negative tradeoff.
+func (b *BloomFilter) MightContain(item string) bool {
+	h1, h2 := b.hash(item)
+	for i := 0; i < b.hashes; i++ {
+		idx := (h1 + uint64(i)*h2) % b.nBits
+		if b.bits[idx/64]&(1<<(idx%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// hash derives two hashes from item using FNV-1a's 64- and 32-bit variants,
+// combined via Kirsch-Mitzenmacher double hashing (h1 + i*h2) to simulate
+// b.hashes independent hash functions without computing that many.
+func (b *BloomFilter) hash(item string) (uint64, uint64) {
+	h1 := fnv.New64a()
+	h1.Write([]byte(item))
+	h2 := fnv.New32a()
+	h2.Write([]byte(item))
+	return h1.Sum64(), uint64(h2.Sum32())
+}
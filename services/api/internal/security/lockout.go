@@ -0,0 +1,67 @@
+package security
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/eysteinn/driftline/services/api/internal/queue"
+)
+
+const (
+	// loginAttemptsKeyPrefix namespaces failed-login counters in Redis.
+	loginAttemptsKeyPrefix = "login_attempts:"
+
+	// LoginAttemptsWindow is how long failed attempts are counted before
+	// the counter resets, so failures spread far apart never accumulate
+	// toward a lockout.
+	LoginAttemptsWindow = 15 * time.Minute
+
+	// MaxFailedLoginAttempts is how many failures within
+	// LoginAttemptsWindow trigger an account lockout.
+	MaxFailedLoginAttempts = 5
+
+	// LockoutDuration is how long an account stays locked once
+	// MaxFailedLoginAttempts is reached.
+	LockoutDuration = 15 * time.Minute
+)
+
+// loginAttemptsKey namespaces the failed-attempt counter by the email+IP
+// pair, so each pair counts on its own: failures from one IP against many
+// accounts, or from many IPs against one account, land in separate
+// counters and are not summed.
+func loginAttemptsKey(email, ip string) string {
+	return fmt.Sprintf("%s%s:%s", loginAttemptsKeyPrefix, email, ip)
+}
+
+// RecordFailedLogin increments the failed-attempt counter for email+ip and
+// reports whether this failure just reached MaxFailedLoginAttempts, in
+// which case the caller should lock the account.
+func RecordFailedLogin(email, ip string) (lock bool, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	key := loginAttemptsKey(email, ip)
+	n, err := queue.RedisClient.Incr(ctx, key).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to record failed login: %w", err)
+	}
+	if n == 1 {
+		if err := queue.RedisClient.Expire(ctx, key, LoginAttemptsWindow).Err(); err != nil {
+			return false, fmt.Errorf("failed to set failed-login window: %w", err)
+		}
+	}
+	return n >= MaxFailedLoginAttempts, nil
+}
+
+// ResetFailedLogins clears email+ip's failed-attempt counter, after a
+// successful login.
+func ResetFailedLogins(email, ip string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := queue.RedisClient.Del(ctx, loginAttemptsKey(email, ip)).Err(); err != nil {
+		return fmt.Errorf("failed to reset failed logins: %w", err)
+	}
+	return nil
+}
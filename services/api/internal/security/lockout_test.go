@@ -0,0 +1,27 @@
+package security
+
+import "testing"
+
+func TestLoginAttemptsKey(t *testing.T) {
+	got := loginAttemptsKey("user@example.com", "10.0.0.1")
+	want := "login_attempts:user@example.com:10.0.0.1"
+	if got != want {
+		t.Errorf("loginAttemptsKey() = %q, want %q", got, want)
+	}
+}
+
+func TestLoginAttemptsKey_SameEmailDifferentIPDontCollide(t *testing.T) {
+	a := loginAttemptsKey("user@example.com", "10.0.0.1")
+	b := loginAttemptsKey("user@example.com", "10.0.0.2")
+	if a == b {
+		t.Errorf("expected distinct keys for the same email from different IPs, got %q for both", a)
+	}
+}
+
+func TestLoginAttemptsKey_SameIPDifferentEmailDontCollide(t *testing.T) {
+	a := loginAttemptsKey("alice@example.com", "10.0.0.1")
+	b := loginAttemptsKey("bob@example.com", "10.0.0.1")
+	if a == b {
+		t.Errorf("expected distinct keys for different emails from the same IP, got %q for both", a)
+	}
+}
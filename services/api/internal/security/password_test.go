@@ -0,0 +1,81 @@
+package security
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// fastArgon2Params keeps these tests quick; the actual cost tuning is
+// DefaultArgon2Params's concern, not HashPassword/VerifyPassword's.
+var fastArgon2Params = Argon2Params{
+	Memory:      8 * 1024,
+	Time:        1,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+func TestHashAndVerifyPassword_Argon2id(t *testing.T) {
+	hash, err := HashPassword("correct horse battery staple", fastArgon2Params)
+	if err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+	if !strings.HasPrefix(hash, argon2idPrefix) {
+		t.Fatalf("expected hash to start with %q, got %q", argon2idPrefix, hash)
+	}
+
+	ok, err := VerifyPassword(hash, "correct horse battery staple")
+	if err != nil {
+		t.Fatalf("VerifyPassword() error = %v", err)
+	}
+	if !ok {
+		t.Error("expected the correct password to verify")
+	}
+}
+
+func TestVerifyPassword_Argon2id_WrongPassword(t *testing.T) {
+	hash, err := HashPassword("correct horse battery staple", fastArgon2Params)
+	if err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+
+	ok, err := VerifyPassword(hash, "wrong password")
+	if err != nil {
+		t.Fatalf("VerifyPassword() error = %v", err)
+	}
+	if ok {
+		t.Error("expected the wrong password not to verify")
+	}
+}
+
+func TestVerifyPassword_LegacyBcrypt(t *testing.T) {
+	hashBytes, err := bcrypt.GenerateFromPassword([]byte("legacy password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("bcrypt.GenerateFromPassword() error = %v", err)
+	}
+	hash := string(hashBytes)
+
+	ok, err := VerifyPassword(hash, "legacy password")
+	if err != nil {
+		t.Fatalf("VerifyPassword() error = %v", err)
+	}
+	if !ok {
+		t.Error("expected a legacy bcrypt hash to still verify")
+	}
+
+	ok, err = VerifyPassword(hash, "wrong password")
+	if err != nil {
+		t.Fatalf("VerifyPassword() error = %v", err)
+	}
+	if ok {
+		t.Error("expected the wrong password not to verify against a bcrypt hash")
+	}
+}
+
+func TestVerifyPassword_MalformedArgon2idHash(t *testing.T) {
+	if _, err := VerifyPassword(argon2idPrefix+"not-enough-parts", "anything"); err == nil {
+		t.Error("expected a malformed argon2id hash to return an error")
+	}
+}
@@ -0,0 +1,104 @@
+package security
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// CompromisedPasswordChecker reports whether a password appears in a known
+// set of previously-leaked passwords.
+type CompromisedPasswordChecker interface {
+	MightContain(password string) bool
+}
+
+// commonCompromisedPasswords seeds DefaultCompromisedPasswords. It's a
+// small, hardcoded sample of the most common leaked passwords by published
+// breach-corpus frequency - not a Have-I-Been-Pwned-scale corpus. This repo
+// doesn't bundle one, and nothing here fetches one over the network;
+// DefaultCompromisedPasswords exists so a real corpus can be loaded into a
+// BloomFilter at startup without changing PasswordPolicyConfig's contract.
+var commonCompromisedPasswords = []string{
+	"123456", "password", "123456789", "12345678", "12345",
+	"qwerty", "123123", "111111", "abc123", "password1",
+	"iloveyou", "1q2w3e4r", "admin123", "letmein", "welcome",
+	"monkey", "dragon", "football", "baseball", "trustno1",
+	"000000", "qwertyuiop", "123321", "666666", "1qaz2wsx",
+	"sunshine", "master", "shadow", "superman", "qazwsx",
+}
+
+// DefaultCompromisedPasswords is the CompromisedPasswordChecker
+// DefaultPasswordPolicy uses.
+var DefaultCompromisedPasswords = buildDefaultCompromisedPasswords()
+
+func buildDefaultCompromisedPasswords() *BloomFilter {
+	bf := NewBloomFilter(len(commonCompromisedPasswords), 0.01)
+	for _, p := range commonCompromisedPasswords {
+		bf.Add(strings.ToLower(p))
+	}
+	return bf
+}
+
+// PasswordPolicyConfig controls what CreateUserRequest.Validate enforces on
+// a new password, beyond the binding tag's bare min=8 length check.
+type PasswordPolicyConfig struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	// Compromised, when set, rejects a policy-compliant but
+	// widely-breached password (e.g. "Password1!" passes every
+	// character-class check and is still a bad password).
+	Compromised CompromisedPasswordChecker
+}
+
+// DefaultPasswordPolicy is the policy CreateUserRequest.Validate enforces.
+var DefaultPasswordPolicy = PasswordPolicyConfig{
+	MinLength:     12,
+	RequireUpper:  true,
+	RequireLower:  true,
+	RequireDigit:  true,
+	RequireSymbol: true,
+	Compromised:   DefaultCompromisedPasswords,
+}
+
+// Validate reports the first way password fails cfg, or nil if it passes
+// every check.
+func (cfg PasswordPolicyConfig) Validate(password string) error {
+	if len(password) < cfg.MinLength {
+		return fmt.Errorf("password must be at least %d characters", cfg.MinLength)
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r), unicode.IsSymbol(r):
+			hasSymbol = true
+		}
+	}
+
+	if cfg.RequireUpper && !hasUpper {
+		return fmt.Errorf("password must contain an uppercase letter")
+	}
+	if cfg.RequireLower && !hasLower {
+		return fmt.Errorf("password must contain a lowercase letter")
+	}
+	if cfg.RequireDigit && !hasDigit {
+		return fmt.Errorf("password must contain a digit")
+	}
+	if cfg.RequireSymbol && !hasSymbol {
+		return fmt.Errorf("password must contain a symbol")
+	}
+
+	if cfg.Compromised != nil && cfg.Compromised.MightContain(strings.ToLower(password)) {
+		return fmt.Errorf("password appears in a list of known compromised passwords")
+	}
+	return nil
+}
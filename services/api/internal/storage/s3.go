@@ -0,0 +1,236 @@
+// Package storage is the API's one place that builds an S3 session: it
+// issues presigned URLs for mission result objects and mission input
+// assets so large files move directly between the client or the
+// drift-simulation worker and the bucket instead of proxying through the
+// API process, and backs the handful of spots (streamFromS3) that still
+// do proxy bytes through the process.
+package storage
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// Service issues presigned S3 URLs using the same S3-compatible
+// endpoint/credential convention as the rest of the API's S3 access
+// (S3_ENDPOINT, S3_ACCESS_KEY, S3_SECRET_KEY).
+type Service struct {
+	client *s3.S3
+}
+
+// NewService creates a Service from the given S3-compatible endpoint and
+// credentials.
+func NewService(endpoint, accessKey, secretKey string) (*Service, error) {
+	if endpoint == "" || accessKey == "" || secretKey == "" {
+		return nil, fmt.Errorf("S3 configuration not set")
+	}
+
+	sess, err := session.NewSession(&aws.Config{
+		Endpoint:         aws.String(endpoint),
+		Region:           aws.String("us-east-1"),
+		Credentials:      credentials.NewStaticCredentials(accessKey, secretKey, ""),
+		S3ForcePathStyle: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AWS session: %w", err)
+	}
+
+	return &Service{client: s3.New(sess)}, nil
+}
+
+// PresignGet returns a short-lived URL a client can download the object
+// from directly.
+func (s *Service) PresignGet(bucket, key string, ttl time.Duration) (string, error) {
+	req, _ := s.client.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	return req.Presign(ttl)
+}
+
+// PresignPut returns a short-lived URL a caller (e.g. the drift-simulation
+// worker) can upload the object to directly. contentType is part of what's
+// signed, so the caller's PUT must set a matching Content-Type header.
+func (s *Service) PresignPut(bucket, key string, ttl time.Duration, contentType string) (string, error) {
+	req, _ := s.client.PutObjectRequest(&s3.PutObjectInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(key),
+		ContentType: aws.String(contentType),
+	})
+	return req.Presign(ttl)
+}
+
+// GetObject downloads an object's bytes as a stream, for the handlers
+// that still proxy a file's bytes through the API process instead of
+// redirecting to a presigned URL.
+func (s *Service) GetObject(bucket, key string) (io.ReadCloser, error) {
+	out, err := s.client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.Body, nil
+}
+
+// ObjectInfo is an object's metadata, as returned by Stat.
+type ObjectInfo struct {
+	ETag         string
+	Size         int64
+	LastModified time.Time
+}
+
+// Stat returns an object's size, ETag, and last-modified time - everything
+// a caller needs to answer a conditional or range request without first
+// fetching the object's bytes.
+func (s *Service) Stat(bucket, key string) (ObjectInfo, error) {
+	out, err := s.client.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	return ObjectInfo{
+		ETag:         strings.Trim(aws.StringValue(out.ETag), `"`),
+		Size:         aws.Int64Value(out.ContentLength),
+		LastModified: aws.TimeValue(out.LastModified),
+	}, nil
+}
+
+// HeadObject returns an uploaded object's size and ETag. The ETag is used
+// as a best-effort checksum once an upload completes - a true
+// content checksum would need S3 checksum mode, which not every
+// S3-compatible backend (e.g. MinIO in local dev) supports consistently.
+func (s *Service) HeadObject(bucket, key string) (etag string, size int64, err error) {
+	info, err := s.Stat(bucket, key)
+	if err != nil {
+		return "", 0, err
+	}
+	return info.ETag, info.Size, nil
+}
+
+// RangeRequest carries the conditional/range headers a client sent,
+// forwarded to S3 as-is via GetObjectRange so S3 does the range slicing
+// and conditional check instead of the API fetching the whole object
+// first just to answer a cache-hit or a resumed download.
+type RangeRequest struct {
+	Range       string
+	IfNoneMatch string
+}
+
+// ObjectStream is a streamed GetObject response plus the metadata a caller
+// needs to build its own HTTP response (status code, Content-Range)
+// without reaching back into the AWS SDK's types. NotModified is set (and
+// Body left nil) when req.IfNoneMatch matched the object's current ETag.
+type ObjectStream struct {
+	Body          io.ReadCloser
+	ContentLength int64
+	ContentRange  string
+	NotModified   bool
+}
+
+// GetObjectRange streams an object's bytes, honoring req.Range (a full
+// "bytes=..." Range header value) and req.IfNoneMatch so large downloads
+// can be resumed with a Range request or skipped entirely with a
+// conditional GET instead of always transferring the whole object.
+func (s *Service) GetObjectRange(bucket, key string, req RangeRequest) (*ObjectStream, error) {
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+	if req.Range != "" {
+		input.Range = aws.String(req.Range)
+	}
+	if req.IfNoneMatch != "" {
+		input.IfNoneMatch = aws.String(req.IfNoneMatch)
+	}
+
+	out, err := s.client.GetObject(input)
+	if err != nil {
+		if aerr, ok := err.(awserr.RequestFailure); ok && aerr.StatusCode() == 304 {
+			return &ObjectStream{NotModified: true}, nil
+		}
+		return nil, err
+	}
+
+	return &ObjectStream{
+		Body:          out.Body,
+		ContentLength: aws.Int64Value(out.ContentLength),
+		ContentRange:  aws.StringValue(out.ContentRange),
+	}, nil
+}
+
+// CreateMultipartUpload starts a multipart upload for objects too large
+// for a single presigned PUT, returning the upload ID callers pass to
+// PresignUploadPart and CompleteMultipartUpload.
+func (s *Service) CreateMultipartUpload(bucket, key, contentType string) (string, error) {
+	out, err := s.client.CreateMultipartUpload(&s3.CreateMultipartUploadInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(key),
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return "", err
+	}
+	return aws.StringValue(out.UploadId), nil
+}
+
+// PresignUploadPart returns a short-lived URL for uploading one part of a
+// multipart upload started by CreateMultipartUpload. Part numbers start
+// at 1, per the S3 API.
+func (s *Service) PresignUploadPart(bucket, key, uploadID string, partNumber int64, ttl time.Duration) (string, error) {
+	req, _ := s.client.UploadPartRequest(&s3.UploadPartInput{
+		Bucket:     aws.String(bucket),
+		Key:        aws.String(key),
+		UploadId:   aws.String(uploadID),
+		PartNumber: aws.Int64(partNumber),
+	})
+	return req.Presign(ttl)
+}
+
+// CompletedPart identifies one successfully uploaded part of a multipart
+// upload, as reported by the client after it PUTs each part to its
+// presigned URL and records the ETag S3 returns.
+type CompletedPart struct {
+	PartNumber int64
+	ETag       string
+}
+
+// CompleteMultipartUpload finalizes a multipart upload, assembling its
+// parts into the final object.
+func (s *Service) CompleteMultipartUpload(bucket, key, uploadID string, parts []CompletedPart) error {
+	completed := make([]*s3.CompletedPart, len(parts))
+	for i, p := range parts {
+		completed[i] = &s3.CompletedPart{PartNumber: aws.Int64(p.PartNumber), ETag: aws.String(p.ETag)}
+	}
+
+	_, err := s.client.CompleteMultipartUpload(&s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(bucket),
+		Key:             aws.String(key),
+		UploadId:        aws.String(uploadID),
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: completed},
+	})
+	return err
+}
+
+// AbortMultipartUpload cancels an in-progress multipart upload, freeing
+// the parts already uploaded to it. Used when a client reports that its
+// upload failed partway through instead of completing it.
+func (s *Service) AbortMultipartUpload(bucket, key, uploadID string) error {
+	_, err := s.client.AbortMultipartUpload(&s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(bucket),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadID),
+	})
+	return err
+}
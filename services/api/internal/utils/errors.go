@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// requestIDContextKey mirrors middleware.RequestID's gin context key. It's
+// duplicated rather than imported to avoid a cycle (middleware already
+// imports utils for token handling), so keep it in sync with
+// internal/middleware/requestid.go if that key ever changes.
+const requestIDContextKey = "request_id"
+
+// AppError is the structured error model WriteError renders, replacing the
+// bare message string ErrorResponse takes. Handlers that need more than a
+// message - an error code clients can branch on, field-level validation
+// detail, or both - build one of these instead.
+type AppError struct {
+	// Status is the HTTP status code WriteError responds with.
+	Status int `json:"-"`
+	// Code is a short machine-readable identifier, stable across releases,
+	// e.g. "validation_error" or "insufficient_scope".
+	Code string `json:"code"`
+	// Message is the human-readable summary.
+	Message string `json:"message"`
+	// Details carries structured context, e.g. field -> validation failure
+	// for a ValidationError. Omitted entirely when empty.
+	Details map[string]interface{} `json:"details,omitempty"`
+	// TraceID is filled in by WriteError from the request's X-Request-Id
+	// (see middleware.RequestID), so operators can correlate a client bug
+	// report with server logs.
+	TraceID string `json:"trace_id,omitempty"`
+}
+
+func (e *AppError) Error() string {
+	return e.Message
+}
+
+// NewAppError builds an AppError with the given status, code, and message.
+func NewAppError(status int, code, message string) *AppError {
+	return &AppError{Status: status, Code: code, Message: message}
+}
+
+// ValidationError converts a c.ShouldBindJSON/ShouldBind failure into a 422
+// AppError. A validator.ValidationErrors (the usual case for a struct with
+// `binding` tags, e.g. models.CreateUserRequest/LoginRequest) is expanded
+// into one Details entry per offending field; any other bind error (e.g.
+// malformed JSON) is reported as a single "body" detail.
+func ValidationError(err error) *AppError {
+	appErr := &AppError{
+		Status:  http.StatusUnprocessableEntity,
+		Code:    "validation_error",
+		Message: "Request validation failed",
+		Details: map[string]interface{}{},
+	}
+
+	var verrs validator.ValidationErrors
+	if ok := asValidationErrors(err, &verrs); ok {
+		for _, fe := range verrs {
+			field := strings.ToLower(fe.Field())
+			appErr.Details[field] = fe.ActualTag()
+		}
+		return appErr
+	}
+
+	appErr.Details["body"] = err.Error()
+	return appErr
+}
+
+// asValidationErrors reports whether err is itself a
+// validator.ValidationErrors and, if so, stores it in target. It is a plain
+// type assertion: a ValidationErrors wrapped inside another error is not
+// unwrapped.
+func asValidationErrors(err error, target *validator.ValidationErrors) bool {
+	verrs, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return false
+	}
+	*target = verrs
+	return true
+}
+
+// WriteError sends err as a response, preferring the existing
+// {"error","message"} shape unless the client asked for
+// application/problem+json (RFC 7807), and filling in a TraceID from the
+// request's X-Request-Id along the way. A plain error (not an *AppError) is
+// treated as an opaque 500.
+func WriteError(c *gin.Context, err error) {
+	appErr, ok := err.(*AppError)
+	if !ok {
+		appErr = &AppError{
+			Status:  http.StatusInternalServerError,
+			Code:    "internal_error",
+			Message: err.Error(),
+		}
+	}
+	if appErr.TraceID == "" {
+		appErr.TraceID = c.GetString(requestIDContextKey)
+	}
+
+	if strings.Contains(c.GetHeader("Accept"), "application/problem+json") {
+		c.Header("Content-Type", "application/problem+json")
+		c.JSON(appErr.Status, gin.H{
+			"type":     "about:blank",
+			"title":    http.StatusText(appErr.Status),
+			"status":   appErr.Status,
+			"detail":   appErr.Message,
+			"instance": c.Request.URL.Path,
+			"code":     appErr.Code,
+			"details":  appErr.Details,
+			"trace_id": appErr.TraceID,
+		})
+		return
+	}
+
+	c.JSON(appErr.Status, gin.H{
+		"error":    appErr.Message,
+		"message":  appErr.Message,
+		"code":     appErr.Code,
+		"details":  appErr.Details,
+		"trace_id": appErr.TraceID,
+	})
+}
@@ -1,55 +1,259 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/hex"
+	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var jwtSecret []byte
+// SigningAlgorithm identifies which JWT signing method this package uses to
+// mint and verify tokens.
+type SigningAlgorithm string
+
+const (
+	AlgHS256 SigningAlgorithm = "HS256"
+	AlgRS256 SigningAlgorithm = "RS256"
+)
+
+// rsaKeyPair is one kid's RS256 key material.
+type rsaKeyPair struct {
+	private *rsa.PrivateKey
+	public  *rsa.PublicKey
+}
+
+var (
+	signingAlg SigningAlgorithm
+
+	// signingKID is the kid new tokens are signed with. Verification looks
+	// up whichever kid the token itself names, so tokens issued under a
+	// previous signingKID stay valid until they expire - that's what makes
+	// key rotation possible without invalidating live sessions.
+	signingKID string
+	hmacKeySet map[string][]byte
+	rsaKeySet  map[string]rsaKeyPair
+)
 
 func init() {
-	secret := os.Getenv("JWT_SECRET_KEY")
-	if secret == "" {
-		// Only use default in development
-		if os.Getenv("GIN_MODE") == "release" {
-			panic("JWT_SECRET_KEY must be set in production")
+	signingAlg = SigningAlgorithm(os.Getenv("JWT_SIGNING_ALGORITHM"))
+
+	switch signingAlg {
+	case AlgRS256:
+		if err := loadRSAKeySet(); err != nil {
+			panic(fmt.Sprintf("failed to load JWT RS256 keys: %v", err))
+		}
+	default:
+		signingAlg = AlgHS256
+		loadHMACKeySet()
+	}
+}
+
+// loadHMACKeySet reads JWT_KEYS ("kid1:secret1,kid2:secret2,...") plus
+// JWT_CURRENT_KID into hmacKeySet. When JWT_KEYS isn't set it falls back to
+// a single "default" kid sourced from JWT_SECRET_KEY (or a dev default),
+// so existing single-secret deployments keep working unchanged.
+func loadHMACKeySet() {
+	hmacKeySet = map[string][]byte{}
+
+	raw := os.Getenv("JWT_KEYS")
+	if raw == "" {
+		secret := os.Getenv("JWT_SECRET_KEY")
+		if secret == "" {
+			// Only use default in development
+			if os.Getenv("GIN_MODE") == "release" {
+				panic("JWT_SECRET_KEY must be set in production")
+			}
+			secret = "dev-secret-change-in-production"
+		}
+		hmacKeySet["default"] = []byte(secret)
+		signingKID = "default"
+		return
+	}
+
+	for _, entry := range strings.Split(raw, ",") {
+		kid, secret, ok := strings.Cut(entry, ":")
+		if !ok {
+			continue
+		}
+		hmacKeySet[kid] = []byte(secret)
+	}
+
+	signingKID = os.Getenv("JWT_CURRENT_KID")
+	if _, ok := hmacKeySet[signingKID]; !ok {
+		panic("JWT_CURRENT_KID must name one of the kids in JWT_KEYS")
+	}
+}
+
+// loadRSAKeySet reads JWT_RSA_KEYS ("kid1:privPath1:pubPath1,kid2:...")
+// plus JWT_CURRENT_KID into rsaKeySet.
+func loadRSAKeySet() error {
+	raw := os.Getenv("JWT_RSA_KEYS")
+	if raw == "" {
+		return fmt.Errorf("JWT_RSA_KEYS is required for RS256 (format: kid1:privPath1:pubPath1,kid2:...)")
+	}
+
+	rsaKeySet = map[string]rsaKeyPair{}
+	for _, entry := range strings.Split(raw, ",") {
+		fields := strings.Split(entry, ":")
+		if len(fields) != 3 {
+			return fmt.Errorf("invalid JWT_RSA_KEYS entry: %q", entry)
+		}
+		kid, privPath, pubPath := fields[0], fields[1], fields[2]
+
+		privPEM, err := os.ReadFile(privPath)
+		if err != nil {
+			return fmt.Errorf("failed to read private key for kid %s: %w", kid, err)
+		}
+		privKey, err := jwt.ParseRSAPrivateKeyFromPEM(privPEM)
+		if err != nil {
+			return fmt.Errorf("failed to parse private key for kid %s: %w", kid, err)
+		}
+
+		pubPEM, err := os.ReadFile(pubPath)
+		if err != nil {
+			return fmt.Errorf("failed to read public key for kid %s: %w", kid, err)
+		}
+		pubKey, err := jwt.ParseRSAPublicKeyFromPEM(pubPEM)
+		if err != nil {
+			return fmt.Errorf("failed to parse public key for kid %s: %w", kid, err)
+		}
+
+		rsaKeySet[kid] = rsaKeyPair{private: privKey, public: pubKey}
+	}
+
+	signingKID = os.Getenv("JWT_CURRENT_KID")
+	if _, ok := rsaKeySet[signingKID]; !ok {
+		return fmt.Errorf("JWT_CURRENT_KID must name one of the kids in JWT_RSA_KEYS")
+	}
+	return nil
+}
+
+func signingMethod() jwt.SigningMethod {
+	if signingAlg == AlgRS256 {
+		return jwt.SigningMethodRS256
+	}
+	return jwt.SigningMethodHS256
+}
+
+func signingKey() interface{} {
+	if signingAlg == AlgRS256 {
+		return rsaKeySet[signingKID].private
+	}
+	return hmacKeySet[signingKID]
+}
+
+func verifyingKey(kid string) (interface{}, error) {
+	if signingAlg == AlgRS256 {
+		pair, ok := rsaKeySet[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key id: %s", kid)
 		}
-		secret = "dev-secret-change-in-production"
+		return pair.public, nil
+	}
+	key, ok := hmacKeySet[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id: %s", kid)
 	}
-	jwtSecret = []byte(secret)
+	return key, nil
 }
 
-// GenerateTokenPair generates access and refresh tokens
-func GenerateTokenPair(userID, email string) (accessToken, refreshToken string, err error) {
+// GenerateTokenPair generates access and refresh tokens for a user, each
+// with a unique jti (so individual tokens can be revoked without
+// invalidating every token a user holds) and a kid header naming the key
+// used to sign them (so verification keeps working across key rotation).
+func GenerateTokenPair(userID, email, role string) (accessToken, refreshToken string, err error) {
+	accessJTI, err := newJTI()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate token id: %w", err)
+	}
+
 	// Access token (1 hour)
 	accessClaims := jwt.MapClaims{
 		"user_id": userID,
 		"email":   email,
+		"role":    role,
 		"type":    "access",
+		"jti":     accessJTI,
 		"exp":     time.Now().Add(time.Hour * 1).Unix(),
 		"iat":     time.Now().Unix(),
 	}
-	accessTokenObj := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
-	accessToken, err = accessTokenObj.SignedString(jwtSecret)
+	accessToken, err = sign(accessClaims)
 	if err != nil {
 		return "", "", err
 	}
 
+	refreshJTI, err := newJTI()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate token id: %w", err)
+	}
+
 	// Refresh token (7 days)
 	refreshClaims := jwt.MapClaims{
 		"user_id": userID,
 		"email":   email,
+		"role":    role,
 		"type":    "refresh",
+		"jti":     refreshJTI,
 		"exp":     time.Now().Add(time.Hour * 24 * 7).Unix(),
 		"iat":     time.Now().Unix(),
 	}
-	refreshTokenObj := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
-	refreshToken, err = refreshTokenObj.SignedString(jwtSecret)
+	refreshToken, err = sign(refreshClaims)
 	if err != nil {
 		return "", "", err
 	}
 
 	return accessToken, refreshToken, nil
 }
+
+func sign(claims jwt.MapClaims) (string, error) {
+	token := jwt.NewWithClaims(signingMethod(), claims)
+	token.Header["kid"] = signingKID
+	return token.SignedString(signingKey())
+}
+
+// ParseToken validates tokenString's signature and expiry and returns its
+// claims. It looks up the verifying key by the token's own kid header (to
+// support key rotation) but explicitly checks the token's signing method
+// against the algorithm this server is configured for, rejecting tokens
+// that claim a different alg (e.g. "none", or HS256 signed with the RS256
+// public key) so an attacker can't use a valid token of one kind to forge
+// another.
+func ParseToken(tokenString string) (jwt.MapClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, jwt.MapClaims{}, func(t *jwt.Token) (interface{}, error) {
+		if signingAlg == AlgRS256 {
+			if _, ok := t.Method.(*jwt.SigningMethodRSA); !ok {
+				return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+			}
+		} else {
+			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+			}
+		}
+
+		kid, _ := t.Header["kid"].(string)
+		return verifyingKey(kid)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	return claims, nil
+}
+
+func newJTI() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -1,6 +1,10 @@
 package utils
 
-import "github.com/gin-gonic/gin"
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
 
 // SuccessResponse sends a standardized success response
 func SuccessResponse(c *gin.Context, statusCode int, data interface{}) {
@@ -9,12 +13,41 @@ func SuccessResponse(c *gin.Context, statusCode int, data interface{}) {
 	})
 }
 
-// ErrorResponse sends a standardized error response
+// ErrorResponse sends a standardized error response. It's a thin wrapper
+// around WriteError for the common case of a handler that only has a bare
+// message and no AppError of its own to build, so every error response -
+// not just the ones handlers explicitly construct an AppError for - gets
+// the same RFC-7807-on-request, trace-ID-stamped treatment instead of the
+// two diverging error shapes living side by side.
 func ErrorResponse(c *gin.Context, statusCode int, message string) {
-	c.JSON(statusCode, gin.H{
-		"error":   message,
-		"message": message,
-	})
+	WriteError(c, NewAppError(statusCode, genericErrorCode(statusCode), message))
+}
+
+// genericErrorCode derives an AppError.Code for an ErrorResponse caller,
+// which never picks one of its own, so AppError.Code is never empty even
+// for the legacy call sites.
+func genericErrorCode(statusCode int) string {
+	switch statusCode {
+	case http.StatusBadRequest:
+		return "bad_request"
+	case http.StatusUnauthorized:
+		return "unauthorized"
+	case http.StatusForbidden:
+		return "forbidden"
+	case http.StatusNotFound:
+		return "not_found"
+	case http.StatusConflict:
+		return "conflict"
+	case http.StatusLocked:
+		return "account_locked"
+	case http.StatusUnprocessableEntity:
+		return "unprocessable_entity"
+	default:
+		if statusCode >= 500 {
+			return "internal_error"
+		}
+		return "error"
+	}
 }
 
 // PaginatedResponse sends a paginated response
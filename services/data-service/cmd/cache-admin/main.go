@@ -0,0 +1,144 @@
+// Command cache-admin inspects and manages the object-store cache of
+// external dataset fetches (see internal/clients.ObjectStoreCache): list
+// cached entries, purge them by prefix, and report cumulative hit/miss
+// counts scraped from a running data-service's /metrics endpoint.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/eysteinn/driftline/services/data-service/internal/storage"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(1)
+	}
+
+	switch os.Args[1] {
+	case "list":
+		fs := flag.NewFlagSet("list", flag.ExitOnError)
+		prefix := fs.String("prefix", "cache", "object key prefix to list")
+		fs.Parse(os.Args[2:])
+		runList(context.Background(), mustStorage(), *prefix)
+
+	case "purge":
+		fs := flag.NewFlagSet("purge", flag.ExitOnError)
+		prefix := fs.String("prefix", "", "object key prefix to purge (required)")
+		fs.Parse(os.Args[2:])
+		if *prefix == "" {
+			log.Fatal("purge requires -prefix")
+		}
+		runPurge(context.Background(), mustStorage(), *prefix)
+
+	case "stats":
+		fs := flag.NewFlagSet("stats", flag.ExitOnError)
+		metricsURL := fs.String("metrics-url", "http://localhost:8000/metrics", "data-service /metrics endpoint to scrape")
+		fs.Parse(os.Args[2:])
+		runStats(*metricsURL)
+
+	default:
+		usage()
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `usage: cache-admin <command> [flags]
+
+commands:
+  list   -prefix <prefix>     list cached objects under prefix (default "cache")
+  purge  -prefix <prefix>     delete all cached objects under prefix (required)
+  stats  -metrics-url <url>   report cache hit/miss counts from a running data-service
+
+S3_ENDPOINT, S3_ACCESS_KEY, S3_SECRET_KEY configure the object store for
+list/purge, same as the data-service itself.`)
+}
+
+func mustStorage() *storage.Service {
+	endpoint := getenv("S3_ENDPOINT", "http://localhost:9000")
+	accessKey := getenv("S3_ACCESS_KEY", "minioadmin")
+	secretKey := getenv("S3_SECRET_KEY", "minioadmin")
+
+	s, err := storage.NewService(endpoint, accessKey, secretKey)
+	if err != nil {
+		log.Fatalf("failed to connect to object storage: %v", err)
+	}
+	return s
+}
+
+func getenv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func runList(ctx context.Context, store *storage.Service, prefix string) {
+	objects, err := store.List(ctx, prefix)
+	if err != nil {
+		log.Fatalf("failed to list objects: %v", err)
+	}
+	for _, obj := range objects {
+		fmt.Println(obj)
+	}
+	fmt.Fprintf(os.Stderr, "%d object(s)\n", len(objects))
+}
+
+func runPurge(ctx context.Context, store *storage.Service, prefix string) {
+	objects, err := store.List(ctx, prefix)
+	if err != nil {
+		log.Fatalf("failed to list objects: %v", err)
+	}
+
+	purged := 0
+	for _, obj := range objects {
+		if err := store.Delete(ctx, obj); err != nil {
+			log.Printf("failed to delete %s: %v", obj, err)
+			continue
+		}
+		fmt.Printf("deleted %s\n", obj)
+		purged++
+	}
+	fmt.Fprintf(os.Stderr, "purged %d/%d object(s) under %q\n", purged, len(objects), prefix)
+}
+
+// runStats scrapes the Prometheus text exposition format for
+// external_data_cache_operations_total and prints the per-dataset
+// hit/miss lines as-is. cache-admin runs as a separate one-off process, so
+// reading the counters back off the running data-service's /metrics
+// endpoint is simpler than trying to share its in-memory state.
+func runStats(metricsURL string) {
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	resp, err := client.Get(metricsURL)
+	if err != nil {
+		log.Fatalf("failed to scrape %s: %v", metricsURL, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Fatalf("failed to read metrics response: %v", err)
+	}
+
+	var found bool
+	for _, line := range strings.Split(string(body), "\n") {
+		if strings.HasPrefix(line, "external_data_cache_operations_total{") {
+			fmt.Println(strings.TrimSpace(line))
+			found = true
+		}
+	}
+	if !found {
+		fmt.Fprintln(os.Stderr, "no cache operations recorded yet")
+	}
+}
@@ -1,9 +1,19 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"strconv"
+	"time"
 
+	"github.com/eysteinn/driftline/services/data-service/internal/cache"
+	"github.com/eysteinn/driftline/services/data-service/internal/clients"
+	"github.com/eysteinn/driftline/services/data-service/internal/handlers"
+	"github.com/eysteinn/driftline/services/data-service/internal/jobs"
+	"github.com/eysteinn/driftline/services/data-service/internal/observability"
+	"github.com/eysteinn/driftline/services/data-service/internal/services"
+	"github.com/eysteinn/driftline/services/data-service/internal/storage"
 	"github.com/gin-gonic/gin"
 )
 
@@ -13,27 +23,206 @@ func main() {
 		port = "8000"
 	}
 
+	obs, err := observability.NewService(context.Background(), os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"))
+	if err != nil {
+		log.Fatalf("Failed to initialize tracing: %v", err)
+	}
+	defer obs.Shutdown(context.Background())
+
+	// Initialize cache service
+	redisURL := os.Getenv("REDIS_URL")
+	if redisURL == "" {
+		redisURL = "redis://localhost:6379/1"
+	}
+
+	cacheService, err := cache.NewService(redisURL, 24*time.Hour)
+	if err != nil {
+		log.Printf("Warning: Failed to initialize cache service: %v", err)
+		log.Printf("Continuing without cache...")
+		cacheService = nil
+	} else {
+		defer cacheService.Close()
+		log.Printf("Cache service initialized successfully")
+	}
+
+	// Initialize storage service
+	s3Endpoint := os.Getenv("S3_ENDPOINT")
+	s3AccessKey := os.Getenv("S3_ACCESS_KEY")
+	s3SecretKey := os.Getenv("S3_SECRET_KEY")
+
+	if s3Endpoint == "" {
+		s3Endpoint = "http://localhost:9000"
+	}
+	if s3AccessKey == "" {
+		s3AccessKey = "minioadmin"
+	}
+	if s3SecretKey == "" {
+		s3SecretKey = "minioadmin"
+	}
+
+	storageService, err := storage.NewService(s3Endpoint, s3AccessKey, s3SecretKey)
+	if err != nil {
+		log.Printf("Warning: Failed to initialize storage service: %v", err)
+		log.Printf("Continuing without storage...")
+		storageService = nil
+	} else {
+		log.Printf("Storage service initialized successfully")
+	}
+
+	// Initialize external data provider factory. When storage is available,
+	// fetches are cached in it so repeat requests for the same (or a
+	// narrower) window don't re-hit Copernicus/NOAA.
+	var dataCache clients.Cache
+	if storageService != nil {
+		dataCache = clients.NewObjectStoreCache(storageService)
+	}
+
+	clientFactory := clients.NewDataClientFactory(
+		os.Getenv("COPERNICUS_ENDPOINT"),
+		os.Getenv("COPERNICUS_USERNAME"),
+		os.Getenv("COPERNICUS_PASSWORD"),
+		dataCache,
+	)
+
+	// Initialize data service
+	var dataService *services.DataService
+	if cacheService != nil && storageService != nil {
+		dataService = services.NewDataService(cacheService, storageService, clientFactory)
+		log.Printf("Data service initialized successfully")
+	} else {
+		log.Printf("Warning: Running without full data service capabilities")
+	}
+
+	// Initialize the async job queue and worker. Jobs decouple large NetCDF
+	// fetches from the request/response cycle; the worker runs in-process
+	// for now but is independent enough to split into its own deployment
+	// later.
+	jobQueue, err := jobs.NewQueue(redisURL)
+	if err != nil {
+		log.Printf("Warning: Failed to initialize job queue: %v", err)
+		jobQueue = nil
+	} else {
+		defer jobQueue.Close()
+		log.Printf("Job queue initialized successfully")
+	}
+
+	// Initialize handlers
+	var dataHandler *handlers.DataHandler
+	var cacheHandler *handlers.CacheHandler
+	var jobHandler *handlers.JobHandler
+	if dataService != nil {
+		cacheHandler = handlers.NewCacheHandler(dataService)
+	}
+	if jobQueue != nil && dataService != nil {
+		dataHandler = handlers.NewDataHandler(dataService, jobQueue)
+		jobHandler = handlers.NewJobHandler(jobQueue, dataService)
+	} else if dataService != nil {
+		dataHandler = handlers.NewDataHandler(dataService, nil)
+	}
+
+	if jobQueue != nil && dataService != nil {
+		// A pool of workers dequeues concurrently; Redis' BLPop makes this
+		// safe with multiple consumers. Per-provider rate limiting (e.g. not
+		// overwhelming CMEMS) is enforced separately, in the client factory.
+		workerCount := intEnv("DATA_JOB_WORKERS", 4)
+		workerCtx, stopWorkers := context.WithCancel(context.Background())
+		defer stopWorkers()
+		for i := 0; i < workerCount; i++ {
+			worker := jobs.NewWorker(jobQueue, dataService)
+			go worker.Run(workerCtx)
+		}
+		log.Printf("Started %d job workers", workerCount)
+	}
+
+	// Set up router
 	router := gin.Default()
+	router.Use(observability.Middleware())
+	router.GET("/metrics", observability.Handler())
 
+	// Health check endpoint
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{
-			"status": "healthy",
+			"status":  "healthy",
 			"service": "driftline-data-service",
+			"cache":   cacheService != nil,
+			"storage": storageService != nil,
 		})
 	})
 
-	// Data endpoints
+	// /health/live reports whether the process is up, for Kubernetes'
+	// liveness probe - it never checks dependencies, so a slow Redis or
+	// MinIO doesn't get the pod killed and restarted.
+	router.GET("/health/live", func(c *gin.Context) {
+		c.JSON(200, gin.H{"status": "live"})
+	})
+
+	// /health/ready additionally checks the cache and storage backends,
+	// for Kubernetes' readiness probe - a pod that can't reach its
+	// dependencies should stop receiving traffic without being restarted.
+	router.GET("/health/ready", func(c *gin.Context) {
+		if cacheService == nil || storageService == nil {
+			c.JSON(503, gin.H{"status": "not ready", "cache": cacheService != nil, "storage": storageService != nil})
+			return
+		}
+		c.JSON(200, gin.H{"status": "ready"})
+	})
+
+	// API v1 endpoints
 	v1 := router.Group("/v1")
 	{
-		v1.GET("/data/ocean-currents", func(c *gin.Context) {
-			c.JSON(501, gin.H{"message": "Ocean currents data endpoint - not implemented yet"})
-		})
-		v1.GET("/data/wind", func(c *gin.Context) {
-			c.JSON(501, gin.H{"message": "Wind data endpoint - not implemented yet"})
-		})
-		v1.GET("/data/waves", func(c *gin.Context) {
-			c.JSON(501, gin.H{"message": "Wave data endpoint - not implemented yet"})
-		})
+		if dataHandler != nil {
+			v1.GET("/data/ocean-currents", dataHandler.GetOceanCurrents)
+			v1.GET("/data/wind", dataHandler.GetWind)
+			v1.GET("/data/waves", dataHandler.GetWaves)
+			v1.GET("/data/ocean-currents/stream", dataHandler.StreamOceanCurrents)
+			v1.GET("/data/wind/stream", dataHandler.StreamWind)
+			v1.GET("/data/waves/stream", dataHandler.StreamWaves)
+			v1.GET("/data/ocean-currents/events", dataHandler.SubscribeOceanCurrents)
+			v1.GET("/data/wind/events", dataHandler.SubscribeWind)
+			v1.GET("/data/waves/events", dataHandler.SubscribeWaves)
+		} else {
+			unavailable := func(c *gin.Context) {
+				c.JSON(503, gin.H{"error": "Service unavailable - cache or storage not initialized"})
+			}
+			v1.GET("/data/ocean-currents", unavailable)
+			v1.GET("/data/wind", unavailable)
+			v1.GET("/data/waves", unavailable)
+			v1.GET("/data/ocean-currents/stream", unavailable)
+			v1.GET("/data/wind/stream", unavailable)
+			v1.GET("/data/waves/stream", unavailable)
+			v1.GET("/data/ocean-currents/events", unavailable)
+			v1.GET("/data/wind/events", unavailable)
+			v1.GET("/data/waves/events", unavailable)
+		}
+
+		// Admin endpoints. TODO: gate behind the same JWT/API-key auth the
+		// api-gateway uses once that middleware lands here too.
+		admin := v1.Group("/cache")
+		{
+			if cacheHandler != nil {
+				admin.POST("/invalidate", cacheHandler.Invalidate)
+				admin.POST("/invalidate-data-type", cacheHandler.InvalidateDataType)
+			} else {
+				unavailableCache := func(c *gin.Context) {
+					c.JSON(503, gin.H{"error": "Service unavailable - cache or storage not initialized"})
+				}
+				admin.POST("/invalidate", unavailableCache)
+				admin.POST("/invalidate-data-type", unavailableCache)
+			}
+		}
+
+		// Async data-fetch jobs: enqueue a DataRequest and poll or stream its
+		// progress instead of blocking the request on a multi-GB download.
+		if jobHandler != nil {
+			v1.POST("/data/request", jobHandler.CreateJob)
+			v1.GET("/data/jobs/:id", jobHandler.GetJob)
+			v1.GET("/data/jobs/:id/stream", jobHandler.StreamJob)
+			v1.DELETE("/data/jobs/:id", jobHandler.CancelJob)
+		} else {
+			v1.POST("/data/request", func(c *gin.Context) {
+				c.JSON(503, gin.H{"error": "Service unavailable - job queue not initialized"})
+			})
+		}
 	}
 
 	log.Printf("Starting Data Service on port %s", port)
@@ -41,3 +230,17 @@ func main() {
 		log.Fatal("Failed to start server:", err)
 	}
 }
+
+// intEnv reads an integer from the named environment variable, falling back
+// to def if it's unset or not a valid integer.
+func intEnv(name string, def int) int {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return def
+	}
+	return n
+}
@@ -0,0 +1,138 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/eysteinn/driftline/services/data-service/internal/observability"
+	"github.com/redis/rueidis"
+)
+
+// ClientSideConfig configures a ClientSideService.
+type ClientSideConfig struct {
+	// LocalTTL bounds how long rueidis may serve a tracked key out of its
+	// client-side cache before it must revalidate against Redis,
+	// independent of whether the server has pushed an invalidation for it
+	// - a backstop against a dropped connection losing an invalidation
+	// message in flight.
+	LocalTTL time.Duration
+}
+
+// ClientSideService layers RESP3 client-side caching in front of Redis,
+// for environmental-data reads whose bbox/time range is repeated by many
+// concurrent requests within a short window - serving those out of
+// process memory avoids a Redis round-trip entirely.
+//
+// This wraps a rueidis client directly rather than sitting on top of
+// Service's go-redis/v8 connection: rueidis opts a key into server-side
+// tracking the first time it's read via DoCache, and the Redis server
+// pushes an invalidation over that same connection the moment any client
+// modifies the key, which rueidis uses to drop its local copy
+// immediately. That's a stronger guarantee than the Pub/Sub channel a
+// hand-rolled approximation would need: every write anywhere invalidates
+// every tracking client's copy, not just the ones this package's own
+// Set/Delete happen to publish to, and there's no window between a write
+// committing and an invalidation message being published for it.
+type ClientSideService struct {
+	client rueidis.Client
+	cfg    ClientSideConfig
+}
+
+// NewClientSideService dials addr with RESP3 client-side caching enabled
+// and wraps it, configured by cfg.
+func NewClientSideService(addr string, cfg ClientSideConfig) (*ClientSideService, error) {
+	client, err := rueidis.NewClient(rueidis.ClientOption{
+		InitAddress: []string{addr},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+	return &ClientSideService{client: client, cfg: cfg}, nil
+}
+
+// Close releases the underlying connection pool.
+func (s *ClientSideService) Close() error {
+	s.client.Close()
+	return nil
+}
+
+// Get serves key through rueidis's client-side cache: a prior read within
+// LocalTTL that Redis hasn't since pushed an invalidation for is returned
+// straight from process memory; otherwise this blocks on a real GET and
+// Redis opts key into tracking for next time.
+func (s *ClientSideService) Get(ctx context.Context, key string) (string, error) {
+	resp := s.client.DoCache(ctx, s.client.B().Get().Key(key).Cache(), s.cfg.LocalTTL)
+	val, err := resp.ToString()
+	if rueidis.IsRedisNil(err) {
+		observability.ClientSideCacheOperationsTotal.WithLabelValues("miss").Inc()
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if resp.IsCacheHit() {
+		observability.ClientSideCacheOperationsTotal.WithLabelValues("hit").Inc()
+	} else {
+		observability.ClientSideCacheOperationsTotal.WithLabelValues("miss").Inc()
+	}
+	return val, nil
+}
+
+// Set writes key through to Redis. Nothing here needs to publish an
+// invalidation - rueidis's tracking readers, including this process's
+// own, are invalidated by the server the moment the write lands.
+func (s *ClientSideService) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	data, err := encodeCacheValue(value)
+	if err != nil {
+		return err
+	}
+	cmd := s.client.B().Set().Key(key).Value(rueidis.BinaryString(data))
+	if ttl > 0 {
+		return s.client.Do(ctx, cmd.Ex(ttl).Build()).Error()
+	}
+	return s.client.Do(ctx, cmd.Build()).Error()
+}
+
+// Delete removes key from Redis, which invalidates every tracking
+// client's cached copy of it, this one included.
+func (s *ClientSideService) Delete(ctx context.Context, key string) error {
+	return s.client.Do(ctx, s.client.B().Del().Key(key).Build()).Error()
+}
+
+// Exists checks whether key currently exists in Redis. This always hits
+// Redis rather than consulting the local cache, since EXISTS isn't a
+// trackable read the way GET is.
+func (s *ClientSideService) Exists(ctx context.Context, key string) (bool, error) {
+	n, err := s.client.Do(ctx, s.client.B().Exists().Key(key).Build()).ToInt64()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// InvalidateTags drops every key associated with any of tags, the same
+// way Service.InvalidateTags does, reading tag membership from the sets
+// SetWithTags populates. Deleting those keys invalidates every tracking
+// client's cached copy of them, this one included.
+func (s *ClientSideService) InvalidateTags(ctx context.Context, tags ...string) error {
+	for _, tag := range tags {
+		setKey := tagSetKey(tag)
+		keys, err := s.client.Do(ctx, s.client.B().Smembers().Key(setKey).Build()).AsStrSlice()
+		if err != nil {
+			return fmt.Errorf("failed to read tag set %s: %w", tag, err)
+		}
+		if len(keys) == 0 {
+			continue
+		}
+
+		del := s.client.B().Del().Key(keys...).Build()
+		dropSet := s.client.B().Del().Key(setKey).Build()
+		for _, resp := range s.client.DoMulti(ctx, del, dropSet) {
+			if err := resp.Error(); err != nil {
+				return fmt.Errorf("failed to invalidate tag %s: %w", tag, err)
+			}
+		}
+	}
+	return nil
+}
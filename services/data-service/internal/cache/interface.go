@@ -0,0 +1,27 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Cache is the read/write surface callers depend on, so a handler or
+// DataService can take a Cache and stay agnostic to whether it's talking
+// to Redis directly (Service), Redis plus a local layer
+// (ClientSideService), a Redis-free in-process cache (MemoryCache), or an
+// L1/L2 combination of the two (TieredCache).
+type Cache interface {
+	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+	Exists(ctx context.Context, key string) (bool, error)
+	InvalidateTags(ctx context.Context, tags ...string) error
+	Close() error
+}
+
+var (
+	_ Cache = (*Service)(nil)
+	_ Cache = (*ClientSideService)(nil)
+	_ Cache = (*MemoryCache)(nil)
+	_ Cache = (*TieredCache)(nil)
+)
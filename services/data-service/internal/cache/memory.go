@@ -0,0 +1,164 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+// MemoryCache is an in-process Cache backend for deployments without
+// Redis - local dev, or a single-node deployment where a network hop to a
+// separate cache isn't worth it. It's an LRU of maxEntries, each value
+// expiring independently after its own TTL, built on
+// hashicorp/golang-lru/v2 for the eviction bookkeeping; MemoryCache itself
+// only adds the per-entry TTL and the tag index InvalidateTags reads.
+type MemoryCache struct {
+	mu       sync.Mutex
+	cache    *lru.Cache[string, *memoryEntry]
+	tagIndex map[string]map[string]struct{} // tag -> set of keys
+}
+
+type memoryEntry struct {
+	value     string
+	expiresAt time.Time
+	tags      []string
+}
+
+// NewMemoryCache creates an in-process cache holding at most maxEntries
+// values, evicting the least recently used once full. maxEntries must be
+// positive, per golang-lru/v2.New's own contract.
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	m := &MemoryCache{
+		tagIndex: make(map[string]map[string]struct{}),
+	}
+	// onEvicted fires synchronously inside Add/Remove, while m.mu is
+	// already held by this MemoryCache's own caller, so it touches
+	// tagIndex directly rather than taking m.mu itself. It's what keeps
+	// tagIndex in sync when the LRU evicts a key on its own, on overflow,
+	// rather than via an explicit Delete/InvalidateTags.
+	cache, err := lru.NewWithEvict[string, *memoryEntry](maxEntries, func(key string, entry *memoryEntry) {
+		m.untagLocked(key, entry)
+	})
+	if err != nil {
+		// Only possible when maxEntries <= 0.
+		panic(err)
+	}
+	m.cache = cache
+	return m
+}
+
+// Get returns key's value, or "" if it's absent or expired.
+func (m *MemoryCache) Get(ctx context.Context, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.cache.Get(key)
+	if !ok {
+		return "", nil
+	}
+	if time.Now().After(entry.expiresAt) {
+		m.removeLocked(key)
+		return "", nil
+	}
+	return entry.value, nil
+}
+
+// Set stores value under key with ttl (0 means never expire).
+func (m *MemoryCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	return m.SetWithTags(ctx, key, value, ttl)
+}
+
+// SetWithTags is Set, additionally recording key against each of tags so a
+// later InvalidateTags call can drop it without the caller enumerating
+// keys.
+func (m *MemoryCache) SetWithTags(ctx context.Context, key string, value interface{}, ttl time.Duration, tags ...string) error {
+	data, err := encodeCacheValue(value)
+	if err != nil {
+		return err
+	}
+
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	} else {
+		expiresAt = time.Now().Add(100 * 365 * 24 * time.Hour)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if old, ok := m.cache.Peek(key); ok {
+		m.untagLocked(key, old)
+	}
+
+	entry := &memoryEntry{value: string(data), expiresAt: expiresAt, tags: tags}
+	m.cache.Add(key, entry)
+	for _, tag := range tags {
+		if m.tagIndex[tag] == nil {
+			m.tagIndex[tag] = make(map[string]struct{})
+		}
+		m.tagIndex[tag][key] = struct{}{}
+	}
+
+	return nil
+}
+
+// InvalidateTags drops every key associated with any of tags, mirroring
+// Service.InvalidateTags. A tag with no members is left untouched.
+func (m *MemoryCache) InvalidateTags(ctx context.Context, tags ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, tag := range tags {
+		for key := range m.tagIndex[tag] {
+			m.removeLocked(key)
+		}
+		delete(m.tagIndex, tag)
+	}
+	return nil
+}
+
+// Delete removes key, if present.
+func (m *MemoryCache) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.removeLocked(key)
+	return nil
+}
+
+// Exists reports whether key is present and unexpired.
+func (m *MemoryCache) Exists(ctx context.Context, key string) (bool, error) {
+	val, err := m.Get(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	return val != "", nil
+}
+
+// Close is a no-op; MemoryCache owns no external resources.
+func (m *MemoryCache) Close() error {
+	return nil
+}
+
+// removeLocked removes key from the LRU, if present, which also untags it
+// via the onEvicted callback NewMemoryCache registers. Callers must hold
+// m.mu.
+func (m *MemoryCache) removeLocked(key string) {
+	m.cache.Remove(key)
+}
+
+// untagLocked drops key out of every tag set entry.tags put it in.
+// Callers must hold m.mu.
+func (m *MemoryCache) untagLocked(key string, entry *memoryEntry) {
+	for _, tag := range entry.tags {
+		if set, ok := m.tagIndex[tag]; ok {
+			delete(set, key)
+			if len(set) == 0 {
+				delete(m.tagIndex, tag)
+			}
+		}
+	}
+}
@@ -13,29 +13,48 @@ import (
 type Service struct {
 	client *redis.Client
 	ttl    time.Duration
+	codec  Codec
+	sf     singleflightGroup
+}
+
+// ServiceOption is a functional option for Service.
+type ServiceOption func(*Service)
+
+// WithCodec overrides the Codec GetInto/GetOrLoad use to marshal and
+// unmarshal values, in place of the JSON default.
+func WithCodec(codec Codec) ServiceOption {
+	return func(s *Service) {
+		s.codec = codec
+	}
 }
 
 // NewService creates a new cache service
-func NewService(redisURL string, ttl time.Duration) (*Service, error) {
-	opts, err := redis.ParseURL(redisURL)
+func NewService(redisURL string, ttl time.Duration, opts ...ServiceOption) (*Service, error) {
+	redisOpts, err := redis.ParseURL(redisURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse redis URL: %w", err)
 	}
 
-	client := redis.NewClient(opts)
-	
+	client := redis.NewClient(redisOpts)
+
 	// Test connection
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	
+
 	if err := client.Ping(ctx).Err(); err != nil {
 		return nil, fmt.Errorf("failed to connect to redis: %w", err)
 	}
 
-	return &Service{
+	s := &Service{
 		client: client,
 		ttl:    ttl,
-	}, nil
+		codec:  jsonCodec{},
+		sf:     newSingleflightGroup(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
 }
 
 // Get retrieves a value from cache
@@ -52,26 +71,87 @@ func (s *Service) Get(ctx context.Context, key string) (string, error) {
 
 // Set stores a value in cache with TTL
 func (s *Service) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	data, err := encodeCacheValue(value)
+	if err != nil {
+		return err
+	}
+	if ttl == 0 {
+		ttl = s.ttl
+	}
+	return s.client.Set(ctx, key, data, ttl).Err()
+}
+
+// SetWithTags is Set, additionally associating key with one or more tags
+// (e.g. "data:sst", "dataset:noaa-v2") so InvalidateTags can later drop
+// every key sharing a tag in one call, without the caller needing to know
+// their exact keys - the case a content-addressed cache key makes
+// impossible for a plain Delete. The Set and each tag's set membership are
+// written in one MULTI/EXEC pipeline so a crash between them never leaves a
+// tag pointing at a key that was never written.
+func (s *Service) SetWithTags(ctx context.Context, key string, value interface{}, ttl time.Duration, tags ...string) error {
+	data, err := encodeCacheValue(value)
+	if err != nil {
+		return err
+	}
 	if ttl == 0 {
 		ttl = s.ttl
 	}
-	
-	var data []byte
-	var err error
-	
+
+	pipe := s.client.TxPipeline()
+	pipe.Set(ctx, key, data, ttl)
+	for _, tag := range tags {
+		pipe.SAdd(ctx, tagSetKey(tag), key)
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// InvalidateTags drops every key associated with any of tags (as recorded
+// by SetWithTags), along with the tags' own membership sets. A tag with no
+// members is left untouched rather than erroring, since that's simply the
+// case of nothing currently cached under it.
+func (s *Service) InvalidateTags(ctx context.Context, tags ...string) error {
+	for _, tag := range tags {
+		setKey := tagSetKey(tag)
+		keys, err := s.client.SMembers(ctx, setKey).Result()
+		if err != nil {
+			return fmt.Errorf("failed to read tag set %s: %w", tag, err)
+		}
+		if len(keys) == 0 {
+			continue
+		}
+
+		pipe := s.client.TxPipeline()
+		pipe.Del(ctx, keys...)
+		pipe.Del(ctx, setKey)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return fmt.Errorf("failed to invalidate tag %s: %w", tag, err)
+		}
+	}
+	return nil
+}
+
+// tagSetKey returns the Redis key of the Set tracking which cache keys are
+// tagged with tag.
+func tagSetKey(tag string) string {
+	return "tag:" + tag
+}
+
+// encodeCacheValue converts value to the bytes Set/SetWithTags actually
+// store, matching Get's expectation of a plain string/JSON payload.
+func encodeCacheValue(value interface{}) ([]byte, error) {
 	switch v := value.(type) {
 	case string:
-		data = []byte(v)
+		return []byte(v), nil
 	case []byte:
-		data = v
+		return v, nil
 	default:
-		data, err = json.Marshal(value)
+		data, err := json.Marshal(value)
 		if err != nil {
-			return fmt.Errorf("failed to marshal value: %w", err)
+			return nil, fmt.Errorf("failed to marshal value: %w", err)
 		}
+		return data, nil
 	}
-	
-	return s.client.Set(ctx, key, data, ttl).Err()
 }
 
 // Delete removes a value from cache
@@ -97,3 +177,11 @@ func (s *Service) Close() error {
 func GenerateDataCacheKey(dataType, bounds, timeRange string) string {
 	return fmt.Sprintf("data:%s:%s:%s", dataType, bounds, timeRange)
 }
+
+// GenerateDataCacheTags returns the tags a cache key built from the same
+// dataType should be stored under via SetWithTags, so every bbox/time slice
+// cached for a dataset can be invalidated in one InvalidateTags call
+// without enumerating their (content-addressed) keys.
+func GenerateDataCacheTags(dataType string) []string {
+	return []string{"data:" + dataType}
+}
@@ -0,0 +1,151 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// tieredInvalidationChannel is the Redis Pub/Sub channel TieredCache
+// instances publish Delete/InvalidateTags notifications on, so every
+// node's L1 stays coherent with whichever node made the write - L1 is
+// per-process, so a local Delete on one node is otherwise invisible to the
+// others.
+const tieredInvalidationChannel = "cache:tiered:invalidate"
+
+// tieredInvalidationMessage is published on tieredInvalidationChannel.
+// Exactly one of Key or Tags is set, matching whether the triggering call
+// was Delete or InvalidateTags.
+type tieredInvalidationMessage struct {
+	Key  string   `json:"key,omitempty"`
+	Tags []string `json:"tags,omitempty"`
+}
+
+// TieredCache checks L1 (an in-process MemoryCache) before L2 (a Redis
+// Service), and writes through both on Set/SetWithTags. L1 gives
+// sub-microsecond hits for hot keys; L2 gives a cluster-shared cache so a
+// cold L1 (e.g. right after a node restarts) still hits instead of falling
+// through to the origin.
+type TieredCache struct {
+	l1 *MemoryCache
+	l2 *Service
+
+	cancel context.CancelFunc
+}
+
+// NewTieredCache wraps l1 and l2 into one Cache and starts the background
+// subscription that keeps every TieredCache's L1 coherent with Delete/
+// InvalidateTags calls made anywhere in the cluster. Call Close to stop it;
+// l2's own Redis connection is closed separately, by its own Close.
+func NewTieredCache(l1 *MemoryCache, l2 *Service) *TieredCache {
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &TieredCache{l1: l1, l2: l2, cancel: cancel}
+	go t.watchInvalidations(ctx)
+	return t
+}
+
+// Close stops the background invalidation subscription.
+func (t *TieredCache) Close() error {
+	t.cancel()
+	return nil
+}
+
+func (t *TieredCache) watchInvalidations(ctx context.Context) {
+	sub := t.l2.client.Subscribe(ctx, tieredInvalidationChannel)
+	defer sub.Close()
+
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			var inv tieredInvalidationMessage
+			if err := json.Unmarshal([]byte(msg.Payload), &inv); err != nil {
+				continue
+			}
+			if inv.Key != "" {
+				t.l1.Delete(ctx, inv.Key)
+			}
+			if len(inv.Tags) > 0 {
+				t.l1.InvalidateTags(ctx, inv.Tags...)
+			}
+		}
+	}
+}
+
+func (t *TieredCache) publish(ctx context.Context, msg tieredInvalidationMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return t.l2.client.Publish(ctx, tieredInvalidationChannel, data).Err()
+}
+
+// Get checks L1 first, falling back to L2 and repopulating L1 on an L1
+// miss.
+func (t *TieredCache) Get(ctx context.Context, key string) (string, error) {
+	if val, err := t.l1.Get(ctx, key); err == nil && val != "" {
+		return val, nil
+	}
+
+	val, err := t.l2.Get(ctx, key)
+	if err != nil || val == "" {
+		return val, err
+	}
+	_ = t.l1.Set(ctx, key, val, 0)
+	return val, nil
+}
+
+// Set writes through to both L2 and L1.
+func (t *TieredCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	if err := t.l2.Set(ctx, key, value, ttl); err != nil {
+		return err
+	}
+	return t.l1.Set(ctx, key, value, ttl)
+}
+
+// SetWithTags is Set, additionally tagging key in both tiers so
+// InvalidateTags can later drop it in either.
+func (t *TieredCache) SetWithTags(ctx context.Context, key string, value interface{}, ttl time.Duration, tags ...string) error {
+	if err := t.l2.SetWithTags(ctx, key, value, ttl, tags...); err != nil {
+		return err
+	}
+	return t.l1.SetWithTags(ctx, key, value, ttl, tags...)
+}
+
+// Delete removes key from both tiers and broadcasts the eviction so every
+// other node's L1 drops it too.
+func (t *TieredCache) Delete(ctx context.Context, key string) error {
+	if err := t.l2.Delete(ctx, key); err != nil {
+		return err
+	}
+	if err := t.l1.Delete(ctx, key); err != nil {
+		return err
+	}
+	return t.publish(ctx, tieredInvalidationMessage{Key: key})
+}
+
+// Exists checks L1 first, falling back to L2.
+func (t *TieredCache) Exists(ctx context.Context, key string) (bool, error) {
+	if ok, err := t.l1.Exists(ctx, key); err == nil && ok {
+		return true, nil
+	}
+	return t.l2.Exists(ctx, key)
+}
+
+// InvalidateTags drops every key tagged with any of tags from L2 (the
+// cluster-wide source of truth for tag membership) and this node's L1,
+// then broadcasts tags so every other node's L1 does the same.
+func (t *TieredCache) InvalidateTags(ctx context.Context, tags ...string) error {
+	if err := t.l2.InvalidateTags(ctx, tags...); err != nil {
+		return err
+	}
+	if err := t.l1.InvalidateTags(ctx, tags...); err != nil {
+		return err
+	}
+	return t.publish(ctx, tieredInvalidationMessage{Tags: tags})
+}
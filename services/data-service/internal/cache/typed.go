@@ -0,0 +1,128 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Codec marshals and unmarshals the values GetInto/GetOrLoad store, in
+// place of the caller hand-rolling json.Marshal/Unmarshal around every
+// cache.Get/Set. A Service defaults to jsonCodec; pass WithCodec to
+// NewService for a different wire format (e.g. gob for types json can't
+// round-trip cleanly).
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// jsonCodec is the default Codec.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) { return json.Marshal(v) }
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+// GetInto looks up key and, on a hit, unmarshals it into dst (which must be
+// a pointer, as with json.Unmarshal) using the Service's Codec. The bool
+// return reports whether key was found, so callers can tell a cache miss
+// from a zero-value hit.
+func (s *Service) GetInto(ctx context.Context, key string, dst interface{}) (bool, error) {
+	val, err := s.Get(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	if val == "" {
+		return false, nil
+	}
+	if err := s.codec.Unmarshal([]byte(val), dst); err != nil {
+		return false, fmt.Errorf("failed to unmarshal cached value for key %s: %w", key, err)
+	}
+	return true, nil
+}
+
+// GetOrLoad looks up key into dst via GetInto and, on a miss, calls loader
+// to produce the value, caches it under key with ttl (0 uses the Service's
+// default), and unmarshals it into dst. Concurrent GetOrLoad calls for the
+// same key coalesce onto a single in-flight loader call, so a stampede of
+// handlers all missing the same "data:sst:bbox:range" entry only hits the
+// underlying data source once; the rest wait on that call's result.
+func (s *Service) GetOrLoad(ctx context.Context, key string, dst interface{}, ttl time.Duration, loader func(context.Context) (interface{}, error)) error {
+	found, err := s.GetInto(ctx, key, dst)
+	if err != nil {
+		return err
+	}
+	if found {
+		return nil
+	}
+
+	data, err := s.sf.do(key, func() ([]byte, error) {
+		val, err := loader(ctx)
+		if err != nil {
+			return nil, err
+		}
+		encoded, err := s.codec.Marshal(val)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal loaded value for key %s: %w", key, err)
+		}
+		loadTTL := ttl
+		if loadTTL == 0 {
+			loadTTL = s.ttl
+		}
+		if err := s.client.Set(ctx, key, encoded, loadTTL).Err(); err != nil {
+			return nil, fmt.Errorf("failed to cache loaded value for key %s: %w", key, err)
+		}
+		return encoded, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.codec.Unmarshal(data, dst)
+}
+
+// singleflightGroup coalesces concurrent do calls sharing the same key onto
+// one execution of fn, as golang.org/x/sync/singleflight.Group does - kept
+// as a small hand-rolled equivalent rather than adding that dependency.
+type singleflightGroup struct {
+	mu    *sync.Mutex
+	calls map[string]*singleflightCall
+}
+
+func newSingleflightGroup() singleflightGroup {
+	return singleflightGroup{
+		mu:    &sync.Mutex{},
+		calls: make(map[string]*singleflightCall),
+	}
+}
+
+type singleflightCall struct {
+	wg  sync.WaitGroup
+	val []byte
+	err error
+}
+
+func (g singleflightGroup) do(key string, fn func() ([]byte, error)) ([]byte, error) {
+	g.mu.Lock()
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		c.wg.Wait()
+		return c.val, c.err
+	}
+
+	c := new(singleflightCall)
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	c.val, c.err = fn()
+	c.wg.Done()
+
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+
+	return c.val, c.err
+}
@@ -0,0 +1,159 @@
+package clients
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuthProvider supplies the Authorization header value CopernicusClient
+// attaches to its FetchData/HealthCheck requests. token is the complete
+// header value, including scheme (e.g. "Basic ..." or "Bearer ..."), so
+// the client can set it directly without caring which auth mode produced
+// it. expiresAt is the zero time.Time if the token never expires.
+type AuthProvider interface {
+	Token(ctx context.Context) (token string, expiresAt time.Time, err error)
+}
+
+// RefreshableAuthProvider is an AuthProvider that caches a token and can be
+// told to drop it, so a client can force re-enrollment after a 401 instead
+// of waiting for the normal refresh window to kick in.
+type RefreshableAuthProvider interface {
+	AuthProvider
+	ForceRefresh()
+}
+
+// BasicAuthProvider implements AuthProvider over static HTTP Basic Auth
+// credentials - CopernicusClient's original auth mode, wrapped behind the
+// interface for callers that want to configure it explicitly via
+// WithAuthProvider instead of relying on the client's default.
+type BasicAuthProvider struct {
+	Username string
+	Password string
+}
+
+// NewBasicAuthProvider creates an AuthProvider for static credentials.
+func NewBasicAuthProvider(username, password string) *BasicAuthProvider {
+	return &BasicAuthProvider{Username: username, Password: password}
+}
+
+// Token returns the Basic Auth header value. It never expires - the
+// credentials are sent on every request, so there's nothing to refresh.
+func (p *BasicAuthProvider) Token(ctx context.Context) (string, time.Time, error) {
+	raw := p.Username + ":" + p.Password
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(raw)), time.Time{}, nil
+}
+
+// EnrollmentTokenProvider implements AuthProvider via the machine-enrollment
+// pattern used by API clients like CrowdSec's ApiClient: it exchanges
+// credentials for a bearer token once, by POSTing them to authEndpoint,
+// and reuses the cached token until it's within refreshWindow of expiring
+// (or has been force-refreshed), re-enrolling transparently when it is.
+type EnrollmentTokenProvider struct {
+	authEndpoint  string
+	username      string
+	password      string
+	httpClient    *http.Client
+	refreshWindow time.Duration
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// defaultRefreshWindow is how far ahead of expiry EnrollmentTokenProvider
+// re-enrolls rather than handing out a token that might lapse mid-request.
+const defaultRefreshWindow = 5 * time.Minute
+
+// NewEnrollmentTokenProvider creates an EnrollmentTokenProvider that
+// authenticates against authEndpoint. httpClient may be nil, in which case
+// a client with a short default timeout is used - enrollment is a single
+// small POST, not a multi-GB download.
+func NewEnrollmentTokenProvider(authEndpoint, username, password string, httpClient *http.Client) *EnrollmentTokenProvider {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &EnrollmentTokenProvider{
+		authEndpoint:  authEndpoint,
+		username:      username,
+		password:      password,
+		httpClient:    httpClient,
+		refreshWindow: defaultRefreshWindow,
+	}
+}
+
+// enrollmentResponse is the {token, expire} shape returned by authEndpoint,
+// with expire as an RFC3339 timestamp.
+type enrollmentResponse struct {
+	Token  string `json:"token"`
+	Expire string `json:"expire"`
+}
+
+// Token returns the cached bearer token, re-enrolling first if there is no
+// token yet or the cached one is within refreshWindow of expiring.
+func (p *EnrollmentTokenProvider) Token(ctx context.Context) (string, time.Time, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.token != "" && time.Until(p.expiresAt) > p.refreshWindow {
+		return "Bearer " + p.token, p.expiresAt, nil
+	}
+	return p.enrollLocked(ctx)
+}
+
+// ForceRefresh discards the cached token, so the next Token call re-enrolls
+// regardless of how much of its lifetime remains. Used when the server
+// returns 401 despite a cached token that looked fresh - it may have been
+// revoked or the two clocks disagree.
+func (p *EnrollmentTokenProvider) ForceRefresh() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.token = ""
+}
+
+// enrollLocked performs the enrollment POST and caches the result. Callers
+// must hold p.mu.
+func (p *EnrollmentTokenProvider) enrollLocked(ctx context.Context) (string, time.Time, error) {
+	form := url.Values{}
+	form.Set("username", p.username)
+	form.Set("password", p.password)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.authEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to create enrollment request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("enrollment request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", time.Time{}, fmt.Errorf("enrollment failed with status %d", resp.StatusCode)
+	}
+
+	var body enrollmentResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to parse enrollment response: %w", err)
+	}
+	if body.Token == "" {
+		return "", time.Time{}, fmt.Errorf("enrollment response missing token")
+	}
+
+	expiresAt, err := time.Parse(time.RFC3339, body.Expire)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to parse enrollment expiry: %w", err)
+	}
+
+	p.token = body.Token
+	p.expiresAt = expiresAt
+	return "Bearer " + p.token, p.expiresAt, nil
+}
@@ -0,0 +1,290 @@
+package clients
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eysteinn/driftline/services/data-service/internal/observability"
+)
+
+// DefaultCacheTTL is how long a cached fetch is served before a client must
+// go back to the upstream source.
+const DefaultCacheTTL = 6 * time.Hour
+
+// CacheKey identifies a cached dataset fetch by the parameters that affect
+// its contents: dataset, bounding box, time window, and variable set.
+type CacheKey struct {
+	Dataset                        string
+	MinLat, MaxLat, MinLon, MaxLon float64
+	StartTime, EndTime             time.Time
+	Variables                      []string
+}
+
+// hash returns a canonical hex digest identifying key, stable across
+// process restarts and independent of Variables ordering.
+func (k CacheKey) hash() string {
+	vars := append([]string(nil), k.Variables...)
+	sort.Strings(vars)
+
+	canonical := fmt.Sprintf("%s|%.6f|%.6f|%.6f|%.6f|%s|%s|%s",
+		k.Dataset, k.MinLat, k.MaxLat, k.MinLon, k.MaxLon,
+		k.StartTime.UTC().Format(time.RFC3339), k.EndTime.UTC().Format(time.RFC3339),
+		strings.Join(vars, ","))
+
+	sum := sha256.Sum256([]byte(canonical))
+	return hex.EncodeToString(sum[:])
+}
+
+// coveredBy reports whether other is a cache entry whose bounds, time
+// window, and variables are a superset of k's - i.e. other can serve k's
+// request without a fresh fetch.
+func (k CacheKey) coveredBy(other CacheKey) bool {
+	if k.Dataset != other.Dataset {
+		return false
+	}
+	if other.MinLat > k.MinLat || other.MaxLat < k.MaxLat {
+		return false
+	}
+	if other.MinLon > k.MinLon || other.MaxLon < k.MaxLon {
+		return false
+	}
+	if other.StartTime.After(k.StartTime) || other.EndTime.Before(k.EndTime) {
+		return false
+	}
+	for _, v := range k.Variables {
+		if !containsStr(other.Variables, v) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsStr(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Cache abstracts caching of external dataset fetches so CopernicusClient
+// and NOAAClient can share one implementation instead of each hitting
+// upstream on every call with the same bounds.
+type Cache interface {
+	// Get returns a local file path serving key - either an exact match or
+	// a cached entry whose bounds are a superset of key's - downloading it
+	// to a fresh temp path. hit is false on a cache miss.
+	Get(ctx context.Context, key CacheKey) (path string, hit bool, err error)
+
+	// Put registers localPath as the cache entry for key, valid for ttl.
+	// The upload happens in the background; Put does not block on it.
+	Put(ctx context.Context, key CacheKey, localPath string, ttl time.Duration)
+}
+
+// objectStore is the subset of storage.Service's API that ObjectStoreCache
+// needs. It's declared narrowly here, rather than imported, so the clients
+// package doesn't have to depend on the storage package's concrete type -
+// any object store with these methods (storage.Service included) satisfies
+// it.
+type objectStore interface {
+	Upload(ctx context.Context, objectName, filePath string) error
+	Download(ctx context.Context, objectName, destPath string) error
+	Exists(ctx context.Context, objectName string) (bool, error)
+	List(ctx context.Context, prefix string) ([]string, error)
+	Delete(ctx context.Context, objectName string) error
+}
+
+// cacheEntry is the sidecar metadata object stored alongside each cached
+// NetCDF file, recording the key it was fetched for and its expiry.
+type cacheEntry struct {
+	Key       CacheKey  `json:"key"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+const cachePrefix = "cache"
+
+func dataObjectName(dataset, hash string) string {
+	return fmt.Sprintf("%s/%s/%s.nc", cachePrefix, dataset, hash)
+}
+
+func metaObjectName(dataset, hash string) string {
+	return fmt.Sprintf("%s/%s/%s.json", cachePrefix, dataset, hash)
+}
+
+// ObjectStoreCache is a Cache backed by object storage (MinIO/S3): cached
+// files live at cache/<dataset>/<hash>.nc, next to a
+// cache/<dataset>/<hash>.json sidecar recording the key and expiry that
+// produced them.
+type ObjectStoreCache struct {
+	store objectStore
+}
+
+// NewObjectStoreCache creates a Cache backed by store.
+func NewObjectStoreCache(store objectStore) *ObjectStoreCache {
+	return &ObjectStoreCache{store: store}
+}
+
+// Get looks for an exact match first, then falls back to scanning the
+// dataset's other cached entries for one whose bounds are a superset of
+// key. The scan downloads each candidate's small metadata sidecar to
+// inspect it, which is fine for the handful of entries a single dataset
+// accumulates - a dataset with many thousands of cached windows would want
+// a real index instead of this linear scan.
+func (c *ObjectStoreCache) Get(ctx context.Context, key CacheKey) (string, bool, error) {
+	hash := key.hash()
+	if path, ok := c.tryServe(ctx, key.Dataset, hash); ok {
+		observability.CacheOperationsTotal.WithLabelValues(key.Dataset, "hit").Inc()
+		return path, true, nil
+	}
+
+	prefix := fmt.Sprintf("%s/%s/", cachePrefix, key.Dataset)
+	objects, err := c.store.List(ctx, prefix)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to list cache entries: %w", err)
+	}
+
+	for _, obj := range objects {
+		if !strings.HasSuffix(obj, ".json") {
+			continue
+		}
+		entry, err := c.readEntry(ctx, obj)
+		if err != nil || time.Now().After(entry.ExpiresAt) {
+			continue
+		}
+		if !key.coveredBy(entry.Key) {
+			continue
+		}
+		if path, ok := c.tryServe(ctx, key.Dataset, entry.Key.hash()); ok {
+			observability.CacheOperationsTotal.WithLabelValues(key.Dataset, "hit").Inc()
+			return path, true, nil
+		}
+	}
+
+	observability.CacheOperationsTotal.WithLabelValues(key.Dataset, "miss").Inc()
+	return "", false, nil
+}
+
+func (c *ObjectStoreCache) tryServe(ctx context.Context, dataset, hash string) (string, bool) {
+	objectName := dataObjectName(dataset, hash)
+	exists, err := c.store.Exists(ctx, objectName)
+	if err != nil || !exists {
+		return "", false
+	}
+
+	tmpPath, err := reservedTempPath("cache_*.nc")
+	if err != nil {
+		return "", false
+	}
+	if err := c.store.Download(ctx, objectName, tmpPath); err != nil {
+		os.Remove(tmpPath)
+		return "", false
+	}
+	return tmpPath, true
+}
+
+func (c *ObjectStoreCache) readEntry(ctx context.Context, objectName string) (*cacheEntry, error) {
+	tmpPath, err := reservedTempPath("cache_meta_*.json")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmpPath)
+
+	if err := c.store.Download(ctx, objectName, tmpPath); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(tmpPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// Put uploads localPath and a metadata sidecar for key in the background,
+// so the caller that just fetched localPath doesn't wait on the upload
+// before returning its result.
+func (c *ObjectStoreCache) Put(_ context.Context, key CacheKey, localPath string, ttl time.Duration) {
+	go func() {
+		uploadCtx := context.Background()
+		hash := key.hash()
+
+		if err := c.store.Upload(uploadCtx, dataObjectName(key.Dataset, hash), localPath); err != nil {
+			return
+		}
+
+		entry := cacheEntry{Key: key, ExpiresAt: time.Now().Add(ttl)}
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return
+		}
+
+		metaPath, err := reservedTempPath("cache_meta_*.json")
+		if err != nil {
+			return
+		}
+		defer os.Remove(metaPath)
+		if err := os.WriteFile(metaPath, data, 0644); err != nil {
+			return
+		}
+
+		c.store.Upload(uploadCtx, metaObjectName(key.Dataset, hash), metaPath)
+	}()
+}
+
+// reservedTempPath returns a unique filesystem path matching pattern
+// without leaving a file behind at that path - used ahead of
+// storage.Service.Download, which (like minio's FGetObject) refuses to
+// write to a path that already exists.
+func reservedTempPath(pattern string) (string, error) {
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", err
+	}
+	path := f.Name()
+	f.Close()
+	os.Remove(path)
+	return path, nil
+}
+
+// fetchWithCache checks cache for a hit before calling fetch, and
+// populates cache with the result on a miss without blocking on the
+// upload. fetch must write the full response to dest. cache may be nil, in
+// which case fetchWithCache always calls fetch.
+func fetchWithCache(ctx context.Context, cache Cache, ttl time.Duration, key CacheKey, writer NetCDFWriter, pattern string, fetch func(dest io.Writer) error) (string, error) {
+	if cache != nil {
+		if path, hit, err := cache.Get(ctx, key); err == nil && hit {
+			return path, nil
+		}
+	}
+
+	dest, tmpPath, err := writer.Create(pattern)
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer dest.Close()
+
+	if err := fetch(dest); err != nil {
+		os.Remove(tmpPath)
+		return "", err
+	}
+
+	if cache != nil {
+		cache.Put(ctx, key, tmpPath, ttl)
+	}
+
+	return tmpPath, nil
+}
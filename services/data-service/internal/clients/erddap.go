@@ -0,0 +1,139 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/eysteinn/driftline/services/data-service/internal/models"
+)
+
+// ERDDAPClient implements ExternalDataClient against an ERDDAP griddap
+// endpoint, requesting NetCDF subsets directly via the ".nc" response type.
+type ERDDAPClient struct {
+	endpoint   string // base ERDDAP server URL, e.g. https://coastwatch.pfeg.noaa.gov/erddap
+	datasetID  string
+	httpClient *http.Client
+	writer     NetCDFWriter
+}
+
+// NewERDDAPClient creates a new ERDDAP griddap client.
+func NewERDDAPClient(endpoint, datasetID string) *ERDDAPClient {
+	return &ERDDAPClient{
+		endpoint:   endpoint,
+		datasetID:  datasetID,
+		httpClient: &http.Client{Timeout: 5 * time.Minute},
+		writer:     fileNetCDFWriter{},
+	}
+}
+
+// buildGriddapURL constructs an ERDDAP griddap request, e.g.
+// "<endpoint>/griddap/<dataset>.nc?uo[(start):1:(end)][(minLat):1:(maxLat)][(minLon):1:(maxLon)]".
+// ERDDAP's griddap syntax addresses dimensions by coordinate value in
+// parentheses, so unlike NOMADS/THREDDS no index lookup is required.
+func (c *ERDDAPClient) buildGriddapURL(req *models.DataRequest) (string, error) {
+	if c.endpoint == "" || c.datasetID == "" {
+		return "", fmt.Errorf("ERDDAP endpoint or dataset not configured")
+	}
+
+	variables := req.Variables
+	if len(variables) == 0 {
+		variables = []string{"uo", "vo"}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s/griddap/%s.nc?", strings.TrimRight(c.endpoint, "/"), c.datasetID)
+	for i, v := range variables {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, "%s[(%s):1:(%s)][(%s):1:(%s)][(%s):1:(%s)]",
+			v,
+			req.StartTime.Format("2006-01-02T15:04:05Z"), req.EndTime.Format("2006-01-02T15:04:05Z"),
+			formatFloat(req.MinLat), formatFloat(req.MaxLat),
+			formatFloat(req.MinLon), formatFloat(req.MaxLon),
+		)
+	}
+
+	return b.String(), nil
+}
+
+// FetchData fetches a griddap NetCDF subset from ERDDAP.
+func (c *ERDDAPClient) FetchData(ctx context.Context, req *models.DataRequest) (string, error) {
+	if err := req.Validate(); err != nil {
+		return "", fmt.Errorf("invalid request: %w", err)
+	}
+
+	requestURL, err := c.buildGriddapURL(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to build griddap URL: %w", err)
+	}
+
+	dest, tmpPath, err := c.writer.Create("erddap_*.nc")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer dest.Close()
+
+	if err := c.download(ctx, requestURL, dest); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("failed to download ERDDAP data: %w", err)
+	}
+
+	return tmpPath, nil
+}
+
+func (c *ERDDAPClient) download(ctx context.Context, requestURL string, dest io.Writer) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodySnippet := make([]byte, 512)
+		n, _ := io.ReadFull(resp.Body, bodySnippet)
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(bodySnippet[:n]))
+	}
+
+	if _, err := io.Copy(dest, resp.Body); err != nil {
+		return fmt.Errorf("failed to write response: %w", err)
+	}
+
+	return nil
+}
+
+// HealthCheck probes the ERDDAP dataset's metadata (.das) document.
+func (c *ERDDAPClient) HealthCheck(ctx context.Context) error {
+	if c.endpoint == "" || c.datasetID == "" {
+		return fmt.Errorf("ERDDAP endpoint or dataset not configured")
+	}
+
+	u := fmt.Sprintf("%s/griddap/%s.das", strings.TrimRight(c.endpoint, "/"), c.datasetID)
+	req, err := http.NewRequestWithContext(ctx, "HEAD", u, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create health check request: %w", err)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("health check request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("health check failed with status: %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -6,21 +6,38 @@ import (
 	"io"
 	"math"
 	"net/http"
+	"net/http/cookiejar"
 	"net/url"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/eysteinn/driftline/services/data-service/internal/models"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("driftline-data-service/clients")
+
 // ExternalDataClient represents a client for fetching data from external sources
 type ExternalDataClient interface {
 	FetchData(ctx context.Context, req *models.DataRequest) (string, error)
 	HealthCheck(ctx context.Context) error
 }
 
+// ProgressReportingClient is an optional capability an ExternalDataClient can
+// implement for callers that want live byte-level progress instead of
+// waiting for FetchData to return. Callers type-assert for it rather than
+// it being part of ExternalDataClient itself, since most providers (NOAA,
+// HYCOMClient, ...) have no reason to support it yet.
+type ProgressReportingClient interface {
+	FetchDataWithProgress(ctx context.Context, req *models.DataRequest, progress ProgressFunc) (string, error)
+}
+
 // CopernicusConfig holds configuration for Copernicus Marine data requests
 type CopernicusConfig struct {
 	// Dataset and service identifiers for Motu API
@@ -33,11 +50,24 @@ type CopernicusConfig struct {
 
 // CopernicusClient implements ExternalDataClient for Copernicus Marine Service
 type CopernicusClient struct {
-	endpoint   string
-	username   string
-	password   string
-	httpClient *http.Client
-	config     CopernicusConfig
+	endpoint    string
+	username    string
+	password    string
+	httpClient  *http.Client
+	config      CopernicusConfig
+	casEndpoint string // when set, FetchData authenticates via CAS before downloading
+	cache       Cache
+	cacheTTL    time.Duration
+
+	// authProvider, when set, supplies the Authorization header attached to
+	// every request instead of the default inline Basic Auth. This is how
+	// token-based enrollment auth (WithEnrollmentAuth) opts in without
+	// changing behavior for existing callers that configure neither it nor
+	// WithAuthProvider.
+	authProvider AuthProvider
+
+	retryPolicy RetryPolicy
+	breaker     *circuitBreaker
 }
 
 // CopernicusClientOption is a functional option for CopernicusClient
@@ -57,6 +87,58 @@ func WithConfig(config CopernicusConfig) CopernicusClientOption {
 	}
 }
 
+// WithCASEndpoint enables the CMEMS CAS/cookie authentication flow: before
+// the first download, the client POSTs credentials to casEndpoint and reuses
+// the resulting session cookie (via the HTTP client's cookie jar) for
+// subsequent requests instead of sending Basic Auth on every call.
+func WithCASEndpoint(casEndpoint string) CopernicusClientOption {
+	return func(c *CopernicusClient) {
+		c.casEndpoint = casEndpoint
+	}
+}
+
+// WithAuthProvider replaces the client's default inline Basic Auth with
+// provider: every FetchData/HealthCheck request attaches whatever
+// Authorization header provider.Token returns instead. Mutually exclusive
+// with WithCASEndpoint - if both are set, the CAS cookie flow takes over
+// authentication and provider is never consulted.
+func WithAuthProvider(provider AuthProvider) CopernicusClientOption {
+	return func(c *CopernicusClient) {
+		c.authProvider = provider
+	}
+}
+
+// WithEnrollmentAuth is a convenience over WithAuthProvider: it builds an
+// EnrollmentTokenProvider against authEndpoint using the client's
+// configured username/password as enrollment credentials and its HTTP
+// client for the enrollment request. Apply WithHTTPClient before this
+// option if you need the enrollment POST to use a non-default client.
+func WithEnrollmentAuth(authEndpoint string) CopernicusClientOption {
+	return func(c *CopernicusClient) {
+		c.authProvider = NewEnrollmentTokenProvider(authEndpoint, c.username, c.password, c.httpClient)
+	}
+}
+
+// WithRetryPolicy overrides the client's retry/backoff behavior (default
+// defaultRetryPolicy). See RetryPolicy for how MaxAttempts, BaseDelay,
+// MaxDelay, Multiplier, and JitterFraction combine.
+func WithRetryPolicy(policy RetryPolicy) CopernicusClientOption {
+	return func(c *CopernicusClient) {
+		c.retryPolicy = policy
+	}
+}
+
+// WithCache enables caching of fetched NetCDF files via cache, so a repeat
+// request for the same (or a narrower) bounding box, time window, and
+// variable set is served from cache instead of re-downloading from CMEMS.
+// Each cached entry is valid for ttl.
+func WithCache(cache Cache, ttl time.Duration) CopernicusClientOption {
+	return func(c *CopernicusClient) {
+		c.cache = cache
+		c.cacheTTL = ttl
+	}
+}
+
 // NewCopernicusClient creates a new Copernicus Marine client
 func NewCopernicusClient(endpoint, username, password string, opts ...CopernicusClientOption) *CopernicusClient {
 	client := &CopernicusClient{
@@ -72,45 +154,143 @@ func NewCopernicusClient(endpoint, username, password string, opts ...Copernicus
 			ProductID: "global-analysis-forecast-phy-001-024",
 			Variables: []string{"uo", "vo"}, // eastward and northward velocities
 		},
+		retryPolicy: defaultRetryPolicy,
+		breaker:     newCircuitBreaker(),
 	}
-	
+
 	for _, opt := range opts {
 		opt(client)
 	}
-	
+
 	return client
 }
 
 // FetchData fetches ocean current data from Copernicus Marine
 func (c *CopernicusClient) FetchData(ctx context.Context, req *models.DataRequest) (string, error) {
+	return c.fetchData(ctx, req, nil)
+}
+
+// FetchDataWithProgress is FetchData with live byte-level progress reports
+// as the download proceeds, for a caller that wants finer granularity than
+// waiting for the whole transfer to finish. It satisfies ProgressReportingClient.
+func (c *CopernicusClient) FetchDataWithProgress(ctx context.Context, req *models.DataRequest, progress ProgressFunc) (string, error) {
+	return c.fetchData(ctx, req, progress)
+}
+
+func (c *CopernicusClient) fetchData(ctx context.Context, req *models.DataRequest, progress ProgressFunc) (string, error) {
+	ctx, span := tracer.Start(ctx, "CopernicusClient.FetchData")
+	defer span.End()
+
 	// Validate request
 	if err := req.Validate(); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 		return "", fmt.Errorf("invalid request: %w", err)
 	}
-	
+
+	// Authenticate via CAS/cookie flow if configured; otherwise attachAuth
+	// attaches the AuthProvider's token or Basic Auth per request.
+	if c.casEndpoint != "" {
+		if err := c.authenticateCAS(ctx); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return "", fmt.Errorf("CAS authentication failed: %w", err)
+		}
+	}
+
 	// Build Motu API request URL
 	requestURL, err := c.buildMotuURL(req)
 	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 		return "", fmt.Errorf("failed to build Motu URL: %w", err)
 	}
-	
-	// Create temporary file for NetCDF data
-	tmpFile, err := os.CreateTemp("", "copernicus_*.nc")
+	span.SetAttributes(attribute.String("http.url", requestURL))
+
+	// Serve from cache if a suitable entry exists.
+	key := c.cacheKey(req)
+	if c.cache != nil {
+		if path, hit, err := c.cache.Get(ctx, key); err == nil && hit {
+			return path, nil
+		}
+	}
+
+	// finalPath is derived from the cache key rather than a random temp
+	// name so that downloadResumable's <finalPath>.part and .part.meta
+	// sidecar survive a failed attempt under a name the next retry of this
+	// same request can find again - a large CMEMS extraction that fails at
+	// 900MB resumes from there instead of restarting from byte zero.
+	finalPath := filepath.Join(os.TempDir(), fmt.Sprintf("copernicus_%s.nc", key.hash()))
+
+	if err := c.downloadResumable(ctx, requestURL, finalPath, progress); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return "", fmt.Errorf("failed to download data: %w", err)
+	}
+
+	if c.cache != nil {
+		c.cache.Put(ctx, key, finalPath, c.cacheTTL)
+	}
+
+	return finalPath, nil
+}
+
+// cacheKey builds the Cache lookup key for req, keyed by this client's
+// configured dataset so distinct Copernicus products don't collide.
+func (c *CopernicusClient) cacheKey(req *models.DataRequest) CacheKey {
+	variables := c.config.Variables
+	if len(req.Variables) > 0 {
+		variables = req.Variables
+	}
+	return CacheKey{
+		Dataset:   c.config.DatasetID,
+		MinLat:    req.MinLat,
+		MaxLat:    req.MaxLat,
+		MinLon:    req.MinLon,
+		MaxLon:    req.MaxLon,
+		StartTime: req.StartTime,
+		EndTime:   req.EndTime,
+		Variables: variables,
+	}
+}
+
+// authenticateCAS performs the CMEMS CAS login flow: it POSTs the client's
+// username/password to the CAS endpoint and relies on the HTTP client's
+// cookie jar to retain the resulting session cookie for later requests. If
+// the client has no cookie jar configured, one is installed on first use.
+func (c *CopernicusClient) authenticateCAS(ctx context.Context) error {
+	if c.httpClient.Jar == nil {
+		jar, err := cookiejar.New(nil)
+		if err != nil {
+			return fmt.Errorf("failed to create cookie jar: %w", err)
+		}
+		c.httpClient.Jar = jar
+	}
+
+	form := url.Values{}
+	form.Set("username", c.username)
+	form.Set("password", c.password)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.casEndpoint, strings.NewReader(form.Encode()))
 	if err != nil {
-		return "", fmt.Errorf("failed to create temp file: %w", err)
+		return fmt.Errorf("failed to create CAS request: %w", err)
 	}
-	defer tmpFile.Close()
-	
-	tmpPath := tmpFile.Name()
-	
-	// Download data with retries
-	err = c.downloadWithRetry(ctx, requestURL, tmpFile)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		os.Remove(tmpPath) // Clean up on error
-		return "", fmt.Errorf("failed to download data: %w", err)
+		return fmt.Errorf("CAS request failed: %w", err)
 	}
-	
-	return tmpPath, nil
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	// CAS typically redirects (and the client already followed it, setting
+	// cookies along the way); only treat hard client/server errors as fatal.
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("CAS login returned status %d", resp.StatusCode)
+	}
+
+	return nil
 }
 
 // buildMotuURL constructs the THREDDS NCSS API request URL
@@ -162,74 +342,92 @@ func (c *CopernicusClient) buildMotuURL(req *models.DataRequest) (string, error)
 	return u.String(), nil
 }
 
-// downloadWithRetry downloads data with exponential backoff retry logic
-func (c *CopernicusClient) downloadWithRetry(ctx context.Context, requestURL string, dest io.Writer) error {
-	maxRetries := 3
-	baseDelay := 1 * time.Second
-	
+// retryLoop runs attemptFn under c.breaker and c.retryPolicy's
+// decorrelated-jitter backoff: a retryable failure waits out the computed
+// (or server-supplied Retry-After) delay and tries again, a 401 forces one
+// re-enrollment before giving up, and any other non-retryable error or
+// context cancellation returns immediately. downloadResumable drives its
+// HTTP attempts through this loop, same as the rest of the client's
+// retry/breaker behavior.
+func (c *CopernicusClient) retryLoop(ctx context.Context, attemptFn func(ctx context.Context, attempt int) (int, time.Duration, error)) error {
+	if err := c.breaker.allow(); err != nil {
+		return err
+	}
+
+	refreshedAuth := false
+	rng := newCallRNG()
+
 	var lastErr error
-	for attempt := 0; attempt <= maxRetries; attempt++ {
+	var delay time.Duration
+	var retryAfterOverride time.Duration
+	for attempt := 0; attempt <= c.retryPolicy.MaxAttempts; attempt++ {
 		if attempt > 0 {
-			// Exponential backoff
-			delay := time.Duration(math.Pow(2, float64(attempt-1))) * baseDelay
+			wait := retryAfterOverride
+			if wait == 0 {
+				delay = c.retryPolicy.nextDelay(delay, rng)
+				wait = delay
+			}
 			select {
-			case <-time.After(delay):
+			case <-time.After(wait):
 			case <-ctx.Done():
+				c.breaker.recordFailure()
 				return ctx.Err()
 			}
 		}
-		
-		err := c.downloadData(ctx, requestURL, dest)
+
+		statusCode, retryAfter, err := attemptFn(ctx, attempt)
 		if err == nil {
+			c.breaker.recordSuccess()
 			return nil
 		}
-		
+
 		lastErr = err
-		
+		retryAfterOverride = retryAfter
+
 		// Don't retry on context cancellation or client errors (4xx)
 		if ctx.Err() != nil {
+			c.breaker.recordFailure()
 			return ctx.Err()
 		}
-		
+
+		// A 401 might just mean our cached token expired early or was
+		// revoked server-side, rather than that the credentials are bad.
+		// Force one re-enrollment and retry before giving up.
+		if statusCode == http.StatusUnauthorized && !refreshedAuth {
+			if refreshable, ok := c.authProvider.(RefreshableAuthProvider); ok {
+				refreshable.ForceRefresh()
+				refreshedAuth = true
+				continue
+			}
+		}
+
 		// Check if error is retryable (5xx or network errors)
 		if !isRetryableError(err) {
+			c.breaker.recordFailure()
 			return err
 		}
 	}
-	
-	return fmt.Errorf("failed after %d retries: %w", maxRetries, lastErr)
+
+	c.breaker.recordFailure()
+	return fmt.Errorf("failed after %d retries: %w", c.retryPolicy.MaxAttempts, lastErr)
 }
 
-// downloadData performs the actual HTTP request and streams response to file
-func (c *CopernicusClient) downloadData(ctx context.Context, requestURL string, dest io.Writer) error {
-	req, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-	
-	// Set basic authentication
-	req.SetBasicAuth(c.username, c.password)
-	
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-	
-	// Check HTTP status
-	if resp.StatusCode != http.StatusOK {
-		// Read a snippet of the response body for error context
-		bodySnippet := make([]byte, 512)
-		n, _ := io.ReadFull(resp.Body, bodySnippet)
-		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(bodySnippet[:n]))
-	}
-	
-	// Stream response body to destination
-	_, err = io.Copy(dest, resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to write response: %w", err)
+// attachAuth authenticates req using whichever mechanism this client is
+// configured with, in precedence order: a CAS session cookie (already
+// carried by the client's cookie jar, so there's nothing to attach here),
+// an AuthProvider's bearer token, or inline Basic Auth.
+func (c *CopernicusClient) attachAuth(ctx context.Context, req *http.Request) error {
+	switch {
+	case c.casEndpoint != "":
+	case c.authProvider != nil:
+		token, _, err := c.authProvider.Token(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to obtain auth token: %w", err)
+		}
+		req.Header.Set("Authorization", token)
+	default:
+		req.SetBasicAuth(c.username, c.password)
 	}
-	
 	return nil
 }
 
@@ -297,9 +495,12 @@ func (c *CopernicusClient) HealthCheck(ctx context.Context) error {
 		return fmt.Errorf("failed to create health check request: %w", err)
 	}
 	
-	// Set basic authentication
-	req.SetBasicAuth(c.username, c.password)
-	
+	// Attach the same Authorization header FetchData's requests carry: none
+	// in CAS mode, otherwise the AuthProvider's token or Basic Auth.
+	if err := c.attachAuth(ctx, req); err != nil {
+		return err
+	}
+
 	// Use shorter timeout for health check
 	client := &http.Client{Timeout: 10 * time.Second}
 	resp, err := client.Do(req)
@@ -321,6 +522,18 @@ func (c *CopernicusClient) HealthCheck(ctx context.Context) error {
 type NOAAClient struct {
 	gfsEndpoint string
 	ww3Endpoint string
+	httpClient  *http.Client
+	writer      NetCDFWriter
+	cache       Cache
+	cacheTTL    time.Duration
+}
+
+// SetCache enables caching of fetched NOMADS subsets via cache, each valid
+// for ttl. NOAAClient has no functional-option constructor, so cache is
+// configured after construction instead.
+func (c *NOAAClient) SetCache(cache Cache, ttl time.Duration) {
+	c.cache = cache
+	c.cacheTTL = ttl
 }
 
 // NewNOAAClient creates a new NOAA client
@@ -331,32 +544,168 @@ func NewNOAAClient(gfsEndpoint, ww3Endpoint string) *NOAAClient {
 	if ww3Endpoint == "" {
 		ww3Endpoint = "https://nomads.ncep.noaa.gov/dods/wave/gfswave"
 	}
-	
+
 	return &NOAAClient{
 		gfsEndpoint: gfsEndpoint,
 		ww3Endpoint: ww3Endpoint,
+		httpClient:  &http.Client{Timeout: 5 * time.Minute},
+		writer:      fileNetCDFWriter{},
+	}
+}
+
+// buildNOMADSURL constructs an OPeNDAP NetCDF subsetting URL for the
+// given NOMADS dataset endpoint and variables, e.g.
+// "<endpoint>.nc?ugrd10m[0:hours][lat_start:lat_end][lon_start:lon_end]".
+// NOMADS grids are indexed, not coordinate-addressed, so bounds are mapped
+// onto the dataset's native 0.25 degree global grid (-90..90, 0..360).
+func buildNOMADSURL(endpoint string, req *models.DataRequest, variables []string) (string, error) {
+	if endpoint == "" {
+		return "", fmt.Errorf("endpoint not configured")
+	}
+
+	const gridRes = 0.25
+	latStart := int((req.MinLat + 90) / gridRes)
+	latEnd := int((req.MaxLat + 90) / gridRes)
+	lonStart := int(normalizeLon(req.MinLon) / gridRes)
+	lonEnd := int(normalizeLon(req.MaxLon) / gridRes)
+
+	hours := int(req.EndTime.Sub(req.StartTime).Hours())
+	if hours < 0 {
+		hours = 0
+	}
+
+	var b strings.Builder
+	b.WriteString(endpoint)
+	b.WriteString(".nc?")
+	for i, v := range variables {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, "%s[0:%d][%d:%d][%d:%d]", v, hours, latStart, latEnd, lonStart, lonEnd)
 	}
+
+	return b.String(), nil
+}
+
+// normalizeLon converts a -180..180 longitude to the 0..360 range used by
+// NOMADS grids.
+func normalizeLon(lon float64) float64 {
+	if lon < 0 {
+		return lon + 360
+	}
+	return lon
 }
 
 // FetchWindData fetches wind data from NOAA GFS
 func (c *NOAAClient) FetchWindData(ctx context.Context, req *models.DataRequest) (string, error) {
-	// TODO: Implement GFS wind data fetching
-	// This would involve:
-	// 1. Determining the appropriate GFS forecast run
-	// 2. Constructing OPeNDAP URL with subsetting parameters
-	// 3. Downloading wind U and V components
-	// 4. Converting to NetCDF format expected by OpenDrift
-	// 5. Returning the file path
-	
-	return "", fmt.Errorf("NOAA GFS wind data fetching not yet implemented")
+	if err := req.Validate(); err != nil {
+		return "", fmt.Errorf("invalid request: %w", err)
+	}
+
+	variables := []string{"ugrd10m", "vgrd10m"}
+	requestURL, err := buildNOMADSURL(c.gfsEndpoint, req, variables)
+	if err != nil {
+		return "", fmt.Errorf("failed to build NOMADS URL: %w", err)
+	}
+
+	key := CacheKey{
+		Dataset: "noaa-gfs", MinLat: req.MinLat, MaxLat: req.MaxLat, MinLon: req.MinLon, MaxLon: req.MaxLon,
+		StartTime: req.StartTime, EndTime: req.EndTime, Variables: variables,
+	}
+	tmpPath, err := fetchWithCache(ctx, c.cache, c.cacheTTL, key, c.writer, "noaa_gfs_*.nc", func(dest io.Writer) error {
+		return c.download(ctx, requestURL, dest)
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to download GFS wind data: %w", err)
+	}
+
+	return tmpPath, nil
 }
 
 // FetchWaveData fetches wave data from NOAA WaveWatch III
 func (c *NOAAClient) FetchWaveData(ctx context.Context, req *models.DataRequest) (string, error) {
-	// TODO: Implement WaveWatch III data fetching
-	// Similar process to wind data but for wave parameters
-	
-	return "", fmt.Errorf("NOAA WaveWatch III wave data fetching not yet implemented")
+	if err := req.Validate(); err != nil {
+		return "", fmt.Errorf("invalid request: %w", err)
+	}
+
+	variables := []string{"htsgwsfc", "perpwsfc"}
+	requestURL, err := buildNOMADSURL(c.ww3Endpoint, req, variables)
+	if err != nil {
+		return "", fmt.Errorf("failed to build NOMADS URL: %w", err)
+	}
+
+	key := CacheKey{
+		Dataset: "noaa-ww3", MinLat: req.MinLat, MaxLat: req.MaxLat, MinLon: req.MinLon, MaxLon: req.MaxLon,
+		StartTime: req.StartTime, EndTime: req.EndTime, Variables: variables,
+	}
+	tmpPath, err := fetchWithCache(ctx, c.cache, c.cacheTTL, key, c.writer, "noaa_ww3_*.nc", func(dest io.Writer) error {
+		return c.download(ctx, requestURL, dest)
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to download WaveWatch III data: %w", err)
+	}
+
+	return tmpPath, nil
+}
+
+// download performs an unauthenticated GET against a NOMADS OPeNDAP URL and
+// retries transient failures, honoring context cancellation throughout.
+func (c *NOAAClient) download(ctx context.Context, requestURL string, dest io.Writer) error {
+	maxRetries := 3
+	baseDelay := 1 * time.Second
+
+	var lastErr error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			delay := time.Duration(math.Pow(2, float64(attempt-1))) * baseDelay
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		err := c.downloadOnce(ctx, requestURL, dest)
+		if err == nil {
+			return nil
+		}
+
+		lastErr = err
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !isRetryableError(err) {
+			return err
+		}
+	}
+
+	return fmt.Errorf("failed after %d retries: %w", maxRetries, lastErr)
+}
+
+func (c *NOAAClient) downloadOnce(ctx context.Context, requestURL string, dest io.Writer) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodySnippet := make([]byte, 512)
+		n, _ := io.ReadFull(resp.Body, bodySnippet)
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(bodySnippet[:n]))
+	}
+
+	_, err = io.Copy(dest, resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to write response: %w", err)
+	}
+
+	return nil
 }
 
 // FetchData implements ExternalDataClient interface
@@ -371,38 +720,192 @@ func (c *NOAAClient) FetchData(ctx context.Context, req *models.DataRequest) (st
 	}
 }
 
-// HealthCheck verifies NOAA services are accessible
+// HealthCheck verifies NOAA GFS and WaveWatch III services are accessible by
+// issuing a HEAD request against each NOMADS dataset endpoint.
 func (c *NOAAClient) HealthCheck(ctx context.Context) error {
-	// TODO: Implement health check
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	for _, endpoint := range []string{c.gfsEndpoint, c.ww3Endpoint} {
+		req, err := http.NewRequestWithContext(ctx, "HEAD", endpoint+".dds", nil)
+		if err != nil {
+			return fmt.Errorf("failed to create health check request for %s: %w", endpoint, err)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("health check request failed for %s: %w", endpoint, err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("health check failed for %s with status: %d", endpoint, resp.StatusCode)
+		}
+	}
+
 	return nil
 }
 
-// DataClientFactory creates appropriate clients based on data type
+// providerEntry associates a named ExternalDataClient with the data types it
+// can serve.
+type providerEntry struct {
+	name    string
+	client  ExternalDataClient
+	handles map[models.DataType]bool
+}
+
+// DataClientFactory creates appropriate clients based on data type. A data
+// type may be served by more than one provider; GetClient tries providers in
+// registration order and falls back to the next one if HealthCheck fails, so
+// a Copernicus outage doesn't take down ocean current data entirely.
 type DataClientFactory struct {
-	copernicusClient *CopernicusClient
-	noaaClient       *NOAAClient
+	providers []providerEntry
 }
 
-// NewDataClientFactory creates a new factory with configured clients
-func NewDataClientFactory(copernicusEndpoint, copernicusUser, copernicusPass string) *DataClientFactory {
-	return &DataClientFactory{
-		copernicusClient: NewCopernicusClient(copernicusEndpoint, copernicusUser, copernicusPass),
-		noaaClient:       NewNOAAClient("", ""),
+// copernicusDAP4Dataset is the OPeNDAP dataset path queried by OpenDAPClient
+// when COPERNICUS_PROTOCOL=dap4, mirroring the NCSS DatasetID used by the
+// default CopernicusClient config.
+const copernicusDAP4Dataset = "global-analysis-forecast-phy-001-024/cmems_mod_glo_phy-cur_anfc_0.083deg_P1D-m"
+
+// NewDataClientFactory creates a new factory with the default provider set:
+// Copernicus is preferred for ocean currents, with HYCOM as a no-auth
+// fallback; NOAA GFS/WaveWatch III serve wind and wave data.
+//
+// COPERNICUS_PROTOCOL selects how the preferred ocean-currents provider
+// talks to Copernicus: "ncss" (default) downloads a full NetCDF file via
+// THREDDS NCSS, while "dap4" subsets the dataset server-side via OpenDAP's
+// DAP4 constraint expressions. This lets the two be A/B tested against each
+// other without a code change.
+//
+// If cache is non-nil, it's wired into the Copernicus (NCSS) and NOAA
+// providers so repeat fetches for the same (or a narrower) bounding box,
+// time window, and variable set are served from cache instead of hitting
+// CMEMS/NOMADS again.
+func NewDataClientFactory(copernicusEndpoint, copernicusUser, copernicusPass string, cache Cache) *DataClientFactory {
+	f := &DataClientFactory{}
+
+	copernicusLimit := intEnv("COPERNICUS_MAX_CONCURRENCY", 4)
+	if os.Getenv("COPERNICUS_PROTOCOL") == "dap4" {
+		f.RegisterProviderWithLimit("copernicus-dap4",
+			NewOpenDAPClient(copernicusEndpoint, copernicusDAP4Dataset, []string{"uo", "vo"}),
+			copernicusLimit, models.DataTypeOceanCurrents)
+	} else {
+		var copernicusOpts []CopernicusClientOption
+		if cache != nil {
+			copernicusOpts = append(copernicusOpts, WithCache(cache, DefaultCacheTTL))
+		}
+		f.RegisterProviderWithLimit("copernicus", NewCopernicusClient(copernicusEndpoint, copernicusUser, copernicusPass, copernicusOpts...),
+			copernicusLimit, models.DataTypeOceanCurrents)
 	}
+
+	noaa := NewNOAAClient("", "")
+	if cache != nil {
+		noaa.SetCache(cache, DefaultCacheTTL)
+	}
+
+	f.RegisterProvider("hycom", NewHYCOMClient(""), models.DataTypeOceanCurrents)
+	f.RegisterProviderWithLimit("noaa", noaa, intEnv("NOAA_MAX_CONCURRENCY", 8), models.DataTypeWind, models.DataTypeWaves)
+	return f
 }
 
-// GetClient returns the appropriate client for a data type
-func (f *DataClientFactory) GetClient(dataType models.DataType) (ExternalDataClient, error) {
-	switch dataType {
-	case models.DataTypeOceanCurrents:
-		return f.copernicusClient, nil
-	case models.DataTypeWind:
-		return f.noaaClient, nil
-	case models.DataTypeWaves:
-		return f.noaaClient, nil
-	default:
-		return nil, fmt.Errorf("unknown data type: %s", dataType)
+// intEnv reads an integer from the named environment variable, falling back
+// to def if it's unset or not a valid integer.
+func intEnv(name string, def int) int {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+// RegisterProvider adds a named client to the factory for the given data
+// types. Providers are tried in registration order by GetClient, so earlier
+// registrations are preferred.
+func (f *DataClientFactory) RegisterProvider(name string, client ExternalDataClient, dataTypes ...models.DataType) {
+	handles := make(map[models.DataType]bool, len(dataTypes))
+	for _, dt := range dataTypes {
+		handles[dt] = true
+	}
+	f.providers = append(f.providers, providerEntry{name: name, client: client, handles: handles})
+}
+
+// RegisterProviderWithLimit is RegisterProvider but caps how many FetchData
+// calls against client may run concurrently (across every worker in the
+// pool), so a burst of jobs for the same provider doesn't trip its upstream
+// rate limit - CMEMS in particular throttles aggressive polling. limit <= 0
+// means unlimited.
+func (f *DataClientFactory) RegisterProviderWithLimit(name string, client ExternalDataClient, limit int, dataTypes ...models.DataType) {
+	f.RegisterProvider(name, newLimitedClient(client, limit), dataTypes...)
+}
+
+// limitedClient wraps an ExternalDataClient with a semaphore capping
+// concurrent FetchData calls.
+type limitedClient struct {
+	ExternalDataClient
+	sem chan struct{}
+}
+
+func newLimitedClient(client ExternalDataClient, limit int) ExternalDataClient {
+	if limit <= 0 {
+		return client
+	}
+	return &limitedClient{ExternalDataClient: client, sem: make(chan struct{}, limit)}
+}
+
+func (c *limitedClient) FetchData(ctx context.Context, req *models.DataRequest) (string, error) {
+	select {
+	case c.sem <- struct{}{}:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	defer func() { <-c.sem }()
+
+	return c.ExternalDataClient.FetchData(ctx, req)
+}
+
+// FetchDataWithProgress passes progress through to the wrapped client when it
+// is a ProgressReportingClient, under the same concurrency cap as FetchData;
+// otherwise it falls back to FetchData without progress reports.
+func (c *limitedClient) FetchDataWithProgress(ctx context.Context, req *models.DataRequest, progress ProgressFunc) (string, error) {
+	pc, ok := c.ExternalDataClient.(ProgressReportingClient)
+	if !ok {
+		return c.FetchData(ctx, req)
+	}
+	select {
+	case c.sem <- struct{}{}:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	defer func() { <-c.sem }()
+	return pc.FetchDataWithProgress(ctx, req, progress)
+}
+
+// GetClient returns the first healthy provider registered for dataType, in
+// registration order. If every matching provider fails its health check, the
+// first matching provider is returned anyway so the caller still gets a
+// concrete FetchData error rather than a generic "none available" failure.
+func (f *DataClientFactory) GetClient(ctx context.Context, dataType models.DataType) (ExternalDataClient, error) {
+	var fallback ExternalDataClient
+
+	for _, p := range f.providers {
+		if !p.handles[dataType] {
+			continue
+		}
+		if fallback == nil {
+			fallback = p.client
+		}
+		if err := p.client.HealthCheck(ctx); err == nil {
+			return p.client, nil
+		}
+	}
+
+	if fallback != nil {
+		return fallback, nil
 	}
+	return nil, fmt.Errorf("unknown data type: %s", dataType)
 }
 
 // Example of how to use the clients:
@@ -412,9 +915,13 @@ func (f *DataClientFactory) GetClient(dataType models.DataType) (ExternalDataCli
 //     "https://nrt.cmems-du.eu/thredds/ncss",
 //     "username",
 //     "password",
+//     NewObjectStoreCache(storageService),
 // )
+// factory.RegisterProvider("erddap",
+//     NewERDDAPClient("https://coastwatch.pfeg.noaa.gov/erddap", "nesdisVHNchlaDaily"),
+//     models.DataTypeOceanCurrents)
 //
-// client, err := factory.GetClient(models.DataTypeOceanCurrents)
+// client, err := factory.GetClient(ctx, models.DataTypeOceanCurrents)
 // if err != nil {
 //     return err
 // }
@@ -397,6 +397,88 @@ func TestFormatFloat(t *testing.T) {
 	}
 }
 
+func TestBuildNOMADSURL(t *testing.T) {
+	req := &models.DataRequest{
+		DataType:  models.DataTypeWind,
+		MinLat:    60.0,
+		MaxLat:    70.0,
+		MinLon:    -20.0,
+		MaxLon:    -10.0,
+		StartTime: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndTime:   time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+	}
+
+	got, err := buildNOMADSURL("https://nomads.ncep.noaa.gov/dods/gfs_0p25", req, []string{"ugrd10m", "vgrd10m"})
+	if err != nil {
+		t.Fatalf("buildNOMADSURL() error = %v", err)
+	}
+
+	for _, want := range []string{"ugrd10m[", "vgrd10m[", ".nc?"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("URL missing %q\nGot: %s", want, got)
+		}
+	}
+}
+
+func TestBuildNOMADSURL_NoEndpoint(t *testing.T) {
+	req := &models.DataRequest{
+		MinLat: 60.0, MaxLat: 70.0, MinLon: -20.0, MaxLon: -10.0,
+		StartTime: time.Now(), EndTime: time.Now().Add(time.Hour),
+	}
+
+	if _, err := buildNOMADSURL("", req, []string{"ugrd10m"}); err == nil {
+		t.Error("Expected error for empty endpoint, got nil")
+	}
+}
+
+func TestNOAAClient_FetchWindData_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("mock GFS NetCDF data"))
+	}))
+	defer server.Close()
+
+	client := NewNOAAClient(server.URL, "")
+
+	req := &models.DataRequest{
+		DataType:  models.DataTypeWind,
+		MinLat:    60.0,
+		MaxLat:    70.0,
+		MinLon:    -20.0,
+		MaxLon:    -10.0,
+		StartTime: time.Now(),
+		EndTime:   time.Now().Add(24 * time.Hour),
+	}
+
+	filePath, err := client.FetchWindData(context.Background(), req)
+	if err != nil {
+		t.Fatalf("FetchWindData() error = %v", err)
+	}
+	defer os.Remove(filePath)
+
+	if filePath == "" {
+		t.Fatal("Expected non-empty file path")
+	}
+}
+
+func TestNOAAClient_FetchData_UnsupportedType(t *testing.T) {
+	client := NewNOAAClient("", "")
+
+	req := &models.DataRequest{
+		DataType:  models.DataTypeOceanCurrents,
+		MinLat:    60.0,
+		MaxLat:    70.0,
+		MinLon:    -20.0,
+		MaxLon:    -10.0,
+		StartTime: time.Now(),
+		EndTime:   time.Now().Add(24 * time.Hour),
+	}
+
+	if _, err := client.FetchData(context.Background(), req); err == nil {
+		t.Error("Expected error for unsupported data type, got nil")
+	}
+}
+
 func TestCopernicusClient_NoCredentialLogging(t *testing.T) {
 	// This test ensures credentials are never logged
 	// We check that error messages don't contain the password
@@ -516,3 +598,62 @@ func TestCopernicusClient_CustomVariables(t *testing.T) {
 	// Clean up
 	_ = os.Remove(filePath)
 }
+
+// fakeClient is a minimal ExternalDataClient test double whose HealthCheck
+// outcome is controlled directly, so registry fallback can be tested without
+// spinning up real providers.
+type fakeClient struct {
+	healthy bool
+}
+
+func (f *fakeClient) FetchData(ctx context.Context, req *models.DataRequest) (string, error) {
+	return "/tmp/fake.nc", nil
+}
+
+func (f *fakeClient) HealthCheck(ctx context.Context) error {
+	if f.healthy {
+		return nil
+	}
+	return fmt.Errorf("fake client unhealthy")
+}
+
+func TestDataClientFactory_GetClient_PrefersHealthyProvider(t *testing.T) {
+	f := &DataClientFactory{}
+	primary := &fakeClient{healthy: false}
+	fallback := &fakeClient{healthy: true}
+	f.RegisterProvider("primary", primary, models.DataTypeOceanCurrents)
+	f.RegisterProvider("fallback", fallback, models.DataTypeOceanCurrents)
+
+	client, err := f.GetClient(context.Background(), models.DataTypeOceanCurrents)
+	if err != nil {
+		t.Fatalf("GetClient() error = %v", err)
+	}
+	if client != fallback {
+		t.Errorf("expected fallback provider to be selected when primary is unhealthy")
+	}
+}
+
+func TestDataClientFactory_GetClient_AllUnhealthyReturnsFirst(t *testing.T) {
+	f := &DataClientFactory{}
+	primary := &fakeClient{healthy: false}
+	secondary := &fakeClient{healthy: false}
+	f.RegisterProvider("primary", primary, models.DataTypeOceanCurrents)
+	f.RegisterProvider("secondary", secondary, models.DataTypeOceanCurrents)
+
+	client, err := f.GetClient(context.Background(), models.DataTypeOceanCurrents)
+	if err != nil {
+		t.Fatalf("GetClient() error = %v", err)
+	}
+	if client != primary {
+		t.Errorf("expected first registered provider when none are healthy")
+	}
+}
+
+func TestDataClientFactory_GetClient_UnknownDataType(t *testing.T) {
+	f := &DataClientFactory{}
+	f.RegisterProvider("primary", &fakeClient{healthy: true}, models.DataTypeOceanCurrents)
+
+	if _, err := f.GetClient(context.Background(), models.DataTypeWind); err == nil {
+		t.Error("expected error for data type with no registered provider")
+	}
+}
@@ -0,0 +1,268 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/eysteinn/driftline/services/data-service/internal/models"
+)
+
+// FederatedSource is one backend a FederatedClient can route requests to -
+// e.g. a second CMEMS mirror, or an ERDDAP instance serving the same
+// DataType as a fallback for the primary Copernicus provider.
+type FederatedSource struct {
+	Name      string
+	Client    ExternalDataClient
+	Priority  int // lower runs first
+	DataTypes map[models.DataType]bool
+}
+
+// Handles reports whether this source advertises support for dataType.
+func (s *FederatedSource) Handles(dataType models.DataType) bool {
+	return s.DataTypes[dataType]
+}
+
+const (
+	// defaultBreakerThreshold is how many consecutive failures trip a
+	// source's circuit breaker open.
+	defaultBreakerThreshold = 3
+	// defaultBreakerCooldown is how long a tripped breaker stays open
+	// before a half-open probe is allowed through.
+	defaultBreakerCooldown = 1 * time.Minute
+	// defaultHealthTTL is how long a cached HealthCheck result is reused
+	// before HealthCheck hits the source again.
+	defaultHealthTTL = 30 * time.Second
+)
+
+// sourceBreaker tracks one source's health cache and circuit-breaker state.
+type sourceBreaker struct {
+	mu               sync.Mutex
+	consecutiveFails int
+	openUntil        time.Time
+
+	healthCheckedAt time.Time
+	healthErr       error
+}
+
+// allow reports whether a request may currently be attempted against this
+// source: the breaker is closed, or open but past its cooldown (a
+// half-open probe).
+func (b *sourceBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.openUntil.IsZero() || !time.Now().Before(b.openUntil)
+}
+
+// recordResult updates the breaker's consecutive-failure count. A nil err
+// closes the breaker; reaching threshold consecutive failures opens it for
+// cooldown.
+func (b *sourceBreaker) recordResult(err error, threshold int, cooldown time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err == nil {
+		b.consecutiveFails = 0
+		b.openUntil = time.Time{}
+		return
+	}
+	b.consecutiveFails++
+	if b.consecutiveFails >= threshold {
+		b.openUntil = time.Now().Add(cooldown)
+	}
+}
+
+// cachedHealth returns the last HealthCheck result if it's still within
+// ttl, and whether a cached value was found.
+func (b *sourceBreaker) cachedHealth(ttl time.Duration) (error, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.healthCheckedAt.IsZero() || time.Since(b.healthCheckedAt) > ttl {
+		return nil, false
+	}
+	return b.healthErr, true
+}
+
+func (b *sourceBreaker) setHealth(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.healthCheckedAt = time.Now()
+	b.healthErr = err
+}
+
+// FederatedClient implements ExternalDataClient by wrapping several
+// underlying sources (multiple CMEMS mirrors, ERDDAP, etc). FetchData picks
+// the highest-priority source that both advertises the requested DataType
+// and whose circuit breaker is currently closed (or half-open), and falls
+// over to the next candidate on a retryable error (see isRetryableError).
+// This is a finer-grained failover than DataClientFactory's: the factory
+// picks one provider per DataType and lets the caller retry elsewhere,
+// while a FederatedClient exhausts all of its sources within a single
+// FetchData call and remembers source health across calls via the breaker
+// instead of running a synchronous HealthCheck on every request.
+type FederatedClient struct {
+	sources   []*FederatedSource
+	breakers  map[string]*sourceBreaker
+	threshold int
+	cooldown  time.Duration
+	healthTTL time.Duration
+
+	mu         sync.Mutex
+	lastSource string
+}
+
+// FederatedClientOption configures a FederatedClient at construction time.
+type FederatedClientOption func(*FederatedClient)
+
+// WithBreakerThreshold overrides how many consecutive failures trip a
+// source's circuit breaker open (default defaultBreakerThreshold).
+func WithBreakerThreshold(threshold int) FederatedClientOption {
+	return func(f *FederatedClient) { f.threshold = threshold }
+}
+
+// WithBreakerCooldown overrides how long a tripped breaker stays open
+// before a half-open probe is allowed through (default
+// defaultBreakerCooldown).
+func WithBreakerCooldown(cooldown time.Duration) FederatedClientOption {
+	return func(f *FederatedClient) { f.cooldown = cooldown }
+}
+
+// NewFederatedClient creates a FederatedClient over sources, tried in
+// ascending Priority order.
+func NewFederatedClient(sources []*FederatedSource, opts ...FederatedClientOption) *FederatedClient {
+	ordered := make([]*FederatedSource, len(sources))
+	copy(ordered, sources)
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Priority < ordered[j].Priority })
+
+	breakers := make(map[string]*sourceBreaker, len(ordered))
+	for _, s := range ordered {
+		breakers[s.Name] = &sourceBreaker{}
+	}
+
+	f := &FederatedClient{
+		sources:   ordered,
+		breakers:  breakers,
+		threshold: defaultBreakerThreshold,
+		cooldown:  defaultBreakerCooldown,
+		healthTTL: defaultHealthTTL,
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// LastSource returns the name of the source that served the most recent
+// successful FetchData call, or "" if none has succeeded yet. FetchData's
+// signature is shared with every other ExternalDataClient, so this is the
+// side channel callers use to learn which source actually served a request.
+func (f *FederatedClient) LastSource() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.lastSource
+}
+
+// candidates returns f's sources that handle dataType, in priority order.
+func (f *FederatedClient) candidates(dataType models.DataType) []*FederatedSource {
+	var out []*FederatedSource
+	for _, s := range f.sources {
+		if s.Handles(dataType) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// FetchData tries candidates for req.DataType in priority order, skipping
+// any whose breaker is currently open. A retryable error (e.g. a 5xx or
+// network failure) falls through to the next candidate; any other error is
+// returned immediately, since retrying a different source won't fix a bad
+// request. If every candidate's breaker is open, the highest-priority one
+// is tried anyway so the caller gets a concrete error instead of a generic
+// "no healthy source" failure.
+func (f *FederatedClient) FetchData(ctx context.Context, req *models.DataRequest) (string, error) {
+	candidates := f.candidates(req.DataType)
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("no federated source handles data type: %s", req.DataType)
+	}
+
+	attempted := false
+	var lastErr error
+	for _, s := range candidates {
+		breaker := f.breakers[s.Name]
+		if !breaker.allow() {
+			continue
+		}
+		attempted = true
+
+		path, err := s.Client.FetchData(ctx, req)
+		breaker.recordResult(err, f.threshold, f.cooldown)
+		if err == nil {
+			f.mu.Lock()
+			f.lastSource = s.Name
+			f.mu.Unlock()
+			return path, nil
+		}
+
+		lastErr = fmt.Errorf("source %s: %w", s.Name, err)
+		if !isRetryableError(err) {
+			return "", lastErr
+		}
+	}
+
+	if attempted {
+		return "", fmt.Errorf("all federated sources exhausted, last error: %w", lastErr)
+	}
+
+	// Every candidate's breaker was open - fall back to the highest
+	// priority one anyway rather than failing without ever trying.
+	s := candidates[0]
+	path, err := s.Client.FetchData(ctx, req)
+	f.breakers[s.Name].recordResult(err, f.threshold, f.cooldown)
+	if err != nil {
+		return "", fmt.Errorf("source %s: %w", s.Name, err)
+	}
+	f.mu.Lock()
+	f.lastSource = s.Name
+	f.mu.Unlock()
+	return path, nil
+}
+
+// HealthCheck refreshes (or reuses, within healthTTL) each source's cached
+// health in parallel and feeds the result into its circuit breaker.
+// It returns nil as long as at least one source is healthy, so a caller
+// that only checks HealthCheck before deciding whether to use this client
+// at all isn't blocked by one bad mirror.
+func (f *FederatedClient) HealthCheck(ctx context.Context) error {
+	if len(f.sources) == 0 {
+		return fmt.Errorf("no federated sources configured")
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(f.sources))
+	for i, s := range f.sources {
+		breaker := f.breakers[s.Name]
+		if cached, ok := breaker.cachedHealth(f.healthTTL); ok {
+			errs[i] = cached
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, s *FederatedSource, breaker *sourceBreaker) {
+			defer wg.Done()
+			err := s.Client.HealthCheck(ctx)
+			breaker.setHealth(err)
+			breaker.recordResult(err, f.threshold, f.cooldown)
+			errs[i] = err
+		}(i, s, breaker)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("all federated sources unhealthy: %w", errs[0])
+}
@@ -0,0 +1,126 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/eysteinn/driftline/services/data-service/internal/models"
+)
+
+// fakeSourceClient is a minimal ExternalDataClient that issues a single,
+// unretried GET against a test server and writes the response to a temp
+// file - standing in for a real provider's FetchData without pulling in
+// CopernicusClient's retry/backoff loop, which would make breaker tests
+// slow and timing-dependent.
+type fakeSourceClient struct {
+	serverURL string
+}
+
+func (f *fakeSourceClient) FetchData(ctx context.Context, req *models.DataRequest) (string, error) {
+	resp, err := http.Get(f.serverURL)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	dest, err := os.CreateTemp("", "federated_*.nc")
+	if err != nil {
+		return "", err
+	}
+	defer dest.Close()
+
+	if _, err := io.Copy(dest, resp.Body); err != nil {
+		return "", err
+	}
+	return dest.Name(), nil
+}
+
+func (f *fakeSourceClient) HealthCheck(ctx context.Context) error {
+	resp, err := http.Get(f.serverURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func unavailableServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func dataServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("mock NetCDF data content"))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFederatedClient_FetchData_FallsOverToHealthySource(t *testing.T) {
+	one := unavailableServer(t)
+	two := unavailableServer(t)
+	three := dataServer(t)
+
+	federated := NewFederatedClient([]*FederatedSource{
+		{Name: "one", Priority: 1, DataTypes: map[models.DataType]bool{models.DataTypeOceanCurrents: true},
+			Client: &fakeSourceClient{serverURL: one.URL}},
+		{Name: "two", Priority: 2, DataTypes: map[models.DataType]bool{models.DataTypeOceanCurrents: true},
+			Client: &fakeSourceClient{serverURL: two.URL}},
+		{Name: "three", Priority: 3, DataTypes: map[models.DataType]bool{models.DataTypeOceanCurrents: true},
+			Client: &fakeSourceClient{serverURL: three.URL}},
+	}, WithBreakerThreshold(1))
+
+	req := &models.DataRequest{
+		DataType:  models.DataTypeOceanCurrents,
+		MinLat:    60.0,
+		MaxLat:    70.0,
+		MinLon:    -20.0,
+		MaxLon:    -10.0,
+		StartTime: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndTime:   time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+	}
+
+	path, err := federated.FetchData(context.Background(), req)
+	if err != nil {
+		t.Fatalf("FetchData() error = %v", err)
+	}
+	defer os.Remove(path)
+
+	if _, statErr := os.Stat(path); statErr != nil {
+		t.Fatalf("expected a file to be produced at %s: %v", path, statErr)
+	}
+
+	if got := federated.LastSource(); got != "three" {
+		t.Errorf("LastSource() = %q, want %q", got, "three")
+	}
+
+	if federated.breakers["one"].allow() {
+		t.Error("breaker for source \"one\" should be open after reaching the configured threshold")
+	}
+	if federated.breakers["two"].allow() {
+		t.Error("breaker for source \"two\" should be open after reaching the configured threshold")
+	}
+	if !federated.breakers["three"].allow() {
+		t.Error("breaker for source \"three\" should remain closed after a successful fetch")
+	}
+}
@@ -0,0 +1,133 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/eysteinn/driftline/services/data-service/internal/models"
+)
+
+// GRIB2Client implements ExternalDataClient by downloading raw GFS .grib2
+// files from NOMADS filter services. It exists as a fallback for wind data
+// when OPeNDAP subsetting (NOAAClient) is down, at the cost of downloading
+// whole GRIB2 messages rather than a tight hyperslab.
+//
+// The downloaded .grib2 file is returned as-is; converting it to the NetCDF
+// format OpenDrift expects is out of scope here and left to the caller (the
+// drift-simulation pipeline already shells out to wgrib2/cdo for GRIB
+// ingestion).
+type GRIB2Client struct {
+	endpoint   string // NOMADS filter_gfs.pl base URL
+	httpClient *http.Client
+}
+
+// NewGRIB2Client creates a new GRIB2 client.
+func NewGRIB2Client(endpoint string) *GRIB2Client {
+	if endpoint == "" {
+		endpoint = "https://nomads.ncep.noaa.gov/cgi-bin/filter_gfs_0p25.pl"
+	}
+	return &GRIB2Client{
+		endpoint:   endpoint,
+		httpClient: &http.Client{Timeout: 5 * time.Minute},
+	}
+}
+
+// buildFilterURL constructs a NOMADS GRIB filter request that subsets by
+// bounding box and requests only the UGRD/VGRD 10 m above ground variables.
+func (c *GRIB2Client) buildFilterURL(req *models.DataRequest) (string, error) {
+	if c.endpoint == "" {
+		return "", fmt.Errorf("GRIB2 endpoint not configured")
+	}
+
+	cycleHour := req.StartTime.Hour() / 6 * 6
+	dateStr := req.StartTime.Format("20060102")
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s?file=gfs.t%02dz.pgrb2.0p25.f000", c.endpoint, cycleHour)
+	b.WriteString("&lev_10_m_above_ground=on&var_UGRD=on&var_VGRD=on")
+	fmt.Fprintf(&b, "&subregion=&leftlon=%s&rightlon=%s&toplat=%s&bottomlat=%s",
+		formatFloat(normalizeLon(req.MinLon)), formatFloat(normalizeLon(req.MaxLon)),
+		formatFloat(req.MaxLat), formatFloat(req.MinLat))
+	fmt.Fprintf(&b, "&dir=%%2Fgfs.%s%%2F%02d%%2Fatmos", dateStr, cycleHour)
+
+	return b.String(), nil
+}
+
+// FetchData downloads a GRIB2 subset from NOMADS.
+func (c *GRIB2Client) FetchData(ctx context.Context, req *models.DataRequest) (string, error) {
+	if err := req.Validate(); err != nil {
+		return "", fmt.Errorf("invalid request: %w", err)
+	}
+	if req.DataType != models.DataTypeWind {
+		return "", fmt.Errorf("unsupported data type for GRIB2 client: %s", req.DataType)
+	}
+
+	requestURL, err := c.buildFilterURL(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to build filter URL: %w", err)
+	}
+
+	tmpFile, err := os.CreateTemp("", "gfs_*.grib2")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer tmpFile.Close()
+
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		os.Remove(tmpFile.Name())
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodySnippet := make([]byte, 512)
+		n, _ := io.ReadFull(resp.Body, bodySnippet)
+		os.Remove(tmpFile.Name())
+		return "", fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(bodySnippet[:n]))
+	}
+
+	if _, err := io.Copy(tmpFile, resp.Body); err != nil {
+		os.Remove(tmpFile.Name())
+		return "", fmt.Errorf("failed to write response: %w", err)
+	}
+
+	return tmpFile.Name(), nil
+}
+
+// HealthCheck probes the NOMADS filter CGI endpoint.
+func (c *GRIB2Client) HealthCheck(ctx context.Context) error {
+	if c.endpoint == "" {
+		return fmt.Errorf("GRIB2 endpoint not configured")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "HEAD", c.endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create health check request: %w", err)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("health check request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// NOMADS CGI filter scripts return 400 for a bare HEAD with no query
+	// string but that still proves the endpoint is reachable.
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("health check failed with status: %d", resp.StatusCode)
+	}
+
+	return nil
+}
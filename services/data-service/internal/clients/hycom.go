@@ -0,0 +1,125 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/eysteinn/driftline/services/data-service/internal/models"
+)
+
+// HYCOMClient implements ExternalDataClient for the HYCOM global ocean
+// currents OPeNDAP endpoint. It serves as a fallback for ocean current data
+// when Copernicus is unavailable: coarser resolution, but globally available
+// with no auth requirement.
+type HYCOMClient struct {
+	endpoint   string // THREDDS OPeNDAP base, e.g. https://tds.hycom.org/thredds/dodsC/GLBy0.08
+	httpClient *http.Client
+	writer     NetCDFWriter
+}
+
+// NewHYCOMClient creates a new HYCOM client.
+func NewHYCOMClient(endpoint string) *HYCOMClient {
+	if endpoint == "" {
+		endpoint = "https://tds.hycom.org/thredds/dodsC/GLBy0.08/expt_93.0"
+	}
+	return &HYCOMClient{
+		endpoint:   endpoint,
+		httpClient: &http.Client{Timeout: 5 * time.Minute},
+		writer:     fileNetCDFWriter{},
+	}
+}
+
+// buildDODSURL constructs a constrained DODS NetCDF (".nc") request for HYCOM's
+// water_u/water_v variables subsetted to the request bounds. HYCOM's native
+// grid is tripolar, so this approximates indices on the documented 1/12.5
+// degree tropical/midlatitude grid used by the GLBy0.08 experiment.
+func (c *HYCOMClient) buildDODSURL(req *models.DataRequest) (string, error) {
+	if c.endpoint == "" {
+		return "", fmt.Errorf("HYCOM endpoint not configured")
+	}
+
+	const gridRes = 0.08
+	latStart := int((req.MinLat + 80) / gridRes)
+	latEnd := int((req.MaxLat + 80) / gridRes)
+	lonStart := int(normalizeLon(req.MinLon) / gridRes)
+	lonEnd := int(normalizeLon(req.MaxLon) / gridRes)
+
+	return fmt.Sprintf("%s.nc?water_u[0:0][0][%d:%d][%d:%d],water_v[0:0][0][%d:%d][%d:%d]",
+		c.endpoint, latStart, latEnd, lonStart, lonEnd, latStart, latEnd, lonStart, lonEnd), nil
+}
+
+// FetchData fetches an ocean-currents subset from HYCOM.
+func (c *HYCOMClient) FetchData(ctx context.Context, req *models.DataRequest) (string, error) {
+	if err := req.Validate(); err != nil {
+		return "", fmt.Errorf("invalid request: %w", err)
+	}
+	if req.DataType != models.DataTypeOceanCurrents {
+		return "", fmt.Errorf("unsupported data type for HYCOM client: %s", req.DataType)
+	}
+
+	requestURL, err := c.buildDODSURL(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to build DODS URL: %w", err)
+	}
+
+	dest, tmpPath, err := c.writer.Create("hycom_*.nc")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer dest.Close()
+
+	reqHTTP, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(reqHTTP)
+	if err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodySnippet := make([]byte, 512)
+		n, _ := io.ReadFull(resp.Body, bodySnippet)
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(bodySnippet[:n]))
+	}
+
+	if _, err := io.Copy(dest, resp.Body); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("failed to write response: %w", err)
+	}
+
+	return tmpPath, nil
+}
+
+// HealthCheck probes the HYCOM dataset's DDS metadata document.
+func (c *HYCOMClient) HealthCheck(ctx context.Context) error {
+	if c.endpoint == "" {
+		return fmt.Errorf("HYCOM endpoint not configured")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "HEAD", c.endpoint+".dds", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create health check request: %w", err)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("health check request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("health check failed with status: %d", resp.StatusCode)
+	}
+
+	return nil
+}
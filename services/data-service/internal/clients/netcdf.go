@@ -0,0 +1,25 @@
+package clients
+
+import (
+	"io"
+	"os"
+)
+
+// NetCDFWriter abstracts creation of the local NetCDF destination file for a
+// fetch, so tests can swap in an in-memory fake instead of touching disk.
+type NetCDFWriter interface {
+	// Create opens a new destination for the given os.CreateTemp-style name
+	// pattern and returns a writer along with its final path.
+	Create(pattern string) (io.WriteCloser, string, error)
+}
+
+// fileNetCDFWriter is the default NetCDFWriter, backed by the local filesystem.
+type fileNetCDFWriter struct{}
+
+func (fileNetCDFWriter) Create(pattern string) (io.WriteCloser, string, error) {
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return nil, "", err
+	}
+	return f, f.Name(), nil
+}
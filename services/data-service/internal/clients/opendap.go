@@ -0,0 +1,447 @@
+package clients
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eysteinn/driftline/services/data-service/internal/models"
+)
+
+// OpenDAPClient implements ExternalDataClient by speaking DAP4 directly to
+// an OPeNDAP server (THREDDS/Hyrax) instead of downloading a full NetCDF
+// file via NCSS the way CopernicusClient does: it resolves the request's
+// lat/lon/time box to integer index slices along the dataset's coordinate
+// variables and asks the server for only that hyperslab, via the
+// constrained ".nc4" response so the file on disk still matches what the
+// NCSS path produces today.
+type OpenDAPClient struct {
+	endpoint    string // OPeNDAP base, e.g. https://nrt.cmems-du.eu/thredds/dodsC
+	datasetPath string // dataset path appended to endpoint
+	variables   []string
+	httpClient  *http.Client
+	writer      NetCDFWriter
+
+	coordsMu sync.Mutex
+	coords   map[string]*datasetCoords // datasetURL -> cached coordinate lookup
+}
+
+// datasetCoords holds a dataset's coordinate variable values, used to map a
+// DataRequest's geographic/time bounds onto integer index slices.
+type datasetCoords struct {
+	lat, lon, time, depth []float64
+}
+
+// NewOpenDAPClient creates a new DAP4 subsetting client for the given
+// OPeNDAP dataset. variables are the default set requested when
+// req.Variables is empty (e.g. []string{"uo", "vo"} for ocean currents).
+func NewOpenDAPClient(endpoint, datasetPath string, variables []string) *OpenDAPClient {
+	return &OpenDAPClient{
+		endpoint:    endpoint,
+		datasetPath: datasetPath,
+		variables:   variables,
+		httpClient:  &http.Client{Timeout: 5 * time.Minute},
+		writer:      fileNetCDFWriter{},
+		coords:      make(map[string]*datasetCoords),
+	}
+}
+
+func (c *OpenDAPClient) datasetURL() string {
+	return strings.TrimRight(c.endpoint, "/") + "/" + c.datasetPath
+}
+
+// FetchData resolves req's bounds to index slices along the dataset's
+// coordinate variables and issues a DAP4 constraint-expression request for
+// just that hyperslab, instead of downloading the whole dataset.
+func (c *OpenDAPClient) FetchData(ctx context.Context, req *models.DataRequest) (string, error) {
+	if c.endpoint == "" {
+		return "", fmt.Errorf("OpenDAP endpoint not configured")
+	}
+	if err := req.Validate(); err != nil {
+		return "", fmt.Errorf("invalid request: %w", err)
+	}
+
+	coords, err := c.coordinates(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve dataset coordinates: %w", err)
+	}
+
+	ce, err := c.buildConstraintExpression(req, coords)
+	if err != nil {
+		return "", fmt.Errorf("failed to build constraint expression: %w", err)
+	}
+
+	// Hyrax and recent THREDDS both serve a ".nc4" response format for a
+	// DAP4-constrained request, returning a real NetCDF-4 file rather than
+	// the raw DAP4 chunked binary encoding - that keeps the file on disk in
+	// the same format the NCSS path already produces.
+	requestURL := c.datasetURL() + ".nc4?dap4.ce=" + url.QueryEscape(ce)
+
+	dest, tmpPath, err := c.writer.Create("opendap_*.nc")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer dest.Close()
+
+	if err := c.download(ctx, requestURL, dest); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("failed to download data: %w", err)
+	}
+
+	return tmpPath, nil
+}
+
+// coordinates returns the dataset's coordinate variable values, fetching
+// and caching them on first use per dataset URL - the request body's lat/
+// lon/time box changes on every call, but the coordinate arrays it's
+// resolved against don't.
+func (c *OpenDAPClient) coordinates(ctx context.Context) (*datasetCoords, error) {
+	key := c.datasetURL()
+
+	c.coordsMu.Lock()
+	cached, ok := c.coords[key]
+	c.coordsMu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	if err := c.verifyDimensions(ctx); err != nil {
+		return nil, err
+	}
+
+	coords, err := c.fetchCoordinateValues(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.coordsMu.Lock()
+	c.coords[key] = coords
+	c.coordsMu.Unlock()
+
+	return coords, nil
+}
+
+var requiredDimensions = []string{"lat", "lon", "time"}
+
+// verifyDimensions fetches the dataset's .dmr (DAP4 metadata) document and
+// checks it declares the dimensions this client depends on. It doesn't
+// attempt a full DAP4 XML parse - just enough to fail fast with a clear
+// error if pointed at an incompatible dataset.
+func (c *OpenDAPClient) verifyDimensions(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.datasetURL()+".dmr", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create DMR request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("DMR request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("DMR request returned HTTP %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read DMR response: %w", err)
+	}
+
+	for _, dim := range requiredDimensions {
+		pattern := regexp.MustCompile(`(?i)<Dimension\s+name="` + regexp.QuoteMeta(dim) + `"`)
+		if !pattern.Match(body) {
+			return fmt.Errorf("dataset DMR does not declare a %q dimension", dim)
+		}
+	}
+
+	return nil
+}
+
+// fetchCoordinateValues retrieves the dataset's lat/lon/time (and depth, if
+// present) coordinate variable values via a DAP2 ".ascii" data request,
+// which returns each variable as a plain comma-separated line rather than
+// DAP4's binary chunked framing - much simpler to parse, and only paid once
+// per dataset thanks to the coords cache.
+func (c *OpenDAPClient) fetchCoordinateValues(ctx context.Context) (*datasetCoords, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.datasetURL()+".ascii?lat,lon,time,depth", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create coordinate request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("coordinate request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("coordinate request returned HTTP %d", resp.StatusCode)
+	}
+
+	values, err := parseDAP2ASCII(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse coordinate response: %w", err)
+	}
+	if len(values["lat"]) == 0 || len(values["lon"]) == 0 || len(values["time"]) == 0 {
+		return nil, fmt.Errorf("coordinate response is missing lat, lon, or time values")
+	}
+
+	return &datasetCoords{
+		lat:   values["lat"],
+		lon:   values["lon"],
+		time:  values["time"],
+		depth: values["depth"], // absent on datasets with no depth dimension
+	}, nil
+}
+
+// parseDAP2ASCII parses a DAP2 ".ascii" response body into a map of
+// variable name to its flat float64 values. The format is a series of
+// blocks, each starting with a "name[dim = n]" header line followed by one
+// comma-separated line of values, e.g.:
+//
+//	lat[lat = 3]
+//	10.0, 20.0, 30.0
+//
+//	lon[lon = 2]
+//	100.0, 200.0
+func parseDAP2ASCII(r io.Reader) (map[string][]float64, error) {
+	values := make(map[string][]float64)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	var currentVar string
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if idx := strings.Index(line, "["); idx > 0 && !isNumericCSV(line) {
+			currentVar = line[:idx]
+			continue
+		}
+
+		if currentVar == "" {
+			continue
+		}
+
+		parsed, err := parseFloatCSV(line)
+		if err != nil {
+			continue
+		}
+		values[currentVar] = append(values[currentVar], parsed...)
+		currentVar = ""
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+func isNumericCSV(line string) bool {
+	_, err := strconv.ParseFloat(strings.TrimSpace(strings.Split(line, ",")[0]), 64)
+	return err == nil
+}
+
+func parseFloatCSV(line string) ([]float64, error) {
+	fields := strings.Split(line, ",")
+	out := make([]float64, 0, len(fields))
+	for _, f := range fields {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(f, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid numeric value %q: %w", f, err)
+		}
+		out = append(out, v)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no numeric values in line %q", line)
+	}
+	return out, nil
+}
+
+// buildConstraintExpression maps req's lat/lon/time bounds onto integer
+// index slices along coords and renders a DAP4 constraint expression
+// requesting just that hyperslab of each configured variable, fixed at
+// index 0 on the depth axis (surface level) since DataRequest has no depth
+// field. Lat/lon use indexRange's inclusive bounds (nearest-neighbor
+// fallback); time uses timeIndexRange's half-open [start, end) bounds so
+// back-to-back windows don't overlap on the shared boundary timestep.
+func (c *OpenDAPClient) buildConstraintExpression(req *models.DataRequest, coords *datasetCoords) (string, error) {
+	latStart, latEnd, err := indexRange(coords.lat, req.MinLat, req.MaxLat)
+	if err != nil {
+		return "", fmt.Errorf("latitude: %w", err)
+	}
+	lonStart, lonEnd, err := indexRange(coords.lon, normalizeLon(req.MinLon), normalizeLon(req.MaxLon))
+	if err != nil {
+		return "", fmt.Errorf("longitude: %w", err)
+	}
+	timeStart, timeEnd, err := timeIndexRange(coords.time, float64(req.StartTime.Unix()), float64(req.EndTime.Unix()))
+	if err != nil {
+		return "", fmt.Errorf("time: %w", err)
+	}
+
+	variables := c.variables
+	if len(req.Variables) > 0 {
+		variables = req.Variables
+	}
+
+	exprs := make([]string, 0, len(variables))
+	for _, v := range variables {
+		if len(coords.depth) > 0 {
+			exprs = append(exprs, fmt.Sprintf("/%s[%d:%d][0][%d:%d][%d:%d]", v, timeStart, timeEnd, latStart, latEnd, lonStart, lonEnd))
+		} else {
+			exprs = append(exprs, fmt.Sprintf("/%s[%d:%d][%d:%d][%d:%d]", v, timeStart, timeEnd, latStart, latEnd, lonStart, lonEnd))
+		}
+	}
+
+	return strings.Join(exprs, ";"), nil
+}
+
+// indexRange returns the smallest index range in coord whose values fall
+// within [lo, hi]. coord doesn't need to be sorted or monotonic - a linear
+// scan is cheap enough for coordinate arrays, which are at most a few
+// thousand points. If no value falls in range (a box narrower than the grid
+// spacing), it falls back to the single nearest index.
+func indexRange(coord []float64, lo, hi float64) (start, end int, err error) {
+	if len(coord) == 0 {
+		return 0, 0, fmt.Errorf("coordinate variable has no values")
+	}
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+
+	start, end = -1, -1
+	for i, v := range coord {
+		if v < lo || v > hi {
+			continue
+		}
+		if start == -1 {
+			start = i
+		}
+		end = i
+	}
+
+	if start == -1 {
+		nearest := nearestIndex(coord, (lo+hi)/2)
+		return nearest, nearest, nil
+	}
+	if start > end {
+		start, end = end, start
+	}
+
+	return start, end, nil
+}
+
+// timeIndexRange is indexRange's time-axis counterpart: it treats [lo, hi)
+// as a half-open interval rather than inclusive on both ends, so a request
+// for consecutive daily windows (e.g. [day1, day2) then [day2, day3)) doesn't
+// double-count the timestep sitting exactly on the shared boundary. Falls
+// back to the single nearest index when the interval contains no timestep,
+// same as indexRange.
+func timeIndexRange(coord []float64, lo, hi float64) (start, end int, err error) {
+	if len(coord) == 0 {
+		return 0, 0, fmt.Errorf("coordinate variable has no values")
+	}
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+
+	start, end = -1, -1
+	for i, v := range coord {
+		if v < lo || v >= hi {
+			continue
+		}
+		if start == -1 {
+			start = i
+		}
+		end = i
+	}
+
+	if start == -1 {
+		nearest := nearestIndex(coord, (lo+hi)/2)
+		return nearest, nearest, nil
+	}
+	if start > end {
+		start, end = end, start
+	}
+
+	return start, end, nil
+}
+
+func nearestIndex(coord []float64, target float64) int {
+	best := 0
+	bestDist := math.Abs(coord[0] - target)
+	for i, v := range coord {
+		if d := math.Abs(v - target); d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return best
+}
+
+func (c *OpenDAPClient) download(ctx context.Context, requestURL string, dest io.Writer) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodySnippet := make([]byte, 512)
+		n, _ := io.ReadFull(resp.Body, bodySnippet)
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(bodySnippet[:n]))
+	}
+
+	if _, err := io.Copy(dest, resp.Body); err != nil {
+		return fmt.Errorf("failed to write response: %w", err)
+	}
+
+	return nil
+}
+
+// HealthCheck probes the dataset's DMR metadata document.
+func (c *OpenDAPClient) HealthCheck(ctx context.Context) error {
+	if c.endpoint == "" {
+		return fmt.Errorf("OpenDAP endpoint not configured")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "HEAD", c.datasetURL()+".dmr", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create health check request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("health check request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("health check failed with status: %d", resp.StatusCode)
+	}
+
+	return nil
+}
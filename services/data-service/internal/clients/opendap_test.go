@@ -0,0 +1,128 @@
+package clients
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eysteinn/driftline/services/data-service/internal/models"
+)
+
+func TestParseDAP2ASCII(t *testing.T) {
+	body := "lat[lat = 3]\n10.0, 20.0, 30.0\n\nlon[lon = 2]\n100.0, 200.0\n\ntime[time = 2]\n1704067200.0, 1704153600.0\n"
+
+	values, err := parseDAP2ASCII(strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("parseDAP2ASCII() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		want []float64
+	}{
+		{"lat", []float64{10.0, 20.0, 30.0}},
+		{"lon", []float64{100.0, 200.0}},
+		{"time", []float64{1704067200.0, 1704153600.0}},
+	}
+
+	for _, tt := range tests {
+		got := values[tt.name]
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s[%d]: got %v, want %v", tt.name, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestIndexRange(t *testing.T) {
+	coord := []float64{10.0, 20.0, 30.0, 40.0, 50.0}
+
+	tests := []struct {
+		name      string
+		lo, hi    float64
+		wantStart int
+		wantEnd   int
+	}{
+		{"spans several points", 15.0, 45.0, 1, 3},
+		{"exact bounds are inclusive", 20.0, 40.0, 1, 3},
+		{"whole range", 0.0, 100.0, 0, 4},
+		{"narrower than spacing falls back to nearest", 21.0, 22.0, 1, 1},
+		{"reversed bounds are normalized", 45.0, 15.0, 1, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, err := indexRange(coord, tt.lo, tt.hi)
+			if err != nil {
+				t.Fatalf("indexRange() error = %v", err)
+			}
+			if start != tt.wantStart || end != tt.wantEnd {
+				t.Errorf("indexRange(%v, %v) = (%d, %d), want (%d, %d)", tt.lo, tt.hi, start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestTimeIndexRange(t *testing.T) {
+	// Three consecutive daily timesteps, one day apart.
+	coord := []float64{0, 86400, 172800}
+
+	tests := []struct {
+		name      string
+		lo, hi    float64
+		wantStart int
+		wantEnd   int
+	}{
+		{"half-open excludes the end boundary", 0, 172800, 0, 1},
+		{"back-to-back window picks up the shared boundary", 86400, 259200, 1, 2},
+		{"whole range plus one", 0, 259200, 0, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, err := timeIndexRange(coord, tt.lo, tt.hi)
+			if err != nil {
+				t.Fatalf("timeIndexRange() error = %v", err)
+			}
+			if start != tt.wantStart || end != tt.wantEnd {
+				t.Errorf("timeIndexRange(%v, %v) = (%d, %d), want (%d, %d)", tt.lo, tt.hi, start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestOpenDAPClient_BuildConstraintExpression(t *testing.T) {
+	client := NewOpenDAPClient("http://example.com/thredds/dodsC", "dataset", []string{"uo", "vo"})
+
+	coords := &datasetCoords{
+		lat:  []float64{60.0, 65.0, 70.0},
+		lon:  []float64{340.0, 345.0, 350.0}, // normalizeLon's 0-360 convention
+		time: []float64{0, 86400},
+	}
+
+	req := &models.DataRequest{
+		DataType:  models.DataTypeOceanCurrents,
+		MinLat:    60.0,
+		MaxLat:    70.0,
+		MinLon:    -20.0,
+		MaxLon:    -10.0,
+		StartTime: time.Unix(0, 0).UTC(),
+		EndTime:   time.Unix(86400, 0).UTC(),
+	}
+
+	ce, err := client.buildConstraintExpression(req, coords)
+	if err != nil {
+		t.Fatalf("buildConstraintExpression() error = %v", err)
+	}
+
+	for _, want := range []string{"/uo[0:0][0:2][0:2]", "/vo[0:0][0:2][0:2]"} {
+		if !strings.Contains(ce, want) {
+			t.Errorf("constraint expression missing %q, got %q", want, ce)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package clients
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// downloadMeta is the JSON sidecar persisted alongside a <finalPath>.part
+// file mid-download, recording enough about the in-flight response that a
+// later retry can decide whether it's safe to append to what's already on
+// disk instead of starting over.
+type downloadMeta struct {
+	ETag          string `json:"etag,omitempty"`
+	LastModified  string `json:"last_modified,omitempty"`
+	ContentLength int64  `json:"content_length,omitempty"`
+}
+
+func loadDownloadMeta(path string) *downloadMeta {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var meta downloadMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil
+	}
+	return &meta
+}
+
+func saveDownloadMeta(path string, meta *downloadMeta) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("failed to marshal download metadata: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write download metadata: %w", err)
+	}
+	return nil
+}
+
+// ProgressFunc receives cumulative bytes transferred as a download proceeds.
+// bytesTotal is 0 when the server didn't report a size (e.g. no
+// Content-Length on a chunked response).
+type ProgressFunc func(bytesDone, bytesTotal int64)
+
+// progressReader wraps a response body, invoking report with the cumulative
+// number of bytes read (offset by base, the bytes already on disk from a
+// prior attempt) after every chunk, so a caller can surface live download
+// progress instead of waiting for the whole transfer to finish.
+type progressReader struct {
+	r      io.Reader
+	base   int64
+	read   int64
+	total  int64
+	report ProgressFunc
+}
+
+func (p *progressReader) Read(buf []byte) (int, error) {
+	n, err := p.r.Read(buf)
+	if n > 0 {
+		p.read += int64(n)
+		p.report(p.base+p.read, p.total)
+	}
+	return n, err
+}
+
+// downloadResumable downloads requestURL to finalPath, writing through
+// <finalPath>.part and a <finalPath>.part.meta sidecar so that a transport
+// failure partway through a large extraction resumes via HTTP Range on the
+// next attempt instead of restarting from byte zero. It drives its HTTP
+// attempts through retryLoop, so it shares CopernicusClient's retry policy
+// and circuit breaker with the rest of the client. progress may be nil.
+func (c *CopernicusClient) downloadResumable(ctx context.Context, requestURL, finalPath string, progress ProgressFunc) error {
+	partPath := finalPath + ".part"
+	metaPath := finalPath + ".part.meta"
+
+	err := c.retryLoop(ctx, func(ctx context.Context, attempt int) (int, time.Duration, error) {
+		return c.resumableAttempt(ctx, requestURL, partPath, metaPath, attempt, progress)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := os.Rename(partPath, finalPath); err != nil {
+		return fmt.Errorf("failed to finalize downloaded file: %w", err)
+	}
+	os.Remove(metaPath)
+	return nil
+}
+
+// resumableAttempt wraps resumableRequest in a child span carrying the
+// request URL, attempt number, and resulting HTTP status code.
+func (c *CopernicusClient) resumableAttempt(ctx context.Context, requestURL, partPath, metaPath string, attempt int, progress ProgressFunc) (int, time.Duration, error) {
+	ctx, span := tracer.Start(ctx, "CopernicusClient.resumableAttempt", trace.WithAttributes(
+		attribute.String("http.url", requestURL),
+		attribute.Int("retry.attempt", attempt),
+	))
+	defer span.End()
+
+	statusCode, retryAfter, err := c.resumableRequest(ctx, requestURL, partPath, metaPath, progress)
+	if statusCode != 0 {
+		span.SetAttributes(attribute.Int("http.status_code", statusCode))
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return statusCode, retryAfter, err
+}
+
+// resumableRequest performs one HTTP request of downloadResumable's
+// transfer. When partPath already holds bytes from a previous attempt
+// against the same ETag, it requests the remainder via Range and appends;
+// otherwise (first attempt, the server ignored Range with a 200, or the
+// ETag no longer matches) it truncates partPath and starts over.
+func (c *CopernicusClient) resumableRequest(ctx context.Context, requestURL, partPath, metaPath string, progress ProgressFunc) (int, time.Duration, error) {
+	prevMeta := loadDownloadMeta(metaPath)
+
+	var offset int64
+	if prevMeta != nil {
+		if info, statErr := os.Stat(partPath); statErr == nil {
+			offset = info.Size()
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	if err := c.attachAuth(ctx, req); err != nil {
+		return 0, 0, err
+	}
+	if offset > 0 && prevMeta.ETag != "" {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", prevMeta.ETag)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		bodySnippet := make([]byte, 512)
+		n, _ := io.ReadFull(resp.Body, bodySnippet)
+		retryAfter, _ := parseRetryAfter(resp.Header.Get("Retry-After"))
+		return resp.StatusCode, retryAfter, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(bodySnippet[:n]))
+	}
+
+	resuming := resp.StatusCode == http.StatusPartialContent && offset > 0 &&
+		prevMeta != nil && prevMeta.ETag != "" && prevMeta.ETag == resp.Header.Get("ETag")
+
+	meta := &downloadMeta{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+
+	var file *os.File
+	if resuming {
+		file, err = os.OpenFile(partPath, os.O_WRONLY|os.O_APPEND, 0o644)
+		meta.ContentLength = contentRangeTotal(resp.Header.Get("Content-Range"))
+	} else {
+		offset = 0
+		file, err = os.Create(partPath)
+		if resp.ContentLength > 0 {
+			meta.ContentLength = resp.ContentLength
+		}
+	}
+	if err != nil {
+		return resp.StatusCode, 0, fmt.Errorf("failed to open destination file: %w", err)
+	}
+	defer file.Close()
+
+	if err := saveDownloadMeta(metaPath, meta); err != nil {
+		return resp.StatusCode, 0, err
+	}
+
+	// A checksum header describes the whole object, not just the range
+	// being appended in a resumed response, so it can only be verified
+	// when this attempt writes the file from scratch.
+	var checksum hash.Hash
+	var wantChecksum string
+	var checksumIsHex bool
+	if !resuming {
+		switch {
+		case resp.Header.Get("X-Checksum-Sha256") != "":
+			checksum, wantChecksum, checksumIsHex = sha256.New(), resp.Header.Get("X-Checksum-Sha256"), true
+		case resp.Header.Get("Content-MD5") != "":
+			checksum, wantChecksum, checksumIsHex = md5.New(), resp.Header.Get("Content-MD5"), false
+		}
+	}
+
+	w := io.Writer(file)
+	if checksum != nil {
+		w = io.MultiWriter(file, checksum)
+	}
+
+	body := io.Reader(resp.Body)
+	if progress != nil {
+		body = &progressReader{r: resp.Body, base: offset, total: meta.ContentLength, report: progress}
+	}
+
+	n, err := io.Copy(w, body)
+	if err != nil {
+		return resp.StatusCode, 0, fmt.Errorf("failed to write response: %w", err)
+	}
+
+	finalSize := offset + n
+	if meta.ContentLength > 0 && finalSize != meta.ContentLength {
+		return resp.StatusCode, 0, fmt.Errorf("downloaded %d bytes, expected %d", finalSize, meta.ContentLength)
+	}
+
+	if checksum != nil {
+		got := checksum.Sum(nil)
+		var match bool
+		if checksumIsHex {
+			match = strings.EqualFold(hex.EncodeToString(got), wantChecksum)
+		} else {
+			match = base64.StdEncoding.EncodeToString(got) == wantChecksum
+		}
+		if !match {
+			return resp.StatusCode, 0, fmt.Errorf("checksum validation failed for downloaded file")
+		}
+	}
+
+	return resp.StatusCode, 0, nil
+}
+
+// contentRangeTotal parses the total resource size out of a Content-Range
+// response header of the form "bytes 900-999/1000", returning 0 if the
+// header is absent or malformed.
+func contentRangeTotal(header string) int64 {
+	idx := strings.LastIndex(header, "/")
+	if idx == -1 || idx == len(header)-1 {
+		return 0
+	}
+	total, err := strconv.ParseInt(header[idx+1:], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return total
+}
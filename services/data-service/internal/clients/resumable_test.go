@@ -0,0 +1,212 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/eysteinn/driftline/services/data-service/internal/models"
+)
+
+// TestCopernicusClient_ResumableDownload_ResumesAfterTruncation simulates a
+// connection that closes mid-body on the first attempt: the server declares
+// a Content-Length longer than what it actually writes, which makes the
+// client's io.Copy fail with a short read exactly like a dropped TCP
+// connection would. The second attempt must come in with a Range request
+// for only the missing suffix and honor it with a 206.
+func TestCopernicusClient_ResumableDownload_ResumesAfterTruncation(t *testing.T) {
+	full := bytes.Repeat([]byte("0123456789"), 500) // 5000 bytes
+	splitAt := 2000
+	const etag = `"v1"`
+
+	attempts := 0
+	var secondAttemptBytesSent int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		switch attempts {
+		case 1:
+			if rng := r.Header.Get("Range"); rng != "" {
+				t.Errorf("first attempt should not send a Range header, got %q", rng)
+			}
+			w.Header().Set("ETag", etag)
+			w.Header().Set("Content-Length", strconv.Itoa(len(full)))
+			w.WriteHeader(http.StatusOK)
+			w.Write(full[:splitAt]) // connection ends here, short of the declared length
+		case 2:
+			wantRange := fmt.Sprintf("bytes=%d-", splitAt)
+			if got := r.Header.Get("Range"); got != wantRange {
+				t.Errorf("second attempt Range = %q, want %q", got, wantRange)
+			}
+			if got := r.Header.Get("If-Range"); got != etag {
+				t.Errorf("second attempt If-Range = %q, want %q", got, etag)
+			}
+			remainder := full[splitAt:]
+			w.Header().Set("ETag", etag)
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", splitAt, len(full)-1, len(full)))
+			w.Header().Set("Content-Length", strconv.Itoa(len(remainder)))
+			w.WriteHeader(http.StatusPartialContent)
+			n, _ := w.Write(remainder)
+			secondAttemptBytesSent = n
+		default:
+			t.Fatalf("unexpected attempt %d", attempts)
+		}
+	}))
+	defer server.Close()
+
+	client := NewCopernicusClient(server.URL, "user", "pass", WithRetryPolicy(RetryPolicy{
+		MaxAttempts:    1,
+		BaseDelay:      1 * time.Millisecond,
+		MaxDelay:       5 * time.Millisecond,
+		Multiplier:     2,
+		JitterFraction: 1,
+	}))
+
+	req := &models.DataRequest{
+		DataType:  models.DataTypeOceanCurrents,
+		MinLat:    60.0,
+		MaxLat:    70.0,
+		MinLon:    -20.0,
+		MaxLon:    -10.0,
+		StartTime: time.Now(),
+		EndTime:   time.Now().Add(24 * time.Hour),
+	}
+
+	path, err := client.FetchData(context.Background(), req)
+	if err != nil {
+		t.Fatalf("FetchData() error = %v", err)
+	}
+	defer os.Remove(path)
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read downloaded file: %v", err)
+	}
+	if !bytes.Equal(got, full) {
+		t.Errorf("downloaded file = %d bytes, want %d bytes matching the original content", len(got), len(full))
+	}
+
+	if want := len(full) - splitAt; secondAttemptBytesSent != want {
+		t.Errorf("second attempt sent %d bytes, want %d (only the missing suffix)", secondAttemptBytesSent, want)
+	}
+
+	if _, err := os.Stat(path + ".part"); !os.IsNotExist(err) {
+		t.Errorf("expected .part file to be renamed away, stat err = %v", err)
+	}
+	if _, err := os.Stat(path + ".part.meta"); !os.IsNotExist(err) {
+		t.Errorf("expected .part.meta sidecar to be cleaned up, stat err = %v", err)
+	}
+}
+
+// TestCopernicusClient_FetchDataWithProgress_ReportsBytes verifies that
+// FetchDataWithProgress's progress callback is driven continuously off the
+// response body (not just once at the end), with a final call that reports
+// the whole object as transferred.
+func TestCopernicusClient_FetchDataWithProgress_ReportsBytes(t *testing.T) {
+	full := bytes.Repeat([]byte("x"), 64*1024) // several read chunks
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(len(full)))
+		w.WriteHeader(http.StatusOK)
+		w.Write(full)
+	}))
+	defer server.Close()
+
+	client := NewCopernicusClient(server.URL, "user", "pass")
+
+	var calls int
+	var lastDone, lastTotal int64
+	progress := func(done, total int64) {
+		calls++
+		if done < lastDone {
+			t.Errorf("progress went backwards: %d after %d", done, lastDone)
+		}
+		lastDone, lastTotal = done, total
+	}
+
+	path, err := client.FetchDataWithProgress(context.Background(), testDataRequest(), progress)
+	if err != nil {
+		t.Fatalf("FetchDataWithProgress() error = %v", err)
+	}
+	defer os.Remove(path)
+
+	if calls == 0 {
+		t.Fatal("expected at least one progress callback")
+	}
+	if lastDone != int64(len(full)) {
+		t.Errorf("final bytesDone = %d, want %d", lastDone, len(full))
+	}
+	if lastTotal != int64(len(full)) {
+		t.Errorf("final bytesTotal = %d, want %d", lastTotal, len(full))
+	}
+}
+
+// TestCopernicusClient_ResumableDownload_RestartsOnETagMismatch verifies
+// that when the server's ETag changes between the failed attempt and the
+// retry (meaning the underlying data changed), the client truncates and
+// restarts instead of trying to append stale bytes to fresh content.
+func TestCopernicusClient_ResumableDownload_RestartsOnETagMismatch(t *testing.T) {
+	firstBody := bytes.Repeat([]byte("a"), 100)
+	secondBody := bytes.Repeat([]byte("b"), 50)
+
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		switch attempts {
+		case 1:
+			w.Header().Set("ETag", `"v1"`)
+			w.Header().Set("Content-Length", strconv.Itoa(len(firstBody)+10))
+			w.WriteHeader(http.StatusOK)
+			w.Write(firstBody) // short write triggers a retry
+		case 2:
+			// Respond 200 (not 206) with a different ETag: the server is
+			// telling us the resource changed, so Range is moot here even
+			// though the client sent one.
+			w.Header().Set("ETag", `"v2"`)
+			w.Header().Set("Content-Length", strconv.Itoa(len(secondBody)))
+			w.WriteHeader(http.StatusOK)
+			w.Write(secondBody)
+		default:
+			t.Fatalf("unexpected attempt %d", attempts)
+		}
+	}))
+	defer server.Close()
+
+	client := NewCopernicusClient(server.URL, "user", "pass", WithRetryPolicy(RetryPolicy{
+		MaxAttempts:    1,
+		BaseDelay:      1 * time.Millisecond,
+		MaxDelay:       5 * time.Millisecond,
+		Multiplier:     2,
+		JitterFraction: 1,
+	}))
+
+	req := &models.DataRequest{
+		DataType:  models.DataTypeOceanCurrents,
+		MinLat:    60.0,
+		MaxLat:    70.0,
+		MinLon:    -20.0,
+		MaxLon:    -10.0,
+		StartTime: time.Now(),
+		EndTime:   time.Now().Add(24 * time.Hour),
+	}
+
+	path, err := client.FetchData(context.Background(), req)
+	if err != nil {
+		t.Fatalf("FetchData() error = %v", err)
+	}
+	defer os.Remove(path)
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read downloaded file: %v", err)
+	}
+	if !bytes.Equal(got, secondBody) {
+		t.Errorf("downloaded file = %q, want %q (the restarted content, not firstBody+secondBody)", got, secondBody)
+	}
+}
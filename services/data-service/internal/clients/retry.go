@@ -0,0 +1,236 @@
+package clients
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/eysteinn/driftline/services/data-service/internal/models"
+)
+
+// errCircuitOpen is the sentinel circuitBreaker.allow returns when a call
+// is rejected outright - an alias for models.ErrCircuitOpen kept local so
+// the rest of this file doesn't need to qualify every reference.
+var errCircuitOpen = models.ErrCircuitOpen
+
+// RetryPolicy configures CopernicusClient's retry loop: MaxAttempts is how
+// many retries follow the first attempt (up to MaxAttempts+1 requests in
+// all), and the delay fields set how long to wait between them.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	Multiplier  float64
+
+	// JitterFraction controls how much of the decorrelated-jitter window
+	// is actually randomized, from 0 (no jitter - always wait the full
+	// previous-delay*Multiplier) to 1 (the classic decorrelated-jitter
+	// algorithm, randomizing across the whole [BaseDelay, prevDelay*
+	// Multiplier] range).
+	JitterFraction float64
+}
+
+// defaultRetryPolicy matches the fixed 3-retry, 1s-base, 2x-multiplier
+// behavior the retry loop had before RetryPolicy was configurable.
+var defaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	BaseDelay:      1 * time.Second,
+	MaxDelay:       30 * time.Second,
+	Multiplier:     2,
+	JitterFraction: 1,
+}
+
+// nextDelay computes the decorrelated-jitter backoff delay for the attempt
+// following one that waited prevDelay (BaseDelay if this is the first
+// retry): delay = min(MaxDelay, random_between(low, high)) where high =
+// prevDelay*Multiplier and low narrows toward high as JitterFraction drops
+// below 1, so JitterFraction=1 reproduces the textbook decorrelated-jitter
+// formula low=BaseDelay exactly. rng is caller-supplied so concurrent
+// retries don't share (and contend on) a single random source.
+func (p RetryPolicy) nextDelay(prevDelay time.Duration, rng *rand.Rand) time.Duration {
+	if prevDelay <= 0 {
+		prevDelay = p.BaseDelay
+	}
+
+	high := time.Duration(float64(prevDelay) * p.Multiplier)
+	low := p.BaseDelay
+	if p.JitterFraction < 1 {
+		narrowed := high - time.Duration(p.JitterFraction*float64(high-p.BaseDelay))
+		if narrowed > low {
+			low = narrowed
+		}
+	}
+	if high <= low {
+		return clampDuration(high, p.MaxDelay)
+	}
+
+	delay := low + time.Duration(rng.Int63n(int64(high-low)))
+	return clampDuration(delay, p.MaxDelay)
+}
+
+func clampDuration(d, max time.Duration) time.Duration {
+	if d > max {
+		return max
+	}
+	return d
+}
+
+// newCallRNG returns a random source seeded uniquely enough per call that
+// many goroutines retrying concurrently don't end up picking correlated
+// delays, without paying for crypto/rand or a shared, lock-contended
+// source.
+func newCallRNG() *rand.Rand {
+	var seed int64
+	// A monotonic counter mixed into the seed protects against multiple
+	// calls landing on the same clock tick.
+	seed = time.Now().UnixNano() + rngCounter.next()
+	return rand.New(rand.NewSource(seed))
+}
+
+var rngCounter counter
+
+type counter struct {
+	mu sync.Mutex
+	n  int64
+}
+
+func (c *counter) next() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.n++
+	return c.n
+}
+
+// parseRetryAfter parses an HTTP Retry-After header value, which is either
+// a number of seconds ("120") or an HTTP-date (e.g. "Fri, 31 Dec 1999
+// 23:59:59 GMT"). It returns false if header is empty or unparseable.
+func parseRetryAfter(header string) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(header); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if when, err := http.ParseTime(header); err == nil {
+		delay := time.Until(when)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+
+	return 0, false
+}
+
+// circuitState is the state of a circuitBreaker's underlying state machine.
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// circuitBreaker is a per-endpoint breaker guarding CopernicusClient's
+// retry loop: closed normally, it opens after Threshold consecutive
+// call-level failures seen within Window, rejecting further calls with
+// ErrCircuitOpen until Cooldown elapses, at which point a single
+// half-open probe is allowed through to decide whether to close again.
+type circuitBreaker struct {
+	Threshold int
+	Window    time.Duration
+	Cooldown  time.Duration
+
+	mu               sync.Mutex
+	state            circuitState
+	consecutiveFails int
+	firstFailureAt   time.Time
+	openedAt         time.Time
+	probing          bool
+}
+
+// newCircuitBreaker returns the breaker every CopernicusClient starts with:
+// a generous threshold so the breaker doesn't change behavior for callers
+// who never touch it. No CopernicusClientOption configures it.
+func newCircuitBreaker() *circuitBreaker {
+	return &circuitBreaker{
+		Threshold: 5,
+		Window:    1 * time.Minute,
+		Cooldown:  30 * time.Second,
+	}
+}
+
+// allow reports whether a call may currently proceed, transitioning the
+// breaker from open to half-open once Cooldown has elapsed. It returns
+// models.ErrCircuitOpen when the call should be rejected outright.
+func (b *circuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		if time.Since(b.openedAt) < b.Cooldown {
+			return errCircuitOpen
+		}
+		if b.probing {
+			return errCircuitOpen
+		}
+		b.state = circuitHalfOpen
+		b.probing = true
+		return nil
+	case circuitHalfOpen:
+		if b.probing {
+			return errCircuitOpen
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// recordSuccess closes the breaker, whether it was closed, half-open
+// (the probe succeeded), or (rarely) observed as open by a racing caller.
+func (b *circuitBreaker) recordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state = circuitClosed
+	b.consecutiveFails = 0
+	b.firstFailureAt = time.Time{}
+	b.probing = false
+}
+
+// recordFailure counts a failed call toward the breaker tripping open. A
+// failed half-open probe reopens the breaker immediately for another full
+// Cooldown; a failed closed-state call only trips the breaker once
+// Threshold consecutive failures have landed within Window.
+func (b *circuitBreaker) recordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == circuitHalfOpen {
+		b.probing = false
+		b.state = circuitOpen
+		b.openedAt = time.Now()
+		return
+	}
+
+	now := time.Now()
+	if b.firstFailureAt.IsZero() || now.Sub(b.firstFailureAt) > b.Window {
+		b.firstFailureAt = now
+		b.consecutiveFails = 0
+	}
+	b.consecutiveFails++
+
+	if b.consecutiveFails >= b.Threshold {
+		b.state = circuitOpen
+		b.openedAt = now
+	}
+}
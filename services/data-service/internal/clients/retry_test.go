@@ -0,0 +1,120 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/eysteinn/driftline/services/data-service/internal/models"
+)
+
+func testDataRequest() *models.DataRequest {
+	return &models.DataRequest{
+		DataType:  models.DataTypeOceanCurrents,
+		MinLat:    60.0,
+		MaxLat:    70.0,
+		MinLon:    -20.0,
+		MaxLon:    -10.0,
+		StartTime: time.Now(),
+		EndTime:   time.Now().Add(24 * time.Hour),
+	}
+}
+
+func TestCopernicusClient_RetryPolicy_MaxAttempts(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewCopernicusClient(server.URL, "user", "pass", WithRetryPolicy(RetryPolicy{
+		MaxAttempts:    2,
+		BaseDelay:      1 * time.Millisecond,
+		MaxDelay:       5 * time.Millisecond,
+		Multiplier:     2,
+		JitterFraction: 1,
+	}))
+
+	_, err := client.FetchData(context.Background(), testDataRequest())
+	if err == nil {
+		t.Fatal("expected an error from a permanently failing server")
+	}
+
+	// One initial attempt plus MaxAttempts retries.
+	if want := 1 + 2; attempts != want {
+		t.Errorf("attempts = %d, want %d", attempts, want)
+	}
+}
+
+func TestCopernicusClient_RetryAfter_ShortCircuitsBackoff(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("success"))
+	}))
+	defer server.Close()
+
+	client := NewCopernicusClient(server.URL, "user", "pass", WithRetryPolicy(RetryPolicy{
+		MaxAttempts:    2,
+		BaseDelay:      10 * time.Second,
+		MaxDelay:       20 * time.Second,
+		Multiplier:     2,
+		JitterFraction: 1,
+	}))
+
+	start := time.Now()
+	path, err := client.FetchData(context.Background(), testDataRequest())
+	elapsed := time.Since(start)
+	if err != nil {
+		t.Fatalf("FetchData() error = %v", err)
+	}
+	defer os.Remove(path)
+
+	if elapsed >= 5*time.Second {
+		t.Errorf("retry took %v, want well under the configured 10s BaseDelay (Retry-After: 1 should have preempted it)", elapsed)
+	}
+}
+
+func TestCopernicusClient_CircuitBreaker_OpensAfterThreshold(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewCopernicusClient(server.URL, "user", "pass", WithRetryPolicy(RetryPolicy{
+		MaxAttempts:    0,
+		BaseDelay:      1 * time.Millisecond,
+		MaxDelay:       5 * time.Millisecond,
+		Multiplier:     2,
+		JitterFraction: 1,
+	}))
+	client.breaker.Threshold = 1
+	client.breaker.Cooldown = 1 * time.Minute
+
+	if _, err := client.FetchData(context.Background(), testDataRequest()); err == nil {
+		t.Fatal("expected the first call against a failing server to return an error")
+	}
+
+	attemptsAfterFirstCall := attempts
+
+	_, err := client.FetchData(context.Background(), testDataRequest())
+	if !errors.Is(err, models.ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen once the breaker trips, got %v", err)
+	}
+	if attempts != attemptsAfterFirstCall {
+		t.Errorf("expected no request to reach the server once the breaker is open, got %d new attempts", attempts-attemptsAfterFirstCall)
+	}
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/eysteinn/driftline/services/data-service/internal/models"
+	"github.com/eysteinn/driftline/services/data-service/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// CacheHandler exposes admin operations on the content-addressed data cache.
+type CacheHandler struct {
+	dataService *services.DataService
+}
+
+// NewCacheHandler creates a new cache handler
+func NewCacheHandler(dataService *services.DataService) *CacheHandler {
+	return &CacheHandler{
+		dataService: dataService,
+	}
+}
+
+// invalidateRequest identifies the cache entry to drop. It mirrors
+// models.DataRequest rather than embedding it so variables remain optional
+// and unrelated to the key, matching generateCacheKey's canonical form.
+type invalidateRequest struct {
+	DataType  models.DataType `json:"data_type" binding:"required"`
+	MinLat    float64         `json:"min_lat" binding:"required,min=-90,max=90"`
+	MaxLat    float64         `json:"max_lat" binding:"required,min=-90,max=90"`
+	MinLon    float64         `json:"min_lon" binding:"required,min=-180,max=180"`
+	MaxLon    float64         `json:"max_lon" binding:"required,min=-180,max=180"`
+	StartTime string          `json:"start_time" binding:"required"`
+	EndTime   string          `json:"end_time" binding:"required"`
+}
+
+// Invalidate drops the cached entry for a given request, e.g. after an
+// upstream provider re-publishes a forecast cycle ahead of schedule.
+func (h *CacheHandler) Invalidate(c *gin.Context) {
+	var body invalidateRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	startTime, err := time.Parse(time.RFC3339, body.StartTime)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid start_time: " + err.Error()})
+		return
+	}
+	endTime, err := time.Parse(time.RFC3339, body.EndTime)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid end_time: " + err.Error()})
+		return
+	}
+
+	req := &models.DataRequest{
+		DataType:  body.DataType,
+		MinLat:    body.MinLat,
+		MaxLat:    body.MaxLat,
+		MinLon:    body.MinLon,
+		MaxLon:    body.MaxLon,
+		StartTime: startTime,
+		EndTime:   endTime,
+	}
+
+	if err := h.dataService.InvalidateCache(c.Request.Context(), req); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "invalidated"})
+}
+
+// invalidateDataTypeRequest names the whole dataset to drop every cached
+// bbox/time slice for, rather than one request's exact bounds/time range.
+type invalidateDataTypeRequest struct {
+	DataType models.DataType `json:"data_type" binding:"required"`
+}
+
+// InvalidateDataType drops every cached entry for a data type in one call,
+// e.g. from an ingestion job that just landed a new forecast cycle and
+// wants every previously cached bbox/time slice for that dataset to miss on
+// its next request, without enumerating their content-addressed keys.
+func (h *CacheHandler) InvalidateDataType(c *gin.Context) {
+	var body invalidateDataTypeRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.dataService.InvalidateDataType(c.Request.Context(), body.DataType); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "invalidated", "data_type": body.DataType})
+}
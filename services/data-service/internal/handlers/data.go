@@ -0,0 +1,447 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eysteinn/driftline/services/data-service/internal/jobs"
+	"github.com/eysteinn/driftline/services/data-service/internal/models"
+	"github.com/eysteinn/driftline/services/data-service/internal/observability"
+	"github.com/eysteinn/driftline/services/data-service/internal/services"
+	"github.com/eysteinn/driftline/services/data-service/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+// DataHandler handles environmental data requests
+type DataHandler struct {
+	dataService *services.DataService
+	jobQueue    *jobs.Queue
+}
+
+// NewDataHandler creates a new data handler. jobQueue may be nil, in which
+// case a cache/storage miss falls back to fetching synchronously instead of
+// handing the request off to the async job queue.
+func NewDataHandler(dataService *services.DataService, jobQueue *jobs.Queue) *DataHandler {
+	return &DataHandler{
+		dataService: dataService,
+		jobQueue:    jobQueue,
+	}
+}
+
+// GetOceanCurrents handles requests for ocean current data
+func (h *DataHandler) GetOceanCurrents(c *gin.Context) {
+	req := &models.DataRequest{
+		DataType: models.DataTypeOceanCurrents,
+	}
+	h.handleDataRequest(c, req)
+}
+
+// GetWind handles requests for wind data
+func (h *DataHandler) GetWind(c *gin.Context) {
+	req := &models.DataRequest{
+		DataType: models.DataTypeWind,
+	}
+	h.handleDataRequest(c, req)
+}
+
+// GetWaves handles requests for wave data
+func (h *DataHandler) GetWaves(c *gin.Context) {
+	req := &models.DataRequest{
+		DataType: models.DataTypeWaves,
+	}
+	h.handleDataRequest(c, req)
+}
+
+// StreamOceanCurrents handles GET /data/ocean-currents/stream.
+func (h *DataHandler) StreamOceanCurrents(c *gin.Context) {
+	req := &models.DataRequest{DataType: models.DataTypeOceanCurrents}
+	h.streamDataRequest(c, req)
+}
+
+// StreamWind handles GET /data/wind/stream.
+func (h *DataHandler) StreamWind(c *gin.Context) {
+	req := &models.DataRequest{DataType: models.DataTypeWind}
+	h.streamDataRequest(c, req)
+}
+
+// StreamWaves handles GET /data/waves/stream.
+func (h *DataHandler) StreamWaves(c *gin.Context) {
+	req := &models.DataRequest{DataType: models.DataTypeWaves}
+	h.streamDataRequest(c, req)
+}
+
+// SubscribeOceanCurrents handles GET /data/ocean-currents/events.
+func (h *DataHandler) SubscribeOceanCurrents(c *gin.Context) {
+	req := &models.DataRequest{DataType: models.DataTypeOceanCurrents}
+	h.subscribeDataRequest(c, req)
+}
+
+// SubscribeWind handles GET /data/wind/events.
+func (h *DataHandler) SubscribeWind(c *gin.Context) {
+	req := &models.DataRequest{DataType: models.DataTypeWind}
+	h.subscribeDataRequest(c, req)
+}
+
+// SubscribeWaves handles GET /data/waves/events.
+func (h *DataHandler) SubscribeWaves(c *gin.Context) {
+	req := &models.DataRequest{DataType: models.DataTypeWaves}
+	h.subscribeDataRequest(c, req)
+}
+
+// subscribeDataRequest serves req as an SSE stream of DataService.
+// SubscribeData's events (queued, source selected, live download progress,
+// cached/completed/error), for a client that wants to show fetch progress
+// instead of just blocking on handleDataRequest's synchronous response.
+//
+// This is a different mechanism than the async job queue's
+// /data/jobs/:id/stream: that one polls/streams a previously-enqueued job by
+// ID and survives this process restarting (state lives in Redis), while this
+// endpoint subscribes directly to an in-process fetch keyed on the request's
+// bounds/time/variables, with no job ID of its own. Concurrent callers
+// hitting the same bounds still share a single upstream fetch - see
+// DataService.SubscribeData - but the lifecycle ends if this service
+// restarts mid-fetch. Prefer /data/jobs for anything that needs to survive
+// that; this endpoint is for a client that wants to watch its own request's
+// progress with the least latency and setup.
+func (h *DataHandler) subscribeDataRequest(c *gin.Context, req *models.DataRequest) {
+	if err := h.parseQueryParams(c, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	events, err := h.dataService.SubscribeData(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Writer.Header().Set("Content-Type", "text/event-stream")
+	c.Writer.Header().Set("Cache-Control", "no-cache")
+	c.Writer.Header().Set("Connection", "keep-alive")
+
+	ctx := c.Request.Context()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return false
+			}
+			writeDataEvent(c, ev)
+			return ev.Kind != services.EventCompleted && ev.Kind != services.EventError && ev.Kind != services.EventCached
+		case <-ctx.Done():
+			return false
+		case <-time.After(30 * time.Second):
+			// keep-alive comment so intermediaries don't close the connection
+			c.SSEvent("", "")
+			return true
+		}
+	})
+}
+
+// dataEventPayload is DataEvent's wire shape: error isn't JSON-marshalable
+// directly, and zero-value fields that don't apply to a given Kind (e.g.
+// Response on a Progress event) are omitted for a smaller payload.
+type dataEventPayload struct {
+	Kind       services.DataEventKind `json:"kind"`
+	Source     string                 `json:"source,omitempty"`
+	BytesDone  int64                  `json:"bytes_done,omitempty"`
+	BytesTotal int64                  `json:"bytes_total,omitempty"`
+	Response   *models.DataResponse   `json:"response,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+}
+
+func writeDataEvent(c *gin.Context, ev services.DataEvent) {
+	payload := dataEventPayload{
+		Kind:       ev.Kind,
+		Source:     ev.Source,
+		BytesDone:  ev.BytesDone,
+		BytesTotal: ev.BytesTotal,
+		Response:   ev.Response,
+	}
+	if ev.Err != nil {
+		payload.Error = ev.Err.Error()
+	}
+	c.SSEvent(string(ev.Kind), payload)
+}
+
+// streamDataRequest serves a request's underlying NetCDF object directly,
+// honoring a client's Range header with a proper 206/Content-Range so a
+// large download can resume instead of restarting from byte zero.
+//
+// ?full=true returns a presigned object-storage URL instead (the same file
+// handleDataRequest's FilePath refers to), for clients that would rather
+// download straight from S3/MinIO than proxy through this service.
+//
+// NOTE: this does not yet slice the NetCDF file server-side by bbox or
+// variables - doing that needs a NetCDF-reading library this repo doesn't
+// vendor (the existing NetCDFWriter abstraction in internal/clients only
+// writes files fetched from upstream, it doesn't parse them back). Until
+// that lands, a request here still transfers the whole object; only a
+// client-supplied Range narrows what's sent, which is what
+// observability.DataStreamBytesSaved measures.
+func (h *DataHandler) streamDataRequest(c *gin.Context, req *models.DataRequest) {
+	if err := h.parseQueryParams(c, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	resp, found, err := h.dataService.Peek(ctx, req)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "data not yet fetched for this request - GET the non-streaming endpoint first to trigger a fetch",
+		})
+		return
+	}
+
+	if c.Query("full") == "true" {
+		url, err := h.dataService.PresignObjectURL(ctx, resp.FilePath)
+		if err != nil {
+			h.respondError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"url": url})
+		return
+	}
+
+	size, err := h.dataService.ObjectSize(ctx, resp.FilePath)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	rng, err := parseRangeHeader(c.GetHeader("Range"), size)
+	if err != nil {
+		c.Header("Content-Range", fmt.Sprintf("bytes */%d", size))
+		c.JSON(http.StatusRequestedRangeNotSatisfiable, gin.H{"error": err.Error()})
+		return
+	}
+
+	reader, err := h.dataService.OpenObjectRange(ctx, resp.FilePath, rng)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	defer reader.Close()
+
+	c.Header("Accept-Ranges", "bytes")
+	c.Header("Content-Type", "application/x-netcdf")
+
+	served := size
+	if rng == nil {
+		c.Header("Content-Length", strconv.FormatInt(size, 10))
+		c.Status(http.StatusOK)
+	} else {
+		served = rng.Length
+		c.Header("Content-Range", fmt.Sprintf("bytes %d-%d/%d", rng.Offset, rng.Offset+rng.Length-1, size))
+		c.Header("Content-Length", strconv.FormatInt(rng.Length, 10))
+		c.Status(http.StatusPartialContent)
+	}
+	observability.DataStreamBytesSaved.WithLabelValues(string(req.DataType)).Observe(float64(size - served))
+
+	if _, err := io.Copy(c.Writer, reader); err != nil {
+		log.Printf("Error streaming object %s: %v", resp.FilePath, err)
+	}
+}
+
+// parseRangeHeader parses a single-range "bytes=start-end" Range header
+// (the only form this proxy supports - multi-range requests fall back to
+// the full object). A missing header returns a nil range, meaning "serve
+// everything".
+func parseRangeHeader(header string, size int64) (*storage.ByteRange, error) {
+	if header == "" {
+		return nil, nil
+	}
+
+	spec := strings.TrimPrefix(header, "bytes=")
+	if spec == header || strings.Contains(spec, ",") {
+		return nil, nil
+	}
+
+	parts := strings.SplitN(spec, "-", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("malformed Range header")
+	}
+
+	var start, end int64
+	if parts[0] == "" {
+		// Suffix range: "-N" means the last N bytes.
+		n, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed Range header")
+		}
+		if n > size {
+			n = size
+		}
+		start = size - n
+		end = size - 1
+	} else {
+		s, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed Range header")
+		}
+		start = s
+		if parts[1] == "" {
+			end = size - 1
+		} else {
+			e, err := strconv.ParseInt(parts[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("malformed Range header")
+			}
+			end = e
+		}
+	}
+
+	if start < 0 || start >= size || end < start {
+		return nil, fmt.Errorf("range out of bounds for object of size %d", size)
+	}
+	if end >= size {
+		end = size - 1
+	}
+
+	return &storage.ByteRange{Offset: start, Length: end - start + 1}, nil
+}
+
+// handleDataRequest processes a data request: a cache or storage hit is
+// served synchronously, but a miss against a configured job queue is handed
+// off asynchronously (202 + Location) rather than blocking the request on a
+// potentially multi-GB CMEMS/NOAA download.
+func (h *DataHandler) handleDataRequest(c *gin.Context, req *models.DataRequest) {
+	if err := h.parseQueryParams(c, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	resp, found, err := h.dataService.Peek(ctx, req)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	if found {
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+
+	if h.jobQueue == nil {
+		resp, err := h.dataService.GetData(ctx, req)
+		if err != nil {
+			h.respondError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+
+	jobID, err := h.jobQueue.Enqueue(ctx, req, h.dataService.ObjectKey(req))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	location := "/v1/data/jobs/" + jobID
+	c.Header("Location", location)
+	c.JSON(http.StatusAccepted, gin.H{
+		"job_id":   jobID,
+		"status":   "queued",
+		"location": location,
+	})
+}
+
+func (h *DataHandler) respondError(c *gin.Context, err error) {
+	log.Printf("Error getting data: %v", err)
+
+	status := http.StatusInternalServerError
+	switch err {
+	case models.ErrInvalidBounds, models.ErrInvalidTimeRange:
+		status = http.StatusBadRequest
+	case models.ErrDataNotFound:
+		status = http.StatusNotFound
+	case models.ErrExternalSourceUnavailable:
+		status = http.StatusServiceUnavailable
+	}
+
+	c.JSON(status, gin.H{
+		"error": err.Error(),
+	})
+}
+
+// parseQueryParams extracts parameters from query string
+func (h *DataHandler) parseQueryParams(c *gin.Context, req *models.DataRequest) error {
+	minLat, err := parseFloat(c.Query("min_lat"))
+	if err != nil {
+		return err
+	}
+	req.MinLat = minLat
+
+	maxLat, err := parseFloat(c.Query("max_lat"))
+	if err != nil {
+		return err
+	}
+	req.MaxLat = maxLat
+
+	minLon, err := parseFloat(c.Query("min_lon"))
+	if err != nil {
+		return err
+	}
+	req.MinLon = minLon
+
+	maxLon, err := parseFloat(c.Query("max_lon"))
+	if err != nil {
+		return err
+	}
+	req.MaxLon = maxLon
+
+	startTimeStr := c.Query("start_time")
+	if startTimeStr == "" {
+		req.StartTime = time.Now().UTC()
+	} else {
+		startTime, err := time.Parse(time.RFC3339, startTimeStr)
+		if err != nil {
+			return err
+		}
+		req.StartTime = startTime
+	}
+
+	endTimeStr := c.Query("end_time")
+	if endTimeStr == "" {
+		req.EndTime = req.StartTime.Add(48 * time.Hour)
+	} else {
+		endTime, err := time.Parse(time.RFC3339, endTimeStr)
+		if err != nil {
+			return err
+		}
+		req.EndTime = endTime
+	}
+
+	if resolution := c.Query("resolution"); resolution != "" {
+		req.Resolution = resolution
+	}
+
+	return nil
+}
+
+// parseFloat parses a float from string
+func parseFloat(s string) (float64, error) {
+	if s == "" {
+		return 0, models.ErrInvalidBounds
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	return f, nil
+}
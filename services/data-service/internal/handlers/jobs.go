@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/eysteinn/driftline/services/data-service/internal/jobs"
+	"github.com/eysteinn/driftline/services/data-service/internal/models"
+	"github.com/eysteinn/driftline/services/data-service/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// JobHandler exposes the async job-queue flow for FetchData requests too
+// large to service synchronously within a single HTTP request.
+type JobHandler struct {
+	queue       *jobs.Queue
+	dataService *services.DataService
+}
+
+// NewJobHandler creates a new job handler.
+func NewJobHandler(queue *jobs.Queue, dataService *services.DataService) *JobHandler {
+	return &JobHandler{queue: queue, dataService: dataService}
+}
+
+// CreateJob handles POST /v1/data/request: enqueues a DataRequest and
+// returns its job ID for polling/streaming.
+func (h *JobHandler) CreateJob(c *gin.Context) {
+	var req models.DataRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	jobID, err := h.queue.Enqueue(c.Request.Context(), &req, h.dataService.ObjectKey(&req))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"job_id": jobID})
+}
+
+// GetJob handles GET /v1/data/jobs/:id: returns the current job state.
+func (h *JobHandler) GetJob(c *gin.Context) {
+	job, err := h.queue.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err == models.ErrDataNotFound {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// CancelJob handles DELETE /v1/data/jobs/:id: flags the job for
+// cancellation. The worker processing it (if any) picks this up and
+// cancels the in-flight fetch via its context.
+func (h *JobHandler) CancelJob(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := c.Param("id")
+
+	if _, err := h.queue.Get(ctx, id); err != nil {
+		status := http.StatusInternalServerError
+		if err == models.ErrDataNotFound {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.queue.Cancel(ctx, id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "cancelling"})
+}
+
+// StreamJob handles GET /v1/data/jobs/:id/stream: an SSE stream of job state
+// snapshots, terminating once the job reaches a final status.
+func (h *JobHandler) StreamJob(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := c.Param("id")
+
+	job, err := h.queue.Get(ctx, id)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err == models.ErrDataNotFound {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	sub := h.queue.Subscribe(ctx, id)
+	defer sub.Close()
+	ch := sub.Channel()
+
+	c.Writer.Header().Set("Content-Type", "text/event-stream")
+	c.Writer.Header().Set("Cache-Control", "no-cache")
+	c.Writer.Header().Set("Connection", "keep-alive")
+
+	writeEvent(c, job)
+	if isTerminal(job.Status) {
+		return
+	}
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case msg, ok := <-ch:
+			if !ok {
+				return false
+			}
+			var job jobs.Job
+			if err := json.Unmarshal([]byte(msg.Payload), &job); err != nil {
+				return true
+			}
+			writeEvent(c, &job)
+			return !isTerminal(job.Status)
+		case <-ctx.Done():
+			return false
+		case <-time.After(30 * time.Second):
+			// keep-alive comment so intermediaries don't close the connection
+			c.SSEvent("", "")
+			return true
+		}
+	})
+}
+
+func writeEvent(c *gin.Context, job *jobs.Job) {
+	c.SSEvent("progress", job)
+}
+
+func isTerminal(status jobs.Status) bool {
+	return status == jobs.StatusSucceeded || status == jobs.StatusFailed
+}
@@ -0,0 +1,36 @@
+package jobs
+
+import (
+	"time"
+
+	"github.com/eysteinn/driftline/services/data-service/internal/models"
+	"github.com/eysteinn/driftline/services/data-service/internal/services"
+)
+
+// Status is the lifecycle state of an async data-fetch job.
+type Status string
+
+const (
+	StatusQueued    Status = "queued"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+)
+
+// Job tracks an async data-fetch request end to end: what was asked for,
+// where it currently is (Status/Stage), and the eventual result or error.
+// It's serialized as JSON into Redis, so this is the wire format polled by
+// GET /v1/data/jobs/:id and streamed by GET /v1/data/jobs/:id/stream.
+type Job struct {
+	ID              string               `json:"id"`
+	Request         *models.DataRequest  `json:"request"`
+	Status          Status               `json:"status"`
+	Stage           services.Stage       `json:"stage,omitempty"`
+	BytesDownloaded int64                `json:"bytes_downloaded"`
+	Attempts        int                  `json:"attempts"`
+	Error           string               `json:"error,omitempty"`
+	Response        *models.DataResponse `json:"response,omitempty"`
+	Cancelled       bool                 `json:"cancelled"`
+	CreatedAt       time.Time            `json:"created_at"`
+	UpdatedAt       time.Time            `json:"updated_at"`
+}
@@ -0,0 +1,94 @@
+package jobs
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eysteinn/driftline/services/data-service/internal/models"
+	"github.com/eysteinn/driftline/services/data-service/internal/services"
+)
+
+func TestNewJobID_UniqueAndPrefixed(t *testing.T) {
+	id1, err := newJobID()
+	if err != nil {
+		t.Fatalf("newJobID() error = %v", err)
+	}
+	id2, err := newJobID()
+	if err != nil {
+		t.Fatalf("newJobID() error = %v", err)
+	}
+
+	if id1 == id2 {
+		t.Error("expected distinct job IDs")
+	}
+	if !strings.HasPrefix(id1, "job_") {
+		t.Errorf("expected job ID to be prefixed with job_, got %s", id1)
+	}
+}
+
+func TestJob_JSONRoundTrip(t *testing.T) {
+	job := &Job{
+		ID: "job_abc123",
+		Request: &models.DataRequest{
+			DataType:  models.DataTypeWind,
+			MinLat:    60.0,
+			MaxLat:    70.0,
+			MinLon:    -20.0,
+			MaxLon:    -10.0,
+			StartTime: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			EndTime:   time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		},
+		Status:          StatusRunning,
+		Stage:           services.StageDownload,
+		BytesDownloaded: 1024,
+		Attempts:        1,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	}
+
+	data, err := json.Marshal(job)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	var decoded Job
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if decoded.ID != job.ID {
+		t.Errorf("ID = %s, want %s", decoded.ID, job.ID)
+	}
+	if decoded.Status != job.Status {
+		t.Errorf("Status = %s, want %s", decoded.Status, job.Status)
+	}
+	if decoded.Stage != job.Stage {
+		t.Errorf("Stage = %s, want %s", decoded.Stage, job.Stage)
+	}
+	if decoded.BytesDownloaded != job.BytesDownloaded {
+		t.Errorf("BytesDownloaded = %d, want %d", decoded.BytesDownloaded, job.BytesDownloaded)
+	}
+	if decoded.Request.DataType != job.Request.DataType {
+		t.Errorf("Request.DataType = %s, want %s", decoded.Request.DataType, job.Request.DataType)
+	}
+}
+
+func TestIsTerminal(t *testing.T) {
+	tests := []struct {
+		status Status
+		want   bool
+	}{
+		{StatusQueued, false},
+		{StatusRunning, false},
+		{StatusSucceeded, true},
+		{StatusFailed, true},
+	}
+
+	for _, tt := range tests {
+		if got := isTerminalStatus(tt.status); got != tt.want {
+			t.Errorf("isTerminalStatus(%s) = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/eysteinn/driftline/services/data-service/internal/models"
+	"github.com/eysteinn/driftline/services/data-service/internal/services"
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	queueKey   = "data_jobs:queue"
+	dlqKey     = "data_jobs:dlq"
+	jobTTL     = 24 * time.Hour
+	dequeueTTL = 5 * time.Second
+)
+
+// ErrQueueEmpty is returned by Dequeue when no job is available within the
+// poll interval; workers treat it as "try again", not a fatal error.
+var ErrQueueEmpty = errors.New("job queue empty")
+
+// Queue is a Redis-backed job queue for async DataRequest fetches. Job state
+// lives in a per-job key so pollers can read it directly, and in a pub/sub
+// channel so SSE/WebSocket subscribers get pushed updates without polling.
+type Queue struct {
+	client *redis.Client
+}
+
+// NewQueue creates a new job queue backed by the given Redis connection URL.
+func NewQueue(redisURL string) (*Queue, error) {
+	opts, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse redis URL: %w", err)
+	}
+
+	client := redis.NewClient(opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+
+	return &Queue{client: client}, nil
+}
+
+// Close closes the underlying Redis connection.
+func (q *Queue) Close() error {
+	return q.client.Close()
+}
+
+// Enqueue creates a new job for req and pushes it onto the queue, returning
+// its ID. dedupKey identifies the upstream fetch req would trigger (callers
+// pass services.DataService.ObjectKey); if a job with the same dedupKey is
+// already queued or running, its ID is returned instead and no new job is
+// created, so concurrent requests for the same bounds/time collapse into a
+// single CMEMS/NOAA fetch.
+func (q *Queue) Enqueue(ctx context.Context, req *models.DataRequest, dedupKey string) (string, error) {
+	id, err := newJobID()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate job id: %w", err)
+	}
+
+	inFlightKey := dedupInFlightKey(dedupKey)
+	claimed, err := q.client.SetNX(ctx, inFlightKey, id, jobTTL).Result()
+	if err != nil {
+		return "", fmt.Errorf("failed to claim dedup key: %w", err)
+	}
+	if !claimed {
+		if existingID, err := q.client.Get(ctx, inFlightKey).Result(); err == nil {
+			if existing, err := q.Get(ctx, existingID); err == nil && !isTerminalStatus(existing.Status) {
+				return existing.ID, nil
+			}
+		}
+		// The existing mapping is stale (its job finished or vanished) -
+		// claim it for the new job.
+		if err := q.client.Set(ctx, inFlightKey, id, jobTTL).Err(); err != nil {
+			return "", fmt.Errorf("failed to claim dedup key: %w", err)
+		}
+	}
+
+	now := time.Now()
+	job := &Job{
+		ID:        id,
+		Request:   req,
+		Status:    StatusQueued,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := q.save(ctx, job); err != nil {
+		return "", err
+	}
+
+	if err := q.client.RPush(ctx, queueKey, id).Err(); err != nil {
+		return "", fmt.Errorf("failed to enqueue job: %w", err)
+	}
+
+	return id, nil
+}
+
+// Dequeue blocks for up to dequeueTTL waiting for a job to become available.
+// It returns ErrQueueEmpty on timeout so callers can loop and re-check ctx.
+func (q *Queue) Dequeue(ctx context.Context) (*Job, error) {
+	result, err := q.client.BLPop(ctx, dequeueTTL, queueKey).Result()
+	if err == redis.Nil {
+		return nil, ErrQueueEmpty
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to dequeue job: %w", err)
+	}
+
+	// BLPop returns [queueKey, value]
+	id := result[1]
+	job, err := q.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load dequeued job %s: %w", id, err)
+	}
+
+	job.Status = StatusRunning
+	job.UpdatedAt = time.Now()
+	if err := q.save(ctx, job); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// Requeue puts a job back on the queue for another attempt.
+func (q *Queue) Requeue(ctx context.Context, job *Job) error {
+	job.Status = StatusQueued
+	job.UpdatedAt = time.Now()
+	if err := q.save(ctx, job); err != nil {
+		return err
+	}
+	return q.client.RPush(ctx, queueKey, job.ID).Err()
+}
+
+// DeadLetter marks a job permanently failed and records it on the
+// dead-letter list for manual inspection/replay.
+func (q *Queue) DeadLetter(ctx context.Context, job *Job, cause error) error {
+	job.Status = StatusFailed
+	job.Error = cause.Error()
+	job.UpdatedAt = time.Now()
+	if err := q.save(ctx, job); err != nil {
+		return err
+	}
+	return q.client.RPush(ctx, dlqKey, job.ID).Err()
+}
+
+// UpdateProgress records a stage transition and notifies stream subscribers.
+func (q *Queue) UpdateProgress(ctx context.Context, jobID string, stage services.Stage, bytesDownloaded int64) error {
+	job, err := q.Get(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	job.Stage = stage
+	job.BytesDownloaded = bytesDownloaded
+	job.UpdatedAt = time.Now()
+	return q.save(ctx, job)
+}
+
+// Complete records a successful fetch result.
+func (q *Queue) Complete(ctx context.Context, jobID string, resp *models.DataResponse) error {
+	job, err := q.Get(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	job.Status = StatusSucceeded
+	job.Response = resp
+	job.UpdatedAt = time.Now()
+	return q.save(ctx, job)
+}
+
+// Cancel flags a job as cancelled; the worker processing it polls this flag
+// and cancels the FetchData context at the next check.
+func (q *Queue) Cancel(ctx context.Context, jobID string) error {
+	job, err := q.Get(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	job.Cancelled = true
+	job.UpdatedAt = time.Now()
+	return q.save(ctx, job)
+}
+
+// IsCancelled reports whether a job has been flagged for cancellation.
+func (q *Queue) IsCancelled(ctx context.Context, jobID string) (bool, error) {
+	job, err := q.Get(ctx, jobID)
+	if err != nil {
+		return false, err
+	}
+	return job.Cancelled, nil
+}
+
+// Get returns the current state of a job.
+func (q *Queue) Get(ctx context.Context, jobID string) (*Job, error) {
+	data, err := q.client.Get(ctx, jobKey(jobID)).Bytes()
+	if err == redis.Nil {
+		return nil, models.ErrDataNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load job: %w", err)
+	}
+
+	var job Job
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal job: %w", err)
+	}
+	return &job, nil
+}
+
+// Subscribe returns a pub/sub subscription that receives a JSON-encoded Job
+// snapshot every time the job's state changes, for SSE/WebSocket streaming.
+func (q *Queue) Subscribe(ctx context.Context, jobID string) *redis.PubSub {
+	return q.client.Subscribe(ctx, eventsChannel(jobID))
+}
+
+func (q *Queue) save(ctx context.Context, job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+	if err := q.client.Set(ctx, jobKey(job.ID), data, jobTTL).Err(); err != nil {
+		return fmt.Errorf("failed to save job: %w", err)
+	}
+	// Best-effort notify; no subscribers is not an error.
+	q.client.Publish(ctx, eventsChannel(job.ID), data)
+	return nil
+}
+
+func jobKey(jobID string) string {
+	return "data_jobs:job:" + jobID
+}
+
+func eventsChannel(jobID string) string {
+	return "data_jobs:events:" + jobID
+}
+
+func dedupInFlightKey(dedupKey string) string {
+	return "data_jobs:inflight:" + dedupKey
+}
+
+func isTerminalStatus(status Status) bool {
+	return status == StatusSucceeded || status == StatusFailed
+}
+
+func newJobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "job_" + hex.EncodeToString(b), nil
+}
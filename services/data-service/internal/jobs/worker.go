@@ -0,0 +1,133 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"math"
+	"time"
+
+	"github.com/eysteinn/driftline/services/data-service/internal/services"
+)
+
+const (
+	maxAttempts        = 3
+	baseRetryDelay     = 2 * time.Second
+	cancelPollInterval = 1 * time.Second
+)
+
+// Worker consumes jobs from a Queue and runs them through DataService,
+// publishing stage/progress updates as it goes. Failed jobs are retried with
+// exponential backoff up to maxAttempts before landing on the dead-letter
+// list.
+type Worker struct {
+	queue       *Queue
+	dataService *services.DataService
+}
+
+// NewWorker creates a new job worker.
+func NewWorker(queue *Queue, dataService *services.DataService) *Worker {
+	return &Worker{
+		queue:       queue,
+		dataService: dataService,
+	}
+}
+
+// Run dequeues and processes jobs until ctx is cancelled.
+func (w *Worker) Run(ctx context.Context) {
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		job, err := w.queue.Dequeue(ctx)
+		if err == ErrQueueEmpty {
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("job worker: dequeue failed: %v", err)
+			continue
+		}
+
+		w.process(ctx, job)
+	}
+}
+
+// process runs a single job, cancelling the in-flight fetch if the job is
+// flagged cancelled, and retrying failed attempts with backoff.
+func (w *Worker) process(ctx context.Context, job *Job) {
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stopWatching := w.watchForCancellation(jobCtx, cancel, job.ID)
+	defer stopWatching()
+
+	report := func(stage services.Stage, bytesDownloaded int64) {
+		if err := w.queue.UpdateProgress(ctx, job.ID, stage, bytesDownloaded); err != nil {
+			log.Printf("job worker: failed to update progress for %s: %v", job.ID, err)
+		}
+	}
+
+	resp, err := w.dataService.GetDataWithProgress(jobCtx, job.Request, report)
+	if err != nil {
+		w.handleFailure(ctx, job, err)
+		return
+	}
+
+	if err := w.queue.Complete(ctx, job.ID, resp); err != nil {
+		log.Printf("job worker: failed to record completion for %s: %v", job.ID, err)
+	}
+}
+
+// watchForCancellation polls the job's cancellation flag and cancels cancel
+// once set, propagating through ctx into the in-flight FetchData call. It
+// returns a function to stop the poller once the job finishes normally.
+func (w *Worker) watchForCancellation(ctx context.Context, cancel context.CancelFunc, jobID string) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(cancelPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				cancelled, err := w.queue.IsCancelled(ctx, jobID)
+				if err != nil {
+					continue
+				}
+				if cancelled {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+func (w *Worker) handleFailure(ctx context.Context, job *Job, err error) {
+	job.Attempts++
+
+	if job.Attempts >= maxAttempts {
+		if dlqErr := w.queue.DeadLetter(ctx, job, err); dlqErr != nil {
+			log.Printf("job worker: failed to dead-letter job %s: %v", job.ID, dlqErr)
+		}
+		return
+	}
+
+	delay := time.Duration(math.Pow(2, float64(job.Attempts-1))) * baseRetryDelay
+	select {
+	case <-time.After(delay):
+	case <-ctx.Done():
+		return
+	}
+
+	if requeueErr := w.queue.Requeue(ctx, job); requeueErr != nil {
+		log.Printf("job worker: failed to requeue job %s: %v", job.ID, requeueErr)
+	}
+}
@@ -20,4 +20,9 @@ var (
 	
 	// ErrExternalSourceUnavailable is returned when external data source is unavailable
 	ErrExternalSourceUnavailable = errors.New("external data source unavailable")
+
+	// ErrCircuitOpen is returned when a client's circuit breaker has
+	// tripped and is still within its cooldown window, so the request is
+	// rejected before it's attempted against the upstream endpoint.
+	ErrCircuitOpen = errors.New("circuit breaker open")
 )
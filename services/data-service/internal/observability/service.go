@@ -0,0 +1,162 @@
+// Package observability wires up Prometheus metrics and OpenTelemetry
+// tracing for the data service: a Gin middleware records request metrics,
+// storage.Service, clients.ObjectStoreCache, cache.ClientSideService and the
+// data handlers call into the package-level collectors directly, and Service
+// configures the OTLP trace exporter.
+package observability
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	httpRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Total HTTP requests handled, by route, method, and status code.",
+	}, []string{"route", "method", "status"})
+
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency in seconds, by route and method.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	StorageUploadBytesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "storage_upload_bytes_total",
+		Help: "Total bytes uploaded to object storage.",
+	}, []string{"bucket"})
+
+	StorageOperationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "storage_operation_duration_seconds",
+		Help:    "Object storage operation latency in seconds, by operation.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"op"})
+
+	CacheOperationsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "external_data_cache_operations_total",
+		Help: "External dataset cache lookups, by dataset and outcome (hit/miss).",
+	}, []string{"dataset", "outcome"})
+
+	// DataStreamBytesSaved tracks, per streamed request, how many fewer
+	// bytes were served than the full object's size. Today the only source
+	// of savings is a client-supplied Range header (the stream endpoint
+	// doesn't yet slice a NetCDF file server-side by bbox/variables - see
+	// handlers.streamDataRequest), so this currently measures the value of
+	// resumable partial downloads rather than true subsetting.
+	DataStreamBytesSaved = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "data_stream_bytes_saved",
+		Help:    "Bytes not transferred by a /data/*/stream request, relative to the full object size, by data type.",
+		Buckets: prometheus.ExponentialBuckets(1024, 8, 10),
+	}, []string{"data_type"})
+
+	// ClientSideCacheOperationsTotal counts cache.ClientSideService's local
+	// in-process cache operations, by outcome (hit/miss/invalidation).
+	ClientSideCacheOperationsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "client_side_cache_operations_total",
+		Help: "Local in-process cache operations for cache.ClientSideService, by outcome (hit/miss/invalidation).",
+	}, []string{"outcome"})
+)
+
+// Service holds this process's tracer and the shutdown hook for its OTLP
+// exporter.
+type Service struct {
+	Tracer   trace.Tracer
+	shutdown func(context.Context) error
+}
+
+// NewService configures the global OTel tracer provider to export spans
+// via OTLP/gRPC to otlpEndpoint (e.g. "otel-collector:4317"). If
+// otlpEndpoint is empty, tracing is left disabled - a no-op tracer is
+// returned so running without a collector configured is not an error.
+func NewService(ctx context.Context, otlpEndpoint string) (*Service, error) {
+	if otlpEndpoint == "" {
+		return &Service{
+			Tracer:   otel.Tracer("driftline-data-service"),
+			shutdown: func(context.Context) error { return nil },
+		}, nil
+	}
+
+	exporter, err := otlptracegrpc.New(ctx, otlptracegrpc.WithEndpoint(otlpEndpoint), otlptracegrpc.WithInsecure())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	res, err := resource.New(ctx, resource.WithAttributes(
+		semconv.ServiceName("driftline-data-service"),
+		semconv.ServiceVersion(serviceVersion()),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build resource: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return &Service{
+		Tracer:   tp.Tracer("driftline-data-service"),
+		shutdown: tp.Shutdown,
+	}, nil
+}
+
+// Shutdown flushes pending spans. It should be deferred by main.
+func (s *Service) Shutdown(ctx context.Context) error {
+	return s.shutdown(ctx)
+}
+
+func serviceVersion() string {
+	if v := os.Getenv("SERVICE_VERSION"); v != "" {
+		return v
+	}
+	return "dev"
+}
+
+// Middleware records http_requests_total and http_request_duration_seconds
+// for every request, and extracts an incoming traceparent header (if any)
+// so downstream spans link to the caller's trace.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		c.Request = c.Request.WithContext(ctx)
+
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		status := strconv.Itoa(c.Writer.Status())
+		httpRequestsTotal.WithLabelValues(route, c.Request.Method, status).Inc()
+		httpRequestDuration.WithLabelValues(route, c.Request.Method).Observe(time.Since(start).Seconds())
+	}
+}
+
+// Handler serves the /metrics endpoint for Prometheus scraping.
+func Handler() gin.HandlerFunc {
+	h := promhttp.Handler()
+	return func(c *gin.Context) {
+		h.ServeHTTP(c.Writer, c.Request)
+	}
+}
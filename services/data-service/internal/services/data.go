@@ -3,32 +3,275 @@ package services
 import (
 	"context"
 	"crypto/sha256"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log"
+	"math"
+	"os"
 	"path/filepath"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/eysteinn/driftline/services/data-service/internal/cache"
+	"github.com/eysteinn/driftline/services/data-service/internal/clients"
 	"github.com/eysteinn/driftline/services/data-service/internal/models"
 	"github.com/eysteinn/driftline/services/data-service/internal/storage"
 )
 
+// subscriberEventBuffer sizes each SubscribeData channel so a burst of
+// Progress events doesn't block runSubscription's broadcast loop on a slow
+// subscriber; a subscriber that falls behind by more than this many events
+// blocks the fetch itself, same as an unbuffered channel would.
+const subscriberEventBuffer = 16
+
+// presignTTL is how long a presigned URL returned by PresignObjectURL stays
+// valid, long enough for a client to start a large NetCDF download without
+// being so long that a leaked URL is a standing access grant.
+const presignTTL = 15 * time.Minute
+
 // DataService handles environmental data retrieval and caching
 type DataService struct {
 	cache   *cache.Service
 	storage *storage.Service
+	clients *clients.DataClientFactory
+
+	inFlightMu sync.Mutex
+	inFlight   map[string]*inFlightFetch
 }
 
 // NewDataService creates a new data service
-func NewDataService(cacheService *cache.Service, storageService *storage.Service) *DataService {
+func NewDataService(cacheService *cache.Service, storageService *storage.Service, clientFactory *clients.DataClientFactory) *DataService {
 	return &DataService{
 		cache:   cacheService,
 		storage: storageService,
+		clients: clientFactory,
 	}
 }
 
-// GetData retrieves environmental data based on the request
+// cacheEntry is what's stored in Redis under a content-addressed cache key:
+// where the fetched data lives in object storage, its metadata, and when it
+// expires (so an invalidation sweep or expired TTL both read the same shape).
+type cacheEntry struct {
+	S3Path    string           `json:"s3_path"`
+	Metadata  *models.Metadata `json:"metadata"`
+	ExpiresAt time.Time        `json:"expires_at"`
+}
+
+// Stage marks where a GetData call currently is, for progress reporting to
+// async job pollers and stream subscribers. The underlying ExternalDataClient
+// interface doesn't report bytes in flight mid-download, so transitions are
+// reported at a per-step granularity rather than continuously.
+type Stage string
+
+const (
+	StageAuth     Stage = "auth"
+	StageSubset   Stage = "subset"
+	StageDownload Stage = "download"
+	StageUpload   Stage = "upload"
+)
+
+// ProgressFunc receives stage transitions as GetDataWithProgress runs.
+// bytesDownloaded is only meaningful once StageDownload has completed.
+type ProgressFunc func(stage Stage, bytesDownloaded int64)
+
+// DataEventKind identifies what a DataEvent from SubscribeData represents.
+type DataEventKind string
+
+const (
+	EventQueued         DataEventKind = "queued"
+	EventSourceSelected DataEventKind = "source_selected"
+	EventProgress       DataEventKind = "progress"
+	EventCached         DataEventKind = "cached"
+	EventCompleted      DataEventKind = "completed"
+	EventError          DataEventKind = "error"
+)
+
+// DataEvent is one update in a SubscribeData stream. Only the fields
+// relevant to Kind are populated: Source for EventSourceSelected, BytesDone/
+// BytesTotal for EventProgress, Response for EventCompleted, Err for
+// EventError.
+type DataEvent struct {
+	Kind       DataEventKind
+	Source     string
+	BytesDone  int64
+	BytesTotal int64
+	Response   *models.DataResponse
+	Err        error
+}
+
+// inFlightFetch tracks a single SubscribeData fetch that's currently running
+// for a cache key, so that concurrent subscribers asking for the same (or
+// grid-equivalent) request are fanned out from one upstream fetch instead of
+// each triggering their own. This is a finer-grained, in-process complement
+// to the job queue's Redis-backed dedup (jobs.Queue.Enqueue keyed on
+// ObjectKey): SubscribeData gives a caller in this process a live event
+// stream without round-tripping through Redis pub/sub, at the cost of not
+// surviving this process restarting mid-fetch - callers that need that
+// survive a restart, or need workers in other processes, should still go
+// through the async job queue.
+type inFlightFetch struct {
+	subscribers []chan DataEvent
+}
+
+// SubscribeData starts (or joins) a fetch for req and returns a channel of
+// DataEvents describing its progress: EventQueued, an EventSourceSelected
+// once a provider is chosen (carrying the data type's default source name,
+// not the name of a fallback provider that serves the request), zero or more
+// EventProgress updates
+// as bytes are downloaded, and a final EventCached, EventCompleted, or
+// EventError. The channel is closed after the terminal event.
+//
+// Concurrent calls for requests that land on the same cache key share a
+// single upstream fetch, each receiving their own channel and therefore
+// their own copy of every event. The broadcast sends to each channel in
+// turn and blocks on a full one, so a subscriber that stops draining its
+// channel stalls the events of every other subscriber once its
+// subscriberEventBuffer slots fill up.
+func (s *DataService) SubscribeData(ctx context.Context, req *models.DataRequest) (<-chan DataEvent, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	ch := make(chan DataEvent, subscriberEventBuffer)
+	cacheKey := s.generateCacheKey(req)
+
+	s.inFlightMu.Lock()
+	if s.inFlight == nil {
+		s.inFlight = make(map[string]*inFlightFetch)
+	}
+	if existing, ok := s.inFlight[cacheKey]; ok {
+		existing.subscribers = append(existing.subscribers, ch)
+		s.inFlightMu.Unlock()
+		return ch, nil
+	}
+	fetch := &inFlightFetch{subscribers: []chan DataEvent{ch}}
+	s.inFlight[cacheKey] = fetch
+	s.inFlightMu.Unlock()
+
+	go s.runSubscription(ctx, req, cacheKey, fetch)
+
+	return ch, nil
+}
+
+// broadcast sends ev to every subscriber currently registered against
+// fetch, taking a snapshot under the lock so a subscriber joining mid-fetch
+// (via SubscribeData) doesn't race the send loop.
+func (s *DataService) broadcast(fetch *inFlightFetch, ev DataEvent) {
+	s.inFlightMu.Lock()
+	subs := append([]chan DataEvent(nil), fetch.subscribers...)
+	s.inFlightMu.Unlock()
+
+	for _, sub := range subs {
+		sub <- ev
+	}
+}
+
+// runSubscription drives one SubscribeData fetch to completion, broadcasting
+// events to fetch's subscribers and removing cacheKey from s.inFlight (and
+// closing every subscriber channel) once it reaches a terminal event. It
+// mirrors GetDataWithProgress's cache/storage/fetch flow, but reports
+// per-byte download progress (via clients.ProgressReportingClient, when the
+// chosen provider supports it) instead of GetDataWithProgress's per-stage
+// granularity.
+func (s *DataService) runSubscription(ctx context.Context, req *models.DataRequest, cacheKey string, fetch *inFlightFetch) {
+	defer func() {
+		s.inFlightMu.Lock()
+		delete(s.inFlight, cacheKey)
+		subs := fetch.subscribers
+		s.inFlightMu.Unlock()
+		for _, sub := range subs {
+			close(sub)
+		}
+	}()
+
+	s.broadcast(fetch, DataEvent{Kind: EventQueued})
+
+	if cached, err := s.cache.Get(ctx, cacheKey); err == nil && cached != "" {
+		var entry cacheEntry
+		if err := json.Unmarshal([]byte(cached), &entry); err == nil {
+			s.broadcast(fetch, DataEvent{Kind: EventCached})
+			resp := s.buildResponse(req, entry.S3Path, entry.Metadata, true)
+			s.broadcast(fetch, DataEvent{Kind: EventCompleted, Response: resp})
+			return
+		}
+		log.Printf("Discarding malformed cache entry for key %s: %v", cacheKey, err)
+	}
+
+	objectKey := s.ObjectKey(req)
+	exists, err := s.storage.Exists(ctx, objectKey)
+	if err != nil {
+		s.broadcast(fetch, DataEvent{Kind: EventError, Err: fmt.Errorf("failed to check storage: %w", err)})
+		return
+	}
+
+	if !exists {
+		if err := s.fetchAndStoreWithEvents(ctx, req, objectKey, fetch); err != nil {
+			s.broadcast(fetch, DataEvent{Kind: EventError, Err: err})
+			return
+		}
+	}
+
+	metadata := s.buildMetadata(req)
+	if err := s.storeCacheEntry(ctx, cacheKey, objectKey, metadata, req.DataType); err != nil {
+		log.Printf("Failed to cache result: %v", err)
+	}
+
+	resp := s.buildResponse(req, objectKey, metadata, false)
+	s.broadcast(fetch, DataEvent{Kind: EventCompleted, Response: resp})
+}
+
+// fetchAndStoreWithEvents is fetchAndStore's SubscribeData counterpart: it
+// reports EventSourceSelected and, for a clients.ProgressReportingClient,
+// live EventProgress updates as the download proceeds rather than a single
+// post-hoc byte count.
+func (s *DataService) fetchAndStoreWithEvents(ctx context.Context, req *models.DataRequest, objectKey string, fetch *inFlightFetch) error {
+	if s.clients == nil {
+		return models.ErrExternalSourceUnavailable
+	}
+
+	client, err := s.clients.GetClient(ctx, req.DataType)
+	if err != nil {
+		return fmt.Errorf("no provider available: %w", err)
+	}
+	s.broadcast(fetch, DataEvent{Kind: EventSourceSelected, Source: getDataSource(req.DataType)})
+
+	var tmpPath string
+	if pc, ok := client.(clients.ProgressReportingClient); ok {
+		tmpPath, err = pc.FetchDataWithProgress(ctx, req, func(bytesDone, bytesTotal int64) {
+			s.broadcast(fetch, DataEvent{Kind: EventProgress, BytesDone: bytesDone, BytesTotal: bytesTotal})
+		})
+	} else {
+		tmpPath, err = client.FetchData(ctx, req)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch data: %w", err)
+	}
+	defer os.Remove(tmpPath)
+
+	if info, statErr := os.Stat(tmpPath); statErr == nil {
+		s.broadcast(fetch, DataEvent{Kind: EventProgress, BytesDone: info.Size(), BytesTotal: info.Size()})
+	}
+
+	if err := s.storage.Upload(ctx, objectKey, tmpPath); err != nil {
+		return fmt.Errorf("failed to upload to storage: %w", err)
+	}
+
+	return nil
+}
+
+// GetData retrieves environmental data based on the request, serving from
+// the content-addressed cache when possible and falling back to storage and
+// then the external provider factory.
 func (s *DataService) GetData(ctx context.Context, req *models.DataRequest) (*models.DataResponse, error) {
+	return s.GetDataWithProgress(ctx, req, func(Stage, int64) {})
+}
+
+// GetDataWithProgress is GetData with stage-transition callbacks, so an
+// async job worker can publish progress to pollers and stream subscribers
+// without GetData's synchronous callers having to care.
+func (s *DataService) GetDataWithProgress(ctx context.Context, req *models.DataRequest, report ProgressFunc) (*models.DataResponse, error) {
 	// Validate request
 	if err := req.Validate(); err != nil {
 		return nil, err
@@ -38,67 +281,253 @@ func (s *DataService) GetData(ctx context.Context, req *models.DataRequest) (*mo
 	cacheKey := s.generateCacheKey(req)
 
 	// Check cache first
-	cachedPath, err := s.cache.Get(ctx, cacheKey)
-	if err == nil && cachedPath != "" {
-		log.Printf("Cache hit for key: %s", cacheKey)
-		return s.buildResponse(req, cachedPath, true)
+	if cached, err := s.cache.Get(ctx, cacheKey); err == nil && cached != "" {
+		var entry cacheEntry
+		if err := json.Unmarshal([]byte(cached), &entry); err == nil {
+			log.Printf("Cache hit for key: %s", cacheKey)
+			return s.buildResponse(req, entry.S3Path, entry.Metadata, true), nil
+		}
+		log.Printf("Discarding malformed cache entry for key %s: %v", cacheKey, err)
 	}
 
 	log.Printf("Cache miss for key: %s", cacheKey)
 
-	// Check if data exists in storage
-	objectKey := s.generateObjectKey(req)
+	// Check if data already exists in storage (e.g. fetched by another request
+	// with slightly different but grid-equivalent bounds)
+	objectKey := s.ObjectKey(req)
 	exists, err := s.storage.Exists(ctx, objectKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check storage: %w", err)
 	}
 
-	var filePath string
-	if exists {
-		log.Printf("Data exists in storage: %s", objectKey)
-		// Data exists in storage, use it
-		filePath = objectKey
-	} else {
-		log.Printf("Data not found, would fetch from external source")
-		// In a full implementation, this would fetch from external sources
-		// For now, return a stub indicating where data would come from
-		return s.buildStubResponse(req)
+	if !exists {
+		if err := s.fetchAndStore(ctx, req, objectKey, report); err != nil {
+			return nil, err
+		}
 	}
 
-	// Cache the result
-	if err := s.cache.Set(ctx, cacheKey, filePath, 24*time.Hour); err != nil {
+	metadata := s.buildMetadata(req)
+	if err := s.storeCacheEntry(ctx, cacheKey, objectKey, metadata, req.DataType); err != nil {
 		log.Printf("Failed to cache result: %v", err)
 		// Continue anyway, caching is not critical
 	}
 
-	return s.buildResponse(req, filePath, false)
+	return s.buildResponse(req, objectKey, metadata, false), nil
 }
 
-// generateCacheKey creates a unique cache key for the request
+// Peek returns a response for req if it's already in the cache or object
+// storage, without fetching from any external provider. The second return
+// value reports whether anything was found. Callers that don't want to
+// block a request on a multi-GB CMEMS/NOAA download use this to decide
+// whether to serve synchronously or hand the request off to the async job
+// queue instead.
+func (s *DataService) Peek(ctx context.Context, req *models.DataRequest) (*models.DataResponse, bool, error) {
+	if err := req.Validate(); err != nil {
+		return nil, false, err
+	}
+
+	cacheKey := s.generateCacheKey(req)
+	if cached, err := s.cache.Get(ctx, cacheKey); err == nil && cached != "" {
+		var entry cacheEntry
+		if err := json.Unmarshal([]byte(cached), &entry); err == nil {
+			return s.buildResponse(req, entry.S3Path, entry.Metadata, true), true, nil
+		}
+		log.Printf("Discarding malformed cache entry for key %s: %v", cacheKey, err)
+	}
+
+	objectKey := s.ObjectKey(req)
+	exists, err := s.storage.Exists(ctx, objectKey)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to check storage: %w", err)
+	}
+	if !exists {
+		return nil, false, nil
+	}
+
+	metadata := s.buildMetadata(req)
+	if err := s.storeCacheEntry(ctx, cacheKey, objectKey, metadata, req.DataType); err != nil {
+		log.Printf("Failed to cache result: %v", err)
+	}
+
+	return s.buildResponse(req, objectKey, metadata, false), true, nil
+}
+
+// PresignObjectURL returns a short-lived URL a client can download req's
+// underlying object from directly, for callers that want the full file
+// (the ?full=true path) instead of a proxied stream.
+func (s *DataService) PresignObjectURL(ctx context.Context, objectKey string) (string, error) {
+	return s.storage.PresignGet(ctx, objectKey, presignTTL)
+}
+
+// ObjectSize returns the size in bytes of req's underlying object, for
+// computing a Content-Range before streaming any of it.
+func (s *DataService) ObjectSize(ctx context.Context, objectKey string) (int64, error) {
+	return s.storage.Stat(ctx, objectKey)
+}
+
+// OpenObjectRange returns a reader over objectKey restricted to rng, or the
+// whole object if rng is nil.
+func (s *DataService) OpenObjectRange(ctx context.Context, objectKey string, rng *storage.ByteRange) (io.ReadCloser, error) {
+	if rng == nil {
+		return s.storage.GetObject(ctx, objectKey)
+	}
+	return s.storage.GetObject(ctx, objectKey, *rng)
+}
+
+// fetchAndStore fetches data from the appropriate external provider and
+// uploads it to object storage at objectKey.
+func (s *DataService) fetchAndStore(ctx context.Context, req *models.DataRequest, objectKey string, report ProgressFunc) error {
+	if s.clients == nil {
+		return models.ErrExternalSourceUnavailable
+	}
+
+	client, err := s.clients.GetClient(ctx, req.DataType)
+	if err != nil {
+		return fmt.Errorf("no provider available: %w", err)
+	}
+
+	report(StageAuth, 0)
+	report(StageSubset, 0)
+	report(StageDownload, 0)
+
+	tmpPath, err := client.FetchData(ctx, req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch data: %w", err)
+	}
+	defer os.Remove(tmpPath)
+
+	var bytesDownloaded int64
+	if info, statErr := os.Stat(tmpPath); statErr == nil {
+		bytesDownloaded = info.Size()
+	}
+	report(StageDownload, bytesDownloaded)
+
+	report(StageUpload, bytesDownloaded)
+	if err := s.storage.Upload(ctx, objectKey, tmpPath); err != nil {
+		return fmt.Errorf("failed to upload to storage: %w", err)
+	}
+
+	return nil
+}
+
+// storeCacheEntry records where the fetched data lives in object storage,
+// with a TTL matching the forecast model's own refresh cadence.
+func (s *DataService) storeCacheEntry(ctx context.Context, cacheKey, objectKey string, metadata *models.Metadata, dataType models.DataType) error {
+	ttl := forecastCadence(dataType)
+	entry := cacheEntry{
+		S3Path:    objectKey,
+		Metadata:  metadata,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache entry: %w", err)
+	}
+
+	return s.cache.SetWithTags(ctx, cacheKey, data, ttl, cache.GenerateDataCacheTags(string(dataType))...)
+}
+
+// InvalidateCache removes the cached entry for a request's content-addressed
+// key, forcing the next GetData call to re-fetch (or re-check storage).
+func (s *DataService) InvalidateCache(ctx context.Context, req *models.DataRequest) error {
+	cacheKey := s.generateCacheKey(req)
+	return s.cache.Delete(ctx, cacheKey)
+}
+
+// InvalidateDataType drops every cached bbox/time slice for dataType in one
+// call, for an ingestion job that just landed a new forecast cycle and
+// needs every previously cached entry for that dataset to miss on its next
+// request - the content-addressed keys generateCacheKey produces make it
+// impossible to know or enumerate those keys ahead of time, which is what
+// the "data:<type>" tag exists to sidestep.
+func (s *DataService) InvalidateDataType(ctx context.Context, dataType models.DataType) error {
+	return s.cache.InvalidateTags(ctx, cache.GenerateDataCacheTags(string(dataType))...)
+}
+
+// forecastCadence returns how often the upstream forecast model for a data
+// type is refreshed: GFS (wind/waves) runs every 6 hours, Copernicus Marine
+// (ocean currents) publishes a new analysis daily. Cache TTLs and time
+// bucketing both key off this, so a cached entry never outlives the forecast
+// cycle it was fetched for.
+func forecastCadence(dataType models.DataType) time.Duration {
+	switch dataType {
+	case models.DataTypeOceanCurrents:
+		return 24 * time.Hour
+	default:
+		return 6 * time.Hour
+	}
+}
+
+// gridResolutionDegrees returns the native grid spacing used to round
+// request bounds for cache keys, so that two requests for the same forecast
+// cell hit the same cache entry.
+func gridResolutionDegrees(dataType models.DataType) float64 {
+	switch dataType {
+	case models.DataTypeOceanCurrents:
+		return 1.0 / 12.0
+	case models.DataTypeWind:
+		return 0.25
+	case models.DataTypeWaves:
+		return 0.5
+	default:
+		return 1.0
+	}
+}
+
+// roundToGrid snaps a coordinate to the nearest multiple of res.
+func roundToGrid(v, res float64) float64 {
+	return math.Round(v/res) * res
+}
+
+// generateCacheKey derives a deterministic, content-addressed cache key from
+// a canonical form of the request: bounds rounded to the provider's native
+// grid resolution, time bucketed to the forecast cycle, and variables
+// sorted, so equivalent requests always collide on the same key.
 func (s *DataService) generateCacheKey(req *models.DataRequest) string {
-	// Create a unique key based on all request parameters
-	key := fmt.Sprintf("%s:%.2f,%.2f,%.2f,%.2f:%s:%s",
-		req.DataType,
-		req.MinLat, req.MaxLat, req.MinLon, req.MaxLon,
-		req.StartTime.Format(time.RFC3339),
-		req.EndTime.Format(time.RFC3339),
-	)
-	
-	// Hash the key to keep it reasonably sized
-	hash := sha256.Sum256([]byte(key))
-	return fmt.Sprintf("data:%s:%x", req.DataType, hash[:8])
-}
-
-// generateObjectKey creates a storage key for the data
-func (s *DataService) generateObjectKey(req *models.DataRequest) string {
-	// Organize by data type and time
+	res := gridResolutionDegrees(req.DataType)
+	cadence := forecastCadence(req.DataType)
+
+	variables := append([]string(nil), req.Variables...)
+	sort.Strings(variables)
+
+	canonical := struct {
+		DataType  models.DataType `json:"data_type"`
+		MinLat    float64         `json:"min_lat"`
+		MaxLat    float64         `json:"max_lat"`
+		MinLon    float64         `json:"min_lon"`
+		MaxLon    float64         `json:"max_lon"`
+		StartTime time.Time       `json:"start_time"`
+		EndTime   time.Time       `json:"end_time"`
+		Variables []string        `json:"variables"`
+	}{
+		DataType:  req.DataType,
+		MinLat:    roundToGrid(req.MinLat, res),
+		MaxLat:    roundToGrid(req.MaxLat, res),
+		MinLon:    roundToGrid(req.MinLon, res),
+		MaxLon:    roundToGrid(req.MaxLon, res),
+		StartTime: req.StartTime.UTC().Truncate(cadence),
+		EndTime:   req.EndTime.UTC().Truncate(cadence),
+		Variables: variables,
+	}
+
+	data, _ := json.Marshal(canonical)
+	hash := sha256.Sum256(data)
+	return fmt.Sprintf("data:%s:%x", req.DataType, hash)
+}
+
+// ObjectKey creates a storage key for the data, organized by data type and
+// time. It's also used as the job queue's deduplication key, since two
+// requests that would land on the same object key are asking for the same
+// upstream fetch.
+func (s *DataService) ObjectKey(req *models.DataRequest) string {
 	dateStr := req.StartTime.Format("2006/01/02")
 	return filepath.Join(string(req.DataType), dateStr, "data.nc")
 }
 
-// buildResponse constructs a data response
-func (s *DataService) buildResponse(req *models.DataRequest, filePath string, cacheHit bool) (*models.DataResponse, error) {
-	metadata := &models.Metadata{
+// buildMetadata constructs the metadata describing a fetched data request.
+func (s *DataService) buildMetadata(req *models.DataRequest) *models.Metadata {
+	return &models.Metadata{
 		Variables:  getDefaultVariables(req.DataType),
 		Resolution: getDefaultResolution(req.DataType),
 		Bounds: models.Bounds{
@@ -112,15 +541,18 @@ func (s *DataService) buildResponse(req *models.DataRequest, filePath string, ca
 			End:   req.EndTime,
 		},
 	}
+}
 
+// buildResponse constructs a data response for data backed by object storage.
+func (s *DataService) buildResponse(req *models.DataRequest, objectKey string, metadata *models.Metadata, cacheHit bool) *models.DataResponse {
 	return &models.DataResponse{
 		DataType:  req.DataType,
 		Source:    getDataSource(req.DataType),
 		CacheHit:  cacheHit,
-		FilePath:  filePath,
+		FilePath:  objectKey,
 		Metadata:  metadata,
-		ExpiresAt: time.Now().Add(24 * time.Hour),
-	}, nil
+		ExpiresAt: time.Now().Add(forecastCadence(req.DataType)),
+	}
 }
 
 // buildStubResponse creates a stub response for demonstration
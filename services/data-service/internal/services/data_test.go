@@ -137,6 +137,89 @@ func TestGetDataSource(t *testing.T) {
 	}
 }
 
+func TestGenerateCacheKey_GridRoundingCollapsesNearbyBounds(t *testing.T) {
+	s := &DataService{}
+
+	// Bounds within the same 0.25 degree GFS grid cell should collapse to the
+	// same cache key.
+	req1 := &models.DataRequest{
+		DataType:  models.DataTypeWind,
+		MinLat:    60.01,
+		MaxLat:    70.01,
+		MinLon:    -20.01,
+		MaxLon:    -10.01,
+		StartTime: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndTime:   time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+	}
+	req2 := &models.DataRequest{
+		DataType:  models.DataTypeWind,
+		MinLat:    60.02,
+		MaxLat:    70.02,
+		MinLon:    -20.02,
+		MaxLon:    -10.02,
+		StartTime: time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC),
+		EndTime:   time.Date(2024, 1, 2, 1, 0, 0, 0, time.UTC),
+	}
+
+	if s.generateCacheKey(req1) != s.generateCacheKey(req2) {
+		t.Error("requests within the same grid cell and forecast cycle should generate the same key")
+	}
+}
+
+func TestGenerateCacheKey_VariableOrderIndependent(t *testing.T) {
+	s := &DataService{}
+
+	base := models.DataRequest{
+		DataType:  models.DataTypeOceanCurrents,
+		MinLat:    60.0,
+		MaxLat:    70.0,
+		MinLon:    -20.0,
+		MaxLon:    -10.0,
+		StartTime: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndTime:   time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+	}
+
+	req1 := base
+	req1.Variables = []string{"uo", "vo"}
+	req2 := base
+	req2.Variables = []string{"vo", "uo"}
+
+	if s.generateCacheKey(&req1) != s.generateCacheKey(&req2) {
+		t.Error("variable order should not affect the cache key")
+	}
+}
+
+func TestForecastCadence(t *testing.T) {
+	if forecastCadence(models.DataTypeOceanCurrents) != 24*time.Hour {
+		t.Error("expected Copernicus ocean currents cadence to be 24h")
+	}
+	if forecastCadence(models.DataTypeWind) != 6*time.Hour {
+		t.Error("expected GFS wind cadence to be 6h")
+	}
+	if forecastCadence(models.DataTypeWaves) != 6*time.Hour {
+		t.Error("expected WaveWatch III cadence to be 6h")
+	}
+}
+
+func TestSubscribeDataValidation(t *testing.T) {
+	s := &DataService{}
+	ctx := context.Background()
+
+	invalidReq := &models.DataRequest{
+		DataType:  models.DataTypeOceanCurrents,
+		MinLat:    70.0,
+		MaxLat:    60.0,
+		MinLon:    -20.0,
+		MaxLon:    -10.0,
+		StartTime: time.Now(),
+		EndTime:   time.Now().Add(24 * time.Hour),
+	}
+
+	if _, err := s.SubscribeData(ctx, invalidReq); err == nil {
+		t.Error("Expected error for invalid request, got nil")
+	}
+}
+
 func TestDataServiceValidation(t *testing.T) {
 	// Test that service properly validates requests
 	s := &DataService{}
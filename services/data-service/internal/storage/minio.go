@@ -4,9 +4,13 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"time"
 
+	"github.com/eysteinn/driftline/services/data-service/internal/observability"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
 )
@@ -64,14 +68,16 @@ func NewService(endpoint, accessKey, secretKey string) (*Service, error) {
 
 // Upload uploads a file to object storage
 func (s *Service) Upload(ctx context.Context, objectName, filePath string) error {
+	start := time.Now()
 	info, err := s.client.FPutObject(ctx, s.bucket, objectName, filePath, minio.PutObjectOptions{
 		ContentType: "application/octet-stream",
 	})
+	observability.StorageOperationDuration.WithLabelValues("upload").Observe(time.Since(start).Seconds())
 	if err != nil {
 		return fmt.Errorf("failed to upload file: %w", err)
 	}
-	
-	_ = info // Suppress unused warning
+
+	observability.StorageUploadBytesTotal.WithLabelValues(s.bucket).Add(float64(info.Size))
 	return nil
 }
 
@@ -83,7 +89,9 @@ func (s *Service) Download(ctx context.Context, objectName, destPath string) err
 		return fmt.Errorf("failed to create destination directory: %w", err)
 	}
 
+	start := time.Now()
 	err := s.client.FGetObject(ctx, s.bucket, objectName, destPath, minio.GetObjectOptions{})
+	observability.StorageOperationDuration.WithLabelValues("download").Observe(time.Since(start).Seconds())
 	if err != nil {
 		return fmt.Errorf("failed to download file: %w", err)
 	}
@@ -104,18 +112,88 @@ func (s *Service) Exists(ctx context.Context, objectName string) (bool, error) {
 	return true, nil
 }
 
-// GetObject returns a reader for the object
-func (s *Service) GetObject(ctx context.Context, objectName string) (io.ReadCloser, error) {
-	obj, err := s.client.GetObject(ctx, s.bucket, objectName, minio.GetObjectOptions{})
+// Stat returns an object's size in bytes, for callers that need to compute
+// a Content-Range (e.g. a Range-request streaming proxy) before reading it.
+func (s *Service) Stat(ctx context.Context, objectName string) (int64, error) {
+	info, err := s.client.StatObject(ctx, s.bucket, objectName, minio.StatObjectOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat object: %w", err)
+	}
+	return info.Size, nil
+}
+
+// ByteRange requests a byte range of an object, mirroring io.SectionReader's
+// (offset, length) pair: bytes [Offset, Offset+Length) are fetched instead
+// of the whole object.
+type ByteRange struct {
+	Offset int64
+	Length int64
+}
+
+// GetObject returns a reader for the object. Passing a ByteRange makes the
+// server transfer only that slice, e.g. for a client paging through a large
+// NetCDF file without downloading it in full.
+func (s *Service) GetObject(ctx context.Context, objectName string, rng ...ByteRange) (io.ReadCloser, error) {
+	opts := minio.GetObjectOptions{}
+	if len(rng) > 0 {
+		if err := opts.SetRange(rng[0].Offset, rng[0].Offset+rng[0].Length-1); err != nil {
+			return nil, fmt.Errorf("invalid byte range: %w", err)
+		}
+	}
+
+	obj, err := s.client.GetObject(ctx, s.bucket, objectName, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get object: %w", err)
 	}
 	return obj, nil
 }
 
+// PresignGet returns a short-lived URL a client can download objectName
+// from directly, without the bytes flowing through this process.
+func (s *Service) PresignGet(ctx context.Context, objectName string, ttl time.Duration) (string, error) {
+	u, err := s.client.PresignedGetObject(ctx, s.bucket, objectName, ttl, url.Values{})
+	if err != nil {
+		return "", fmt.Errorf("failed to presign GET: %w", err)
+	}
+	return u.String(), nil
+}
+
+// PresignPut returns a short-lived URL a caller (e.g. a drift-simulation
+// worker) can upload objectName to directly. contentType is signed into
+// the URL as a required query parameter, so the caller's PUT must set a
+// matching Content-Type header.
+func (s *Service) PresignPut(ctx context.Context, objectName string, ttl time.Duration, contentType string) (string, error) {
+	reqParams := url.Values{}
+	reqParams.Set("Content-Type", contentType)
+
+	u, err := s.client.Presign(ctx, http.MethodPut, s.bucket, objectName, ttl, reqParams)
+	if err != nil {
+		return "", fmt.Errorf("failed to presign PUT: %w", err)
+	}
+	return u.String(), nil
+}
+
+// UploadMultipart streams r into objectName using a tuned PartSize so
+// large NetCDF results upload as concurrent parts instead of one long
+// single-stream PUT. size is the total length of r if known, or -1 if not
+// (minio-go buffers a part at a time either way).
+func (s *Service) UploadMultipart(ctx context.Context, objectName string, r io.Reader, size int64, partSize uint64) error {
+	_, err := s.client.PutObject(ctx, s.bucket, objectName, r, size, minio.PutObjectOptions{
+		ContentType: "application/octet-stream",
+		PartSize:    partSize,
+		NumThreads:  4,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload multipart: %w", err)
+	}
+	return nil
+}
+
 // Delete removes an object from storage
 func (s *Service) Delete(ctx context.Context, objectName string) error {
+	start := time.Now()
 	err := s.client.RemoveObject(ctx, s.bucket, objectName, minio.RemoveObjectOptions{})
+	observability.StorageOperationDuration.WithLabelValues("delete").Observe(time.Since(start).Seconds())
 	if err != nil {
 		return fmt.Errorf("failed to delete object: %w", err)
 	}